@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+)
+
+// pageSeparator follows each page when printing pages separately.
+const pageSeparator = "\f"
+
+func CatCommand() *cobra.Command {
+	var pages string
+	cmd := &cobra.Command{
+		Use:   "cat [file|pattern]...",
+		Short: "Print the text of eocr files",
+		Long:  "Print the text of eocr files. With --pages, print only the selected pages, each followed by a form feed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var ranges pageRanges
+			if cmd.Flags().Changed("pages") {
+				var err error
+				if ranges, err = parsePageRanges(pages); err != nil {
+					return err
+				}
+			}
+			return forEachInput(cmd, args, func(name string, r *bufio.Reader) error {
+				doc, err := readDocument(r)
+				if err != nil {
+					return err
+				}
+				out := cmd.OutOrStdout()
+				if ranges == nil {
+					_, err := io.WriteString(out, eocr.Text(doc))
+					return err
+				}
+				for _, page := range ranges.pages(len(doc.Pages)) {
+					text, err := eocr.PageText(doc, page)
+					if err != nil {
+						return err
+					}
+					if _, err := fmt.Fprint(out, text, pageSeparator); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&pages, "pages", "", "pages to print, e.g. 1-3,5 or 4- (numbered from 1)")
+	return cmd
+}
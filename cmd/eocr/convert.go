@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/filetype"
+)
+
+func ConvertCommand() *cobra.Command {
+	var output, to string
+	cmd := &cobra.Command{
+		Use:   "convert [file]",
+		Short: "Convert between eocr and kiraocr files",
+		Long:  "Convert an eocr file to the legacy kiraocr format, or back. The checksum is verified and only the header is rewritten, so the compressed document is copied unchanged.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := parseFormat(to)
+			if err != nil {
+				return err
+			}
+			name := stdinName
+			if len(args) > 0 {
+				name = args[0]
+			}
+			in, err := openInput(name)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			data, err := io.ReadAll(in)
+			if err != nil {
+				return err
+			}
+			converted, err := eocr.Convert(data, eocr.MarshalOptions{Format: format})
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			return writeOutput(cmd, output, func(w io.Writer) error {
+				_, err := w.Write(converted)
+				return err
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", stdinName, "file to write, - for standard output")
+	cmd.Flags().StringVar(&to, "to", filetype.EOCR.String(), "format to write, eocr or kiraocr")
+	return cmd
+}
+
+// parseFormat returns the file type written by convert with the given name.
+func parseFormat(name string) (filetype.Type, error) {
+	for _, t := range []filetype.Type{filetype.EOCR, filetype.KiraOCR} {
+		if name == t.String() {
+			return t, nil
+		}
+	}
+	return filetype.Unknown, fmt.Errorf("unsupported format %q, want eocr or kiraocr", name)
+}
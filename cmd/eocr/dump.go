@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bufio"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+)
+
+func DumpCommand() *cobra.Command {
+	var compact bool
+	cmd := &cobra.Command{
+		Use:   "dump [file|pattern]...",
+		Short: "Print eocr files as JSON",
+		Long:  "Print each eocr file as a JSON document that the load command converts back to eocr.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			marshal := eocr.MarshalJSON
+			if compact {
+				marshal = eocr.MarshalCompactJSON
+			}
+			return forEachInput(cmd, args, func(name string, r *bufio.Reader) error {
+				doc, err := readDocument(r)
+				if err != nil {
+					return err
+				}
+				out, err := marshal(doc)
+				if err != nil {
+					return err
+				}
+				_, err = cmd.OutOrStdout().Write(out)
+				return err
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&compact, "compact", false, "render characters as runs of text with arrays of bounding boxes")
+	return cmd
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+)
+
+func InfoCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "info [file|pattern]...",
+		Short: "Print a summary of eocr files",
+		Long:  "Print the file type, version, source, md5 and the number of pages, characters, tables and fonts of eocr files. For edoc and kiradoc files, also print the number of lines, sentences, headers and footers and the language.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			first := true
+			return forEachInput(cmd, args, func(name string, r *bufio.Reader) error {
+				info, err := readInfo(r)
+				if err != nil {
+					return err
+				}
+				if !first {
+					fmt.Fprintln(cmd.OutOrStdout())
+				}
+				first = false
+				fmt.Fprintf(cmd.OutOrStdout(), "file: %s\n%s", name, info)
+				return nil
+			})
+		},
+	}
+}
+
+// readInfo decodes a document from r and returns its summary.
+func readInfo(r *bufio.Reader) (string, error) {
+	in, err := decodeInput(r)
+	if err != nil {
+		return "", err
+	}
+	doc := in.doc
+	source := doc.Source
+	if source == eocr.Empty {
+		source = "(empty)"
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "type: %s\n", in.format)
+	fmt.Fprintf(&b, "version: %d\n", doc.Version)
+	fmt.Fprintf(&b, "source: %s\n", source)
+	fmt.Fprintf(&b, "pages: %d\n", len(doc.Pages))
+	fmt.Fprintf(&b, "characters: %d\n", len(doc.Characters))
+	fmt.Fprintf(&b, "tables: %d\n", len(doc.Tables))
+	fmt.Fprintf(&b, "table cells: %d\n", len(doc.TableCells))
+	fmt.Fprintf(&b, "fonts: %d\n", len(doc.Fonts))
+	fmt.Fprintf(&b, "font sizes: %d\n", len(doc.FontSizes))
+	fmt.Fprintf(&b, "font styles: %d\n", len(doc.FontStyles))
+	fmt.Fprintf(&b, "md5: %x\n", doc.Md5)
+	if p := in.prepared; p != nil {
+		fmt.Fprintf(&b, "lines: %d\n", len(p.Lines))
+		fmt.Fprintf(&b, "sentences: %d\n", len(p.Sentences))
+		fmt.Fprintf(&b, "headers: %d\n", len(p.Headers))
+		fmt.Fprintf(&b, "footers: %d\n", len(p.Footers))
+		if p.Language != nil {
+			fmt.Fprintf(&b, "language: %s (%.2f)\n", p.Language.Code, p.Language.Confidence)
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zuvaai/eocr-utils/pkg/edoc"
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/filetype"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// stdinName is the file name that stands for standard input, or standard
+// output when writing.
+const stdinName = "-"
+
+// expandInputs returns the files named by args, expanding glob patterns. No
+// arguments means standard input.
+func expandInputs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{stdinName}, nil
+	}
+	var names []string
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			// Not a pattern, or a pattern that matches nothing: keep it so
+			// that opening it reports a useful error.
+			matches = []string{arg}
+		}
+		names = append(names, matches...)
+	}
+	return names, nil
+}
+
+// openInput opens the named file, or standard input for stdinName.
+func openInput(name string) (io.ReadCloser, error) {
+	if name == stdinName {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
+
+// forEachInput calls fn with a buffered reader for every file named by args.
+// A failure on one file is reported and the remaining files are still
+// processed, so a single bad file doesn't stop a batch.
+func forEachInput(cmd *cobra.Command, args []string, fn func(name string, r *bufio.Reader) error) error {
+	names, err := expandInputs(args)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, name := range names {
+		if err := processInput(name, fn); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", name, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(names))
+	}
+	return nil
+}
+
+func processInput(name string, fn func(name string, r *bufio.Reader) error) error {
+	in, err := openInput(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	return fn(name, bufio.NewReader(in))
+}
+
+// writeOutput calls fn with the named file, or standard output for stdinName.
+// The file is only created once fn has something to write to it.
+func writeOutput(cmd *cobra.Command, name string, fn func(w io.Writer) error) error {
+	if name == stdinName {
+		return fn(cmd.OutOrStdout())
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// input is a decoded input file.
+type input struct {
+	format filetype.Type
+	// doc is the document, or the OCR layer of a prepared document.
+	doc *ocr.Document
+	// prepared is set for prepared documents.
+	prepared *edoc.Document
+}
+
+// decodeInput decodes an eocr file, or a prepared document file, from r.
+func decodeInput(r *bufio.Reader) (*input, error) {
+	t, err := inferType(r)
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case filetype.EOCR, filetype.KiraOCR:
+		doc, err := eocr.NewDecoder(r).Decode()
+		if err != nil {
+			return nil, err
+		}
+		return &input{format: t, doc: doc}, nil
+	case filetype.EDoc, filetype.KiraDoc:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		prepared, err := edoc.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		if prepared.OCR == nil {
+			return nil, fmt.Errorf("%s file has no OCR layer", t)
+		}
+		return &input{format: t, doc: prepared.OCR, prepared: prepared}, nil
+	}
+	return nil, fmt.Errorf("unsupported file type %s", t)
+}
+
+// readDocument decodes the document in r, or the OCR layer of a prepared
+// document.
+func readDocument(r *bufio.Reader) (*ocr.Document, error) {
+	in, err := decodeInput(r)
+	if err != nil {
+		return nil, err
+	}
+	return in.doc, nil
+}
+
+// inferType identifies the type of the file in r by its header without
+// consuming it.
+func inferType(r *bufio.Reader) (filetype.Type, error) {
+	header, err := r.Peek(filetype.HeaderLength)
+	if err != nil && len(header) < filetype.HeaderLength {
+		return filetype.Unknown, eocr.ErrTooSmall
+	}
+	if t := filetype.InferBytes(header); t != filetype.Unknown {
+		return t, nil
+	}
+	return filetype.Unknown, eocr.ErrInvalidHeader
+}
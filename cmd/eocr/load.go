@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+)
+
+func LoadCommand() *cobra.Command {
+	var output string
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "load [file]",
+		Short: "Convert JSON to an eocr file",
+		Long:  "Convert a JSON document written by the dump command, compact or not, to an eocr file with a freshly computed checksum. The document is validated first.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := stdinName
+			if len(args) > 0 {
+				name = args[0]
+			}
+			in, err := openInput(name)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			data, err := io.ReadAll(in)
+			if err != nil {
+				return err
+			}
+			doc, err := eocr.UnmarshalJSON(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			errs := eocr.Validate(doc)
+			for _, err := range errs {
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", name, err)
+			}
+			if eocr.HasErrors(errs) && !force {
+				return fmt.Errorf("%s: invalid document", name)
+			}
+			return writeOutput(cmd, output, func(w io.Writer) error {
+				return eocr.NewEncoder(w).Encode(doc)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", stdinName, "file to write, - for standard output")
+	cmd.Flags().BoolVar(&force, "force", false, "write the document even if it is invalid")
+	return cmd
+}
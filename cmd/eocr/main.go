@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var Main = &cobra.Command{
+	Use:          "eocr",
+	Short:        "Inspect eocr files",
+	Long:         "Inspect eocr files. Commands read standard input when no file is given or the file is -, and expand glob patterns themselves so they can be quoted to avoid argument limits.",
+	SilenceUsage: true,
+}
+
+func init() {
+	Main.AddCommand(
+		InfoCommand(),
+		VerifyCommand(),
+		CatCommand(),
+		DumpCommand(),
+		LoadCommand(),
+		ConvertCommand(),
+		RenderCommand(),
+		SplitCommand(),
+		MergeCommand(),
+	)
+}
+
+func main() {
+	if err := Main.Execute(); err != nil {
+		os.Exit(-1)
+	}
+}
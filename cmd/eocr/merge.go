@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func MergeCommand() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "merge file...",
+		Short: "Merge eocr files into one",
+		Long:  "Merge eocr files, or the OCR layers of prepared documents, into a single eocr file holding their pages in the order given, written to standard output or --output. Spans are moved to the merged characters, tables are renumbered to keep their ids unique, and the md5 of the merged file is derived from the md5s of the inputs.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := expandInputs(args)
+			if err != nil {
+				return err
+			}
+			var docs []*ocr.Document
+			for _, name := range names {
+				if err := processInput(name, func(name string, r *bufio.Reader) error {
+					doc, err := readDocument(r)
+					if err != nil {
+						return err
+					}
+					docs = append(docs, doc)
+					return nil
+				}); err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+			}
+			merged, err := eocr.Merge(docs...)
+			if err != nil {
+				return err
+			}
+			return writeOutput(cmd, output, func(w io.Writer) error {
+				return eocr.NewEncoder(w).Encode(merged)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", stdinName, "file to write, or - for standard output")
+	return cmd
+}
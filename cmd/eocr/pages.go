@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pageRange is an inclusive range of page indexes starting from 0. An end of
+// -1 means the last page.
+type pageRange struct {
+	start, end int
+}
+
+// pageRanges is a list of page ranges as given on the command line.
+type pageRanges []pageRange
+
+// parsePageRanges parses a comma separated list of page numbers and ranges
+// numbered from 1, e.g. "1-3,5,8-".
+func parsePageRanges(s string) (pageRanges, error) {
+	var ranges pageRanges
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := parsePageNumber(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page range %q: %w", part, err)
+		}
+		end := start
+		if isRange {
+			end = -1
+			if to != "" {
+				if end, err = parsePageNumber(to); err != nil {
+					return nil, fmt.Errorf("invalid page range %q: %w", part, err)
+				}
+				if end < start {
+					return nil, fmt.Errorf("invalid page range %q: end is before start", part)
+				}
+			}
+		}
+		ranges = append(ranges, pageRange{start: start, end: end})
+	}
+	return ranges, nil
+}
+
+func parsePageNumber(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a page number", s)
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("pages are numbered from 1")
+	}
+	return n - 1, nil
+}
+
+// pages returns the indexes of the selected pages of a document with
+// numPages pages, ignoring pages past the end.
+func (rs pageRanges) pages(numPages int) []int {
+	var pages []int
+	for _, r := range rs {
+		end := r.end
+		if end < 0 || end >= numPages {
+			end = numPages - 1
+		}
+		for p := r.start; p <= end; p++ {
+			pages = append(pages, p)
+		}
+	}
+	return pages
+}
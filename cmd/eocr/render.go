@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+	"github.com/zuvaai/eocr-utils/pkg/render"
+)
+
+func RenderCommand() *cobra.Command {
+	var pages, format, output string
+	var opts render.Options
+	cmd := &cobra.Command{
+		Use:   "render [file]",
+		Short: "Draw the pages of an eocr file",
+		Long:  "Draw the pages of an eocr file, or the OCR layer of a prepared document, as PNG or SVG images named <output>-<page>.<format>, with pages numbered from 1. The output prefix defaults to the input file name without its extension. Characters are drawn at their bounding boxes over the shading and borders of table cells, and the boxes of characters, words and lines can be outlined.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var write func(w io.Writer, doc *ocr.Document, page int, opts render.Options) error
+			switch format {
+			case "png":
+				write = render.WritePNG
+			case "svg":
+				write = render.WriteSVG
+			default:
+				return fmt.Errorf("unsupported format %q, want png or svg", format)
+			}
+			ranges := pageRanges{{start: 0, end: -1}}
+			if cmd.Flags().Changed("pages") {
+				var err error
+				if ranges, err = parsePageRanges(pages); err != nil {
+					return err
+				}
+			}
+			name := stdinName
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if output == "" {
+				output = "page"
+				if name != stdinName {
+					output = strings.TrimSuffix(name, filepath.Ext(name))
+				}
+			}
+			in, err := openInput(name)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			doc, err := readDocument(bufio.NewReader(in))
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			for _, page := range ranges.pages(len(doc.Pages)) {
+				filename := fmt.Sprintf("%s-%d.%s", output, page+1, format)
+				if err := writeOutput(cmd, filename, func(w io.Writer) error {
+					return write(w, doc, page, opts)
+				}); err != nil {
+					return fmt.Errorf("%s: %w", filename, err)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&pages, "pages", "", "pages to draw, e.g. 1-3,5 or 4- (numbered from 1), all by default")
+	cmd.Flags().StringVar(&format, "format", "png", "image format, png or svg")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "prefix of the image files")
+	cmd.Flags().Float64Var(&opts.Scale, "scale", 1, "size of the images relative to the pages")
+	cmd.Flags().BoolVar(&opts.CharBoxes, "chars", false, "outline the boxes of characters in red")
+	cmd.Flags().BoolVar(&opts.WordBoxes, "words", false, "outline the boxes of words in green")
+	cmd.Flags().BoolVar(&opts.LineBoxes, "lines", false, "outline the boxes of lines in blue")
+	cmd.Flags().BoolVar(&opts.Heatmap, "heatmap", false, "color characters by their error, from green to red")
+	return cmd
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func SplitCommand() *cobra.Command {
+	var pages, output string
+	var every int
+	cmd := &cobra.Command{
+		Use:   "split [file]",
+		Short: "Split an eocr file into page ranges",
+		Long:  "Split an eocr file, or the OCR layer of a prepared document, into eocr files holding the page ranges given by --pages, or every --every pages. The files are named <output>-<first>-<last>.eocr, with pages numbered from 1, and the output prefix defaults to the input file name without its extension. Spans are moved to the characters of each file, tables are renumbered, and the md5 of each file is derived from the md5 of the input and its page range. Page ranges without characters, such as blank scanned pages, can't be written on their own and are skipped with a warning.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("pages") == cmd.Flags().Changed("every") {
+				return errors.New("give either --pages or --every")
+			}
+			var ranges pageRanges
+			if cmd.Flags().Changed("pages") {
+				var err error
+				if ranges, err = parsePageRanges(pages); err != nil {
+					return err
+				}
+			}
+			name := stdinName
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if output == "" {
+				output = "split"
+				if name != stdinName {
+					output = strings.TrimSuffix(name, filepath.Ext(name))
+				}
+			}
+			in, err := openInput(name)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			doc, err := readDocument(bufio.NewReader(in))
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+
+			var parts []*ocr.Document
+			var firstPages []int
+			skip := func(first, last int) {
+				// Blank pages, such as scans, can't be written on their own.
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s: skipping pages %d-%d, which have no characters\n", name, first, last)
+			}
+			if ranges == nil {
+				all, err := eocr.SplitEvery(doc, every)
+				if err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				for i, part := range all {
+					if part == nil {
+						last := (i + 1) * every
+						if last > len(doc.Pages) {
+							last = len(doc.Pages)
+						}
+						skip(i*every+1, last)
+						continue
+					}
+					parts = append(parts, part)
+					firstPages = append(firstPages, i*every)
+				}
+			}
+			for _, r := range ranges {
+				if r.start >= len(doc.Pages) {
+					return fmt.Errorf("%s: page %d does not exist in a document of %d pages", name, r.start+1, len(doc.Pages))
+				}
+				end := r.end + 1
+				if r.end < 0 || end > len(doc.Pages) {
+					end = len(doc.Pages)
+				}
+				part, err := eocr.SliceDocument(doc, r.start, end)
+				if errors.Is(err, eocr.ErrEmptyDocument) {
+					skip(r.start+1, end)
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				parts = append(parts, part)
+				firstPages = append(firstPages, r.start)
+			}
+
+			for i, part := range parts {
+				first := firstPages[i] + 1
+				filename := fmt.Sprintf("%s-%d-%d.eocr", output, first, first+len(part.Pages)-1)
+				if err := writeOutput(cmd, filename, func(w io.Writer) error {
+					return eocr.NewEncoder(w).Encode(part)
+				}); err != nil {
+					return fmt.Errorf("%s: %w", filename, err)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&pages, "pages", "", "page ranges to write, each to its own file, e.g. 1-4,5-12 or 13- (numbered from 1)")
+	cmd.Flags().IntVar(&every, "every", 0, "write a file for every n pages")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "prefix of the files to write")
+	return cmd
+}
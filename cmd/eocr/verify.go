@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+)
+
+func VerifyCommand() *cobra.Command {
+	var strict, quiet bool
+	cmd := &cobra.Command{
+		Use:   "verify [file|pattern]...",
+		Short: "Verify eocr files",
+		Long:  "Verify the header and checksum of eocr files and check their structure. Exits with a non-zero status if any file fails.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return forEachInput(cmd, args, func(name string, r *bufio.Reader) error {
+				doc, err := readDocument(r)
+				if err != nil {
+					return err
+				}
+				errs := eocr.Validate(doc)
+				for _, err := range errs {
+					fmt.Fprintf(cmd.ErrOrStderr(), "%s: %v\n", name, err)
+				}
+				if eocr.HasErrors(errs) || (strict && len(errs) > 0) {
+					return fmt.Errorf("invalid document")
+				}
+				if !quiet {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: ok\n", name)
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&strict, "strict", false, "fail on warnings as well as errors")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "only report files that fail")
+	return cmd
+}
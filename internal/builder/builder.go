@@ -0,0 +1,334 @@
+// Package builder assembles an ocr.Document from the pages, lines and words
+// found by an OCR engine or read from another format.
+//
+// Characters are linearized the way Omnipage does it: words on a line are
+// separated by a space whose bounding box spans the gap between them, and
+// each line ends with a zero width space at the right edge of its last word.
+// Paragraphs need no special treatment, the space ending their last line is
+// enough.
+package builder
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// documentVersion is the version of the documents built.
+const documentVersion = 3
+
+// Glyph is a single recognized character.
+type Glyph struct {
+	Rune rune
+	// Box is the bounding box of the glyph, or nil if it isn't known.
+	Box *ocr.BoundingBox
+	// Error is the error of the glyph, from 0 for full confidence to 100
+	// for no confidence.
+	Error uint32
+}
+
+// Style is the typography of a word. The zero value means nothing is known
+// about it.
+type Style struct {
+	// Font is the name of the font.
+	Font      string
+	Serif     bool
+	Monospace bool
+	// Size is the size of the font in points, or 0 if it isn't known.
+	Size uint32
+	// Styles are the font styles of the word, such as ocr.BOLD.
+	Styles []ocr.FontStyle_Style
+}
+
+// Builder builds a document. Start a page with Page, then add the words of
+// each line with Word and end it with EndLine.
+type Builder struct {
+	doc  *ocr.Document
+	page *ocr.Page
+	// last is the last glyph of the current line, or nil at the start of a
+	// line.
+	last *ocr.Character
+	// lastStyle is the style of the last word.
+	lastStyle Style
+	// styles holds the open font style span for each style.
+	styles map[ocr.FontStyle_Style]*ocr.FontStyle
+}
+
+// New returns an empty builder.
+func New() *Builder {
+	return &Builder{
+		doc:    &ocr.Document{Version: documentVersion},
+		styles: map[ocr.FontStyle_Style]*ocr.FontStyle{},
+	}
+}
+
+// Len returns the number of characters added so far, which is the index of
+// the next character.
+func (b *Builder) Len() uint32 {
+	return uint32(len(b.doc.Characters))
+}
+
+// Pages returns the number of pages added so far.
+func (b *Builder) Pages() int {
+	return len(b.doc.Pages)
+}
+
+// Page ends the current page and starts a new one of the given size in
+// pixels and resolution in pixels per inch.
+func (b *Builder) Page(width, height, dpiX, dpiY uint32) {
+	b.EndLine()
+	b.page = &ocr.Page{
+		CharacterSpan: &ocr.Span{Start: b.Len(), End: b.Len()},
+		Width:         width,
+		Height:        height,
+		DpiX:          dpiX,
+		DpiY:          dpiY,
+	}
+	b.doc.Pages = append(b.doc.Pages, b.page)
+}
+
+// Word adds a word to the current line, preceded by a space if it isn't the
+// first word of the line. Boxes are clipped to the page. Runes outside the
+// Basic Multilingual Plane become a surrogate pair sharing the glyph's box.
+func (b *Builder) Word(glyphs []Glyph, style Style) {
+	if len(glyphs) == 0 {
+		return
+	}
+	if b.last != nil {
+		b.space(gap(b.last.BoundingBox, b.clip(glyphs[0].Box)))
+	}
+	b.Append(glyphs, style)
+}
+
+// Append adds glyphs to the end of the current word without a space, for words
+// whose glyphs don't share a style.
+func (b *Builder) Append(glyphs []Glyph, style Style) {
+	if len(glyphs) == 0 {
+		return
+	}
+	if b.page == nil {
+		b.Page(0, 0, 0, 0)
+	}
+	for _, g := range glyphs {
+		box := b.clip(g.Box)
+		if r1, r2 := utf16.EncodeRune(g.Rune); r1 != unicode.ReplacementChar {
+			b.add(&ocr.Character{Unicode: uint32(r1), Error: g.Error, BoundingBox: box}, style)
+			b.add(&ocr.Character{Unicode: uint32(r2), Error: g.Error, BoundingBox: copyBox(box)}, style)
+		} else {
+			b.add(&ocr.Character{Unicode: uint32(g.Rune), Error: g.Error, BoundingBox: box}, style)
+		}
+	}
+	b.lastStyle = style
+}
+
+// EndLine ends the current line with a zero width space after its last word.
+// It does nothing if the line is empty.
+func (b *Builder) EndLine() {
+	if b.last == nil {
+		return
+	}
+	var box *ocr.BoundingBox
+	if last := b.last.BoundingBox; last != nil {
+		box = &ocr.BoundingBox{X1: last.X2, Y1: last.Y1, X2: last.X2, Y2: last.Y2}
+	}
+	b.space(box)
+	b.last = nil
+}
+
+// space adds a space with the given box and the style of the last word.
+func (b *Builder) space(box *ocr.BoundingBox) {
+	b.add(&ocr.Character{Unicode: ' ', BoundingBox: box}, b.lastStyle)
+}
+
+// add appends c to the current page and applies style to it.
+func (b *Builder) add(c *ocr.Character, style Style) {
+	i := b.Len()
+	b.doc.Characters = append(b.doc.Characters, c)
+	b.page.CharacterSpan.End = b.Len()
+	b.last = c
+	if style.Font != "" || style.Serif || style.Monospace {
+		if n := len(b.doc.Fonts); n > 0 && sameFont(b.doc.Fonts[n-1], style) && b.doc.Fonts[n-1].CharacterSpan.End == i {
+			b.doc.Fonts[n-1].CharacterSpan.End++
+		} else {
+			b.doc.Fonts = append(b.doc.Fonts, &ocr.Font{
+				CharacterSpan: &ocr.Span{Start: i, End: i + 1},
+				Name:          style.Font,
+				Serif:         style.Serif,
+				Monospace:     style.Monospace,
+			})
+		}
+	}
+	if style.Size > 0 {
+		if n := len(b.doc.FontSizes); n > 0 && b.doc.FontSizes[n-1].Size_ == style.Size && b.doc.FontSizes[n-1].CharacterSpan.End == i {
+			b.doc.FontSizes[n-1].CharacterSpan.End++
+		} else {
+			b.doc.FontSizes = append(b.doc.FontSizes, &ocr.FontSize{CharacterSpan: &ocr.Span{Start: i, End: i + 1}, Size_: style.Size})
+		}
+	}
+	for _, s := range style.Styles {
+		if open := b.styles[s]; open != nil && open.CharacterSpan.End == i {
+			open.CharacterSpan.End++
+			continue
+		}
+		open := &ocr.FontStyle{CharacterSpan: &ocr.Span{Start: i, End: i + 1}, Style: s}
+		b.doc.FontStyles = append(b.doc.FontStyles, open)
+		b.styles[s] = open
+	}
+}
+
+func sameFont(f *ocr.Font, style Style) bool {
+	return f.Name == style.Font && f.Serif == style.Serif && f.Monospace == style.Monospace
+}
+
+// Table adds a table on the page with the given index and returns its id.
+func (b *Builder) Table(page uint32) uint32 {
+	id := uint32(len(b.doc.Tables))
+	b.doc.Tables = append(b.doc.Tables, &ocr.Table{Id: id, PageNumber: page})
+	return id
+}
+
+// Cell adds a cell with the given box to the table with the given id. The box
+// is clipped to the current page.
+func (b *Builder) Cell(table uint32, box *ocr.BoundingBox) *ocr.TableCell {
+	cell := &ocr.TableCell{Id: table, BoundingBox: b.clip(box)}
+	b.doc.TableCells = append(b.doc.TableCells, cell)
+	return cell
+}
+
+// Document ends the current line and returns the document with the given md5
+// and source.
+func (b *Builder) Document(md5 []byte, source string) *ocr.Document {
+	b.EndLine()
+	b.doc.Md5 = md5
+	b.doc.Source = source
+	return b.doc
+}
+
+// clip returns a copy of box clipped to the current page. Pages of unknown
+// size don't clip.
+func (b *Builder) clip(box *ocr.BoundingBox) *ocr.BoundingBox {
+	if box == nil {
+		return nil
+	}
+	c := copyBox(box)
+	if b.page == nil {
+		return c
+	}
+	if w := b.page.Width; w > 0 {
+		c.X1, c.X2 = clamp(c.X1, w), clamp(c.X2, w)
+	}
+	if h := b.page.Height; h > 0 {
+		c.Y1, c.Y2 = clamp(c.Y1, h), clamp(c.Y2, h)
+	}
+	return c
+}
+
+func clamp(v, max uint32) uint32 {
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func copyBox(box *ocr.BoundingBox) *ocr.BoundingBox {
+	if box == nil {
+		return nil
+	}
+	c := *box
+	return &c
+}
+
+// gap returns the box of the space between two glyphs on a line, from the
+// right edge of a to the left edge of b and spanning both vertically.
+func gap(a, b *ocr.BoundingBox) *ocr.BoundingBox {
+	if a == nil || b == nil {
+		return nil
+	}
+	g := &ocr.BoundingBox{X1: a.X2, Y1: a.Y1, X2: b.X1, Y2: a.Y2}
+	if b.Y1 < g.Y1 {
+		g.Y1 = b.Y1
+	}
+	if b.Y2 > g.Y2 {
+		g.Y2 = b.Y2
+	}
+	if g.X2 < g.X1 {
+		// Overlapping words get a zero width space.
+		g.X2 = g.X1
+	}
+	return g
+}
+
+// Split divides box horizontally into one glyph per rune of text, each with
+// the same width and the given error. It is for formats that only give word
+// boxes.
+func Split(text string, box *ocr.BoundingBox, err uint32) []Glyph {
+	return split(text, box, err, func(rune) uint64 { return 1 })
+}
+
+// SplitProportional is like Split, but divides box in proportion to an
+// estimate of the advance of each rune, so that an i is narrower than an m.
+// Runes are measured by class, as the font is unknown.
+func SplitProportional(text string, box *ocr.BoundingBox, err uint32) []Glyph {
+	return split(text, box, err, advance)
+}
+
+// split divides box horizontally into one glyph per rune of text, each with
+// a width in proportion to its weight.
+func split(text string, box *ocr.BoundingBox, err uint32, weight func(rune) uint64) []Glyph {
+	runes := []rune(text)
+	glyphs := make([]Glyph, len(runes))
+	var total uint64
+	for _, r := range runes {
+		total += weight(r)
+	}
+	var sum uint64
+	for i, r := range runes {
+		glyphs[i] = Glyph{Rune: r, Error: err}
+		w := weight(r)
+		if box == nil {
+			continue
+		}
+		width := uint64(box.X2 - box.X1)
+		if box.X2 < box.X1 {
+			width = 0
+		}
+		glyphs[i].Box = &ocr.BoundingBox{
+			X1: box.X1 + uint32(width*sum/total),
+			Y1: box.Y1,
+			X2: box.X1 + uint32(width*(sum+w)/total),
+			Y2: box.Y2,
+		}
+		sum += w
+	}
+	return glyphs
+}
+
+// advance returns an estimate of the width of r in thousandths of an em, in
+// a proportional Latin font.
+func advance(r rune) uint64 {
+	switch {
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || (r >= 0xff01 && r <= 0xff60):
+		return 1000
+	case strings.ContainsRune("iljtfrI.,;:!|'`", r):
+		return 300
+	case strings.ContainsRune("mwMW@", r):
+		return 850
+	case unicode.IsUpper(r):
+		return 680
+	}
+	return 500
+}
+
+// ErrorFromConfidence converts a confidence between 0 and 100 to a character
+// error, clamping values out of range.
+func ErrorFromConfidence(confidence float64) uint32 {
+	switch {
+	case confidence >= 100:
+		return 0
+	case confidence <= 0:
+		return 100
+	}
+	return uint32(100 - confidence + 0.5)
+}
@@ -0,0 +1,113 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func box(x1, y1, x2, y2 uint32) *ocr.BoundingBox {
+	return &ocr.BoundingBox{X1: x1, Y1: y1, X2: x2, Y2: y2}
+}
+
+func TestBuilder(t *testing.T) {
+	b := New()
+	b.Page(100, 50, 300, 300)
+	b.Word(Split("ab", box(0, 0, 20, 10), 5), Style{Font: "Arial", Size: 10})
+	b.Word(Split("c", box(30, 2, 40, 12), 0), Style{Font: "Arial", Size: 12, Styles: []ocr.FontStyle_Style{ocr.BOLD}})
+	b.EndLine()
+	b.Word(Split("😀", box(0, 20, 10, 30), 0), Style{})
+	b.Page(100, 50, 300, 300)
+	b.Word(Split("d", box(90, 40, 120, 60), 0), Style{Styles: []ocr.FontStyle_Style{ocr.BOLD}})
+	doc := b.Document([]byte{1}, "test")
+
+	want := []*ocr.Character{
+		{Unicode: 'a', Error: 5, BoundingBox: box(0, 0, 10, 10)},
+		{Unicode: 'b', Error: 5, BoundingBox: box(10, 0, 20, 10)},
+		{Unicode: ' ', BoundingBox: box(20, 0, 30, 12)},
+		{Unicode: 'c', BoundingBox: box(30, 2, 40, 12)},
+		{Unicode: ' ', BoundingBox: box(40, 2, 40, 12)},
+		{Unicode: 0xD83D, BoundingBox: box(0, 20, 10, 30)},
+		{Unicode: 0xDE00, BoundingBox: box(0, 20, 10, 30)},
+		{Unicode: ' ', BoundingBox: box(10, 20, 10, 30)},
+		{Unicode: 'd', BoundingBox: box(90, 40, 100, 50)},
+		{Unicode: ' ', BoundingBox: box(100, 40, 100, 50)},
+	}
+	assert.Equal(t, want, doc.Characters)
+	assert.Equal(t, []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 8}, Width: 100, Height: 50, DpiX: 300, DpiY: 300},
+		{CharacterSpan: &ocr.Span{Start: 8, End: 10}, Width: 100, Height: 50, DpiX: 300, DpiY: 300},
+	}, doc.Pages)
+	assert.Equal(t, []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 5}, Name: "Arial"}}, doc.Fonts)
+	assert.Equal(t, []*ocr.FontSize{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Size_: 10},
+		{CharacterSpan: &ocr.Span{Start: 3, End: 5}, Size_: 12},
+	}, doc.FontSizes)
+	assert.Equal(t, []*ocr.FontStyle{
+		{CharacterSpan: &ocr.Span{Start: 3, End: 5}, Style: ocr.BOLD},
+		{CharacterSpan: &ocr.Span{Start: 8, End: 10}, Style: ocr.BOLD},
+	}, doc.FontStyles)
+	assert.Equal(t, int32(documentVersion), doc.Version)
+	assert.Equal(t, "test", doc.Source)
+}
+
+func TestBuilderAppend(t *testing.T) {
+	b := New()
+	b.Page(100, 50, 300, 300)
+	b.Append(Split("a", box(0, 0, 10, 10), 0), Style{Font: "Arial"})
+	b.Append(Split("b", box(10, 0, 20, 10), 0), Style{Font: "Arial", Styles: []ocr.FontStyle_Style{ocr.BOLD}})
+	b.Word(Split("c", box(30, 0, 40, 10), 0), Style{Font: "Arial"})
+	doc := b.Document(nil, "")
+
+	assert.Equal(t, "ab c ", string(runes(doc.Characters)))
+	assert.Equal(t, []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 5}, Name: "Arial"}}, doc.Fonts)
+	// The space takes the style of the glyph before it.
+	assert.Equal(t, []*ocr.FontStyle{{CharacterSpan: &ocr.Span{Start: 1, End: 3}, Style: ocr.BOLD}}, doc.FontStyles)
+}
+
+func runes(chars []*ocr.Character) []rune {
+	r := make([]rune, len(chars))
+	for i, c := range chars {
+		r[i] = rune(c.Unicode)
+	}
+	return r
+}
+
+func TestBuilderTables(t *testing.T) {
+	b := New()
+	b.Page(100, 100, 300, 300)
+	id := b.Table(0)
+	b.Cell(id, box(0, 0, 50, 150))
+	doc := b.Document(nil, "")
+	require.Len(t, doc.Tables, 1)
+	assert.Equal(t, []*ocr.TableCell{{Id: id, BoundingBox: box(0, 0, 50, 100)}}, doc.TableCells)
+}
+
+func TestSplit(t *testing.T) {
+	assert.Equal(t, []Glyph{
+		{Rune: 'a', Box: box(0, 0, 3, 9), Error: 1},
+		{Rune: 'b', Box: box(3, 0, 6, 9), Error: 1},
+		{Rune: 'c', Box: box(6, 0, 10, 9), Error: 1},
+	}, Split("abc", box(0, 0, 10, 9), 1))
+	assert.Equal(t, []Glyph{{Rune: 'a'}}, Split("a", nil, 0))
+}
+
+func TestSplitProportional(t *testing.T) {
+	assert.Equal(t, []Glyph{
+		{Rune: 'm', Box: box(0, 0, 85, 9), Error: 1},
+		{Rune: 'i', Box: box(85, 0, 115, 9), Error: 1},
+		{Rune: 'A', Box: box(115, 0, 183, 9), Error: 1},
+		{Rune: 'x', Box: box(183, 0, 233, 9), Error: 1},
+	}, SplitProportional("miAx", box(0, 0, 233, 9), 1))
+	assert.Equal(t, []Glyph{{Rune: 'a'}}, SplitProportional("a", nil, 0))
+}
+
+func TestErrorFromConfidence(t *testing.T) {
+	tests := map[float64]uint32{-1: 100, 0: 100, 12.4: 88, 99.6: 0, 100: 0, 250: 0}
+	for confidence, want := range tests {
+		assert.Equal(t, want, ErrorFromConfidence(confidence), confidence)
+	}
+}
@@ -0,0 +1,266 @@
+// Package layout recovers the words and lines of the pages of an ocr.Document,
+// and the font attributes of its characters, for formats that need more
+// structure than a sequence of characters.
+package layout
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/zuvaai/eocr-utils/internal/span"
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Ascent is the part of the height of a character box above the baseline, as
+// laid out by the converters of this module.
+const Ascent = 0.8
+
+// Glyph is a rune of a word and the characters it was decoded from: a surrogate
+// pair decodes to one rune.
+type Glyph struct {
+	Rune  rune
+	Chars []*ocr.Character
+	// Index is the index of the first character in the document.
+	Index int
+}
+
+// Box returns the union of the boxes of the characters of g.
+func (g Glyph) Box() *ocr.BoundingBox {
+	var box *ocr.BoundingBox
+	for _, c := range g.Chars {
+		if c != nil {
+			box = Union(box, c.BoundingBox)
+		}
+	}
+	return box
+}
+
+// Error returns the largest error of the characters of g.
+func (g Glyph) Error() uint32 {
+	var err uint32
+	for _, c := range g.Chars {
+		if c != nil && c.Error > err {
+			err = c.Error
+		}
+	}
+	return err
+}
+
+// Word is a run of glyphs without whitespace.
+type Word struct {
+	Glyphs []Glyph
+	Box    *ocr.BoundingBox
+}
+
+// Text returns the text of w.
+func (w *Word) Text() string {
+	runes := make([]rune, len(w.Glyphs))
+	for i, g := range w.Glyphs {
+		runes[i] = g.Rune
+	}
+	return string(runes)
+}
+
+// Error returns the mean error of the characters of w.
+func (w *Word) Error() uint32 {
+	var sum, n uint32
+	for _, g := range w.Glyphs {
+		for _, c := range g.Chars {
+			if c != nil {
+				sum += c.Error
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / n
+}
+
+// Line is a line of words.
+type Line struct {
+	Words []*Word
+	Box   *ocr.BoundingBox
+}
+
+// PageLines splits the text of the page with the given index into words at
+// whitespace and groups the words into lines. A word starts a new line when it
+// follows a line break, starts left of the previous word, or its baseline is
+// more than half a line height away from the line's.
+func PageLines(doc *ocr.Document, page int) ([]*Line, error) {
+	m, err := eocr.PageTextWithMapping(doc, page)
+	if err != nil {
+		return nil, err
+	}
+	var lines []*Line
+	var line *Line
+	var word *Word
+	var prev *ocr.BoundingBox
+	newLine := true
+	endWord := func() {
+		if word == nil {
+			return
+		}
+		if line == nil || newLine || startsLine(line.Box, prev, word.Box) {
+			line = &Line{}
+			lines = append(lines, line)
+		}
+		line.Words = append(line.Words, word)
+		line.Box = Union(line.Box, word.Box)
+		if word.Box != nil {
+			prev = word.Box
+		}
+		word, newLine = nil, false
+	}
+	for i, r := range []rune(m.Text) {
+		first, _ := m.RuneToCharacter(i)
+		next, _ := m.RuneToCharacter(i + 1)
+		if unicode.IsSpace(r) {
+			endWord()
+			if r == '\n' || r == '\r' {
+				newLine = true
+			}
+			continue
+		}
+		if word == nil {
+			word = &Word{}
+		}
+		g := Glyph{Rune: r, Chars: doc.Characters[first:next], Index: first}
+		word.Glyphs = append(word.Glyphs, g)
+		word.Box = Union(word.Box, g.Box())
+	}
+	endWord()
+	return lines, nil
+}
+
+// startsLine reports whether a word with box bb starts a new line after the
+// word with box prev on a line with box line.
+func startsLine(line, prev, bb *ocr.BoundingBox) bool {
+	if line == nil || prev == nil || bb == nil {
+		return false
+	}
+	if bb.X1 < prev.X1 {
+		return true
+	}
+	height := line.Y2 - line.Y1
+	diff := int64(bb.Y2) - int64(line.Y2)
+	if diff < 0 {
+		diff = -diff
+	}
+	return uint64(diff)*2 > uint64(height)
+}
+
+// Union returns the smallest box containing a and b, either of which may be
+// nil.
+func Union(a, b *ocr.BoundingBox) *ocr.BoundingBox {
+	if b == nil {
+		return a
+	}
+	if a == nil {
+		c := *b
+		return &c
+	}
+	u := *a
+	if b.X1 < u.X1 {
+		u.X1 = b.X1
+	}
+	if b.Y1 < u.Y1 {
+		u.Y1 = b.Y1
+	}
+	if b.X2 > u.X2 {
+		u.X2 = b.X2
+	}
+	if b.Y2 > u.Y2 {
+		u.Y2 = b.Y2
+	}
+	return &u
+}
+
+// Attrs holds the font, font size and font styles of each character of a
+// document.
+type Attrs struct {
+	fonts  []*ocr.Font
+	sizes  []uint32
+	styles []uint16
+}
+
+// NewAttrs returns the attributes of the characters of doc. Spans are clamped
+// to the characters of the document.
+func NewAttrs(doc *ocr.Document) *Attrs {
+	n := uint32(len(doc.Characters))
+	a := &Attrs{
+		fonts:  make([]*ocr.Font, n),
+		sizes:  make([]uint32, n),
+		styles: make([]uint16, n),
+	}
+	for _, f := range doc.Fonts {
+		start, end := span.Clamp(f.GetCharacterSpan(), n)
+		for i := start; i < end; i++ {
+			a.fonts[i] = f
+		}
+	}
+	for _, s := range doc.FontSizes {
+		start, end := span.Clamp(s.GetCharacterSpan(), n)
+		for i := start; i < end; i++ {
+			a.sizes[i] = s.Size_
+		}
+	}
+	for _, s := range doc.FontStyles {
+		if s == nil || s.Style < 0 || s.Style >= 16 {
+			continue
+		}
+		start, end := span.Clamp(s.GetCharacterSpan(), n)
+		for i := start; i < end; i++ {
+			a.styles[i] |= 1 << uint(s.Style)
+		}
+	}
+	return a
+}
+
+// Font returns the font of the character at index i, or nil if it has none.
+func (a *Attrs) Font(i int) *ocr.Font {
+	return a.fonts[i]
+}
+
+// Size returns the font size of the character at index i, or 0 if it has none.
+func (a *Attrs) Size(i int) uint32 {
+	return a.sizes[i]
+}
+
+// Has reports whether the character at index i has style s.
+func (a *Attrs) Has(i int, s ocr.FontStyle_Style) bool {
+	return s >= 0 && s < 16 && a.styles[i]&(1<<uint(s)) != 0
+}
+
+// Styles returns the styles of the character at index i, in the order of
+// their values.
+func (a *Attrs) Styles(i int) []ocr.FontStyle_Style {
+	var styles []ocr.FontStyle_Style
+	for s := ocr.FontStyle_Style(0); s < 16; s++ {
+		if a.Has(i, s) {
+			styles = append(styles, s)
+		}
+	}
+	return styles
+}
+
+// EscapeXML escapes s for XML text and attribute values. Characters XML
+// doesn't allow, such as control characters other than tab, newline and
+// carriage return, surrogates, U+FFFE and U+FFFF, are replaced with
+// unicode.ReplacementChar so that each rune of s stays a rune of the output.
+func EscapeXML(s string) string {
+	return html.EscapeString(strings.Map(xmlRune, s))
+}
+
+// xmlRune returns r, or unicode.ReplacementChar if XML doesn't allow it.
+func xmlRune(r rune) rune {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+	case r < 0x20, r >= 0xd800 && r < 0xe000, r == 0xfffe, r == 0xffff:
+		return unicode.ReplacementChar
+	}
+	return r
+}
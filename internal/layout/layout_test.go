@@ -0,0 +1,82 @@
+package layout
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestPageLines(t *testing.T) {
+	doc, err := eocr.NewDocumentFromText("foo bar\nbaz 😀", 80, 2)
+	require.NoError(t, err)
+	doc.Characters[5].Error = 30
+
+	lines, err := PageLines(doc, 0)
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+
+	var words [][]string
+	for _, l := range lines {
+		var line []string
+		for _, w := range l.Words {
+			line = append(line, w.Text())
+		}
+		words = append(words, line)
+	}
+	assert.Equal(t, [][]string{{"foo", "bar"}, {"baz", "😀"}}, words)
+	assert.Equal(t, &ocr.BoundingBox{X1: 0, Y1: 0, X2: 70, Y2: 10}, lines[0].Box)
+	assert.Equal(t, uint32(10), lines[0].Words[1].Error())
+	assert.Equal(t, uint32(30), lines[0].Words[1].Glyphs[1].Error())
+
+	emoji := lines[1].Words[1].Glyphs[0]
+	assert.Len(t, emoji.Chars, 2)
+	assert.Equal(t, 12, emoji.Index)
+	assert.Equal(t, &ocr.BoundingBox{X1: 40, Y1: 10, X2: 50, Y2: 20}, emoji.Box())
+
+	_, err = PageLines(doc, 1)
+	assert.Error(t, err)
+}
+
+func TestAttrs(t *testing.T) {
+	doc, err := eocr.NewDocumentFromText("foo bar")
+	require.NoError(t, err)
+	doc.Fonts = []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Name: "Arial"}}
+	doc.FontSizes = []*ocr.FontSize{{CharacterSpan: &ocr.Span{Start: 4, End: 100}, Size_: 9}}
+	doc.FontStyles = []*ocr.FontStyle{
+		{CharacterSpan: &ocr.Span{Start: 2, End: 5}, Style: ocr.ITALIC},
+		{CharacterSpan: &ocr.Span{Start: 1, End: 3}, Style: ocr.BOLD},
+	}
+
+	a := NewAttrs(doc)
+	assert.Equal(t, "Arial", a.Font(0).Name)
+	assert.Nil(t, a.Font(3))
+	assert.Equal(t, uint32(0), a.Size(3))
+	assert.Equal(t, uint32(9), a.Size(6))
+	assert.Equal(t, []ocr.FontStyle_Style{ocr.BOLD, ocr.ITALIC}, a.Styles(2))
+	assert.True(t, a.Has(4, ocr.ITALIC))
+	assert.False(t, a.Has(4, ocr.BOLD))
+	assert.Nil(t, a.Styles(6))
+}
+
+func TestEscapeXML(t *testing.T) {
+	tests := map[string]struct {
+		s    string
+		want string
+	}{
+		"markup":       {s: `<a href="x">&</a>`, want: "&lt;a href=&#34;x&#34;&gt;&amp;&lt;/a&gt;"},
+		"whitespace":   {s: "a\tb\nc\r", want: "a\tb\nc\r"},
+		"control":      {s: "a\x00b\x01", want: "a\ufffdb\ufffd"},
+		"noncharacter": {s: "\ufffe\uffff\ufffd", want: "\ufffd\ufffd\ufffd"},
+		"astral":       {s: "😀", want: "😀"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EscapeXML(tt.s))
+		})
+	}
+}
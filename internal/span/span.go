@@ -0,0 +1,22 @@
+// Package span bounds the character spans of an ocr.Document, which may be
+// invalid, to its characters.
+package span
+
+import "github.com/zuvaai/eocr-utils/pkg/ocr"
+
+// Clamp returns the bounds of s limited to a document of n characters, so
+// that they can index its characters even when s is invalid. A nil span is
+// empty.
+func Clamp(s *ocr.Span, n uint32) (start, end uint32) {
+	if s == nil {
+		return 0, 0
+	}
+	start, end = s.Start, s.End
+	if end > n {
+		end = n
+	}
+	if start > end {
+		start = end
+	}
+	return start, end
+}
@@ -0,0 +1,30 @@
+package span
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestClamp(t *testing.T) {
+	tests := map[string]struct {
+		span       *ocr.Span
+		start, end uint32
+	}{
+		"nil":      {span: nil, start: 0, end: 0},
+		"inside":   {span: &ocr.Span{Start: 1, End: 3}, start: 1, end: 3},
+		"past end": {span: &ocr.Span{Start: 2, End: 9}, start: 2, end: 4},
+		"outside":  {span: &ocr.Span{Start: 7, End: 9}, start: 4, end: 4},
+		"reversed": {span: &ocr.Span{Start: 3, End: 1}, start: 1, end: 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			start, end := Clamp(tt.span, 4)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	"github.com/zuvaai/eocr-utils/pkg/ocr"
@@ -26,9 +27,30 @@ const (
 	pageDpi    = 300 // The DPI of the virtual pages.
 )
 
+// Options configures the conversion of text to a Document.
+type Options struct {
+	// LineLength is the maximum number of symbols on a line.
+	LineLength int
+	// PageLength is the maximum number of lines on a page.
+	PageLength int
+	// LegacyUnicode stores runes outside the Basic Multilingual Plane as a
+	// single character holding the code point instead of a UTF-16 surrogate
+	// pair. Earlier versions always did this, and some consumers depend on it.
+	LegacyUnicode bool
+}
+
 // FromUTF8 takes a utf8 string, max number of characters per line, and
 // max number of lines per page, and returns an eocr Document.
 func FromUTF8(s string, lineLength, pageLength int) (*ocr.Document, error) {
+	return FromUTF8WithOptions(s, Options{LineLength: lineLength, PageLength: pageLength})
+}
+
+// FromUTF8WithOptions takes a utf8 string and returns an eocr Document laid out
+// according to opts. Each rune becomes one character, except for runes outside
+// the Basic Multilingual Plane which become a surrogate pair of characters
+// sharing the rune's bounding box, unless opts.LegacyUnicode is set.
+func FromUTF8WithOptions(s string, opts Options) (*ocr.Document, error) {
+	lineLength, pageLength := opts.LineLength, opts.PageLength
 	if lineLength <= 0 {
 		return nil, fmt.Errorf("cannot convert text to document: line length cannot be zero or lower")
 	}
@@ -62,9 +84,13 @@ func FromUTF8(s string, lineLength, pageLength int) (*ocr.Document, error) {
 		}
 		x := uint32(lineCharPos * charWidth)
 		y := uint32(pageLinePos * charHeight)
-		c := newCharacter(r, x, y)
-		chars = append(chars, c)
-		charIdx++
+		if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar && !opts.LegacyUnicode {
+			chars = append(chars, newCharacter(r1, x, y), newCharacter(r2, x, y))
+			charIdx += 2
+		} else {
+			chars = append(chars, newCharacter(r, x, y))
+			charIdx++
+		}
 		// CR and LF are invisible characters and shouldn't advance line
 		// character position.
 		if r != '\r' && r != '\n' {
@@ -101,7 +127,8 @@ func newPage(charIdx uint32, lineLength, pageLength int) *ocr.Page {
 	return newPage
 }
 
-// newCharacter creates a new character for rune starting at location x, y.
+// newCharacter creates a new character for the rune or UTF-16 code unit r
+// starting at location x, y.
 func newCharacter(r rune, x, y uint32) *ocr.Character {
 	return &ocr.Character{
 		BoundingBox: &ocr.BoundingBox{
@@ -731,6 +731,73 @@ func TestFromUTF8(t *testing.T) {
 	}
 }
 
+func TestFromUTF8WithOptionsAstral(t *testing.T) {
+	box := func(x uint32) *document.BoundingBox {
+		return &document.BoundingBox{X1: x, Y1: 0, X2: x + charWidth, Y2: charHeight}
+	}
+	tests := []struct {
+		name  string
+		s     string
+		opts  Options
+		chars []document.Character
+	}{
+		{
+			name: "surrogate pair",
+			s:    "a😀b",
+			opts: Options{LineLength: 70, PageLength: 10},
+			chars: []document.Character{
+				{Unicode: uint32('a'), BoundingBox: box(0)},
+				{Unicode: 0xD83D, BoundingBox: box(charWidth)},
+				{Unicode: 0xDE00, BoundingBox: box(charWidth)},
+				{Unicode: uint32('b'), BoundingBox: box(charWidth * 2)},
+			},
+		},
+		{
+			name: "extension b",
+			s:    "𠀋",
+			opts: Options{LineLength: 70, PageLength: 10},
+			chars: []document.Character{
+				{Unicode: 0xD840, BoundingBox: box(0)},
+				{Unicode: 0xDC0B, BoundingBox: box(0)},
+			},
+		},
+		{
+			name: "legacy",
+			s:    "a😀b",
+			opts: Options{LineLength: 70, PageLength: 10, LegacyUnicode: true},
+			chars: []document.Character{
+				{Unicode: uint32('a'), BoundingBox: box(0)},
+				{Unicode: 0x1F600, BoundingBox: box(charWidth)},
+				{Unicode: uint32('b'), BoundingBox: box(charWidth * 2)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := FromUTF8WithOptions(tt.s, tt.opts)
+			require.NoError(t, err)
+			require.Len(t, doc.Characters, len(tt.chars))
+			for i, c := range tt.chars {
+				assert.Equal(t, c, *doc.Characters[i], i)
+			}
+			require.Len(t, doc.Pages, 1)
+			assert.Equal(t, document.Span{Start: 0, End: uint32(len(tt.chars))}, *doc.Pages[0].CharacterSpan)
+		})
+	}
+}
+
+func TestFromUTF8AstralPageBreak(t *testing.T) {
+	doc, err := FromUTF8("😀😀 😀😀", 2, 1)
+	require.NoError(t, err)
+	require.Len(t, doc.Characters, 9)
+	// Page spans count UTF-16 code units, so a full line of two emoji covers
+	// four characters.
+	require.Len(t, doc.Pages, 3)
+	assert.Equal(t, document.Span{Start: 0, End: 4}, *doc.Pages[0].CharacterSpan)
+	assert.Equal(t, document.Span{Start: 4, End: 5}, *doc.Pages[1].CharacterSpan)
+	assert.Equal(t, document.Span{Start: 5, End: 9}, *doc.Pages[2].CharacterSpan)
+}
+
 func TestRunesUntilNextWhitespace(t *testing.T) {
 	tests := []struct {
 		name string
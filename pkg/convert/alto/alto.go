@@ -0,0 +1,399 @@
+// Package alto converts between ALTO XML, the format used by libraries and
+// archives for digitized documents, and ocr.Document.
+//
+// Versions 2 to 4 of the format are read, and version 4 is written. See
+// https://www.loc.gov/standards/alto/ for the format.
+package alto
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/zuvaai/eocr-utils/internal/builder"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Measurement units of ALTO coordinates.
+const (
+	// UnitPixel measures in pixels.
+	UnitPixel = "pixel"
+	// UnitMM10 measures in tenths of a millimeter.
+	UnitMM10 = "mm10"
+	// UnitInch1200 measures in 1200ths of an inch.
+	UnitInch1200 = "inch1200"
+)
+
+// defaultDPI is the resolution of pages when Options.DPI is zero.
+const defaultDPI = 300
+
+// ErrNoPages means that the input has no Page elements.
+var ErrNoPages = errors.New("alto: no Page elements")
+
+// Options configures the conversion of documents.
+type Options struct {
+	// Unit is the measurement unit written by Marshal: UnitPixel, UnitMM10
+	// or UnitInch1200. Empty means UnitPixel.
+	Unit string
+	// DPI is the resolution given to the pages read by Unmarshal, which is
+	// also used to convert mm10 and inch1200 coordinates to pixels. ALTO
+	// doesn't record the resolution of pages. Zero means 300.
+	DPI uint32
+	// PageDPI overrides DPI for the pages read by Unmarshal, by index from
+	// 0, such as for documents written by Marshal from pages of different
+	// or non-square resolutions. Pages past its end, and zero resolutions,
+	// use DPI.
+	PageDPI []Resolution
+}
+
+// Resolution is the horizontal and vertical resolution of a page in dots per
+// inch.
+type Resolution struct {
+	X, Y uint32
+}
+
+// styleNames maps the ALTO font styles to ocr font styles.
+var styleNames = map[string]ocr.FontStyle_Style{
+	"bold":        ocr.BOLD,
+	"italics":     ocr.ITALIC,
+	"underline":   ocr.UNDERLINE,
+	"superscript": ocr.SUPERSCRIPT,
+	"subscript":   ocr.SUBSCRIPT,
+	"smallcaps":   ocr.SMALLCAPS,
+}
+
+// styleOrder is the order in which font styles are written.
+var styleOrder = []string{"bold", "italics", "underline", "superscript", "subscript", "smallcaps"}
+
+// ReadFile reads an ALTO file.
+func ReadFile(filename string) (*ocr.Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Read reads an ALTO document from r.
+func Read(r io.Reader) (*ocr.Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal converts an ALTO document to an ocr.Document with the default
+// options.
+func Unmarshal(data []byte) (*ocr.Document, error) {
+	return UnmarshalWithOptions(data, Options{})
+}
+
+// UnmarshalWithOptions converts an ALTO document to an ocr.Document.
+//
+// Each Page becomes a page and the String elements of each TextLine become
+// words separated by spaces, wherever their TextBlock is. SP elements are
+// replaced by the spaces the format requires, and HYP elements are appended
+// to the last word of their line. Characters take their boxes from Glyph
+// elements when there are any, otherwise the box of the String is divided
+// evenly between them. WC and GC confidences become character errors. The
+// TextStyle referenced by a String, its TextLine or its TextBlock gives the
+// font and font size, and its FONTSTYLE and the STYLE of the String give the
+// font styles.
+//
+// The md5 of the document is the md5 of data.
+func UnmarshalWithOptions(data []byte, opts Options) (*ocr.Document, error) {
+	if opts.DPI == 0 {
+		opts.DPI = defaultDPI
+	}
+	p := &parser{
+		opts:   opts,
+		b:      builder.New(),
+		styles: map[string]*textStyle{},
+		unit:   UnitPixel,
+		scaleX: 1,
+		scaleY: 1,
+	}
+	if err := p.parse(data); err != nil {
+		return nil, err
+	}
+	if p.b.Pages() == 0 {
+		return nil, ErrNoPages
+	}
+	sum := md5.Sum(data)
+	return p.b.Document(sum[:], ""), nil
+}
+
+// textStyle is an ALTO TextStyle.
+type textStyle struct {
+	font      string
+	size      float64
+	serif     bool
+	monospace bool
+	styles    []ocr.FontStyle_Style
+}
+
+// word is a String element.
+type word struct {
+	glyphs []builder.Glyph
+	style  builder.Style
+}
+
+type parser struct {
+	opts   Options
+	b      *builder.Builder
+	styles map[string]*textStyle
+	// unit is the MeasurementUnit of the document.
+	unit string
+	// scaleX and scaleY convert the coordinates of the current page to
+	// pixels.
+	scaleX, scaleY float64
+	// styleRefs are the STYLEREFS of the enclosing elements.
+	styleRefs []string
+	// line holds the words of the current TextLine.
+	line []*word
+	// word is the current String, if any.
+	word *word
+	// glyphs is set when the current String has Glyph elements.
+	glyphs bool
+	// text accumulates the character data of MeasurementUnit.
+	text strings.Builder
+	// inPage is set inside Page elements.
+	inPage bool
+}
+
+func (p *parser) parse(data []byte) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("alto: %w", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if err := p.start(tok); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if err := p.end(tok.Name.Local); err != nil {
+				return err
+			}
+		case xml.CharData:
+			p.text.Write(tok)
+		}
+	}
+}
+
+func (p *parser) start(el xml.StartElement) error {
+	a := attrs(el.Attr)
+	switch el.Name.Local {
+	case "MeasurementUnit":
+		p.text.Reset()
+	case "TextStyle":
+		p.styles[a["ID"]] = parseTextStyle(a)
+	case "Page":
+		dpiX, dpiY := p.pageDPI(p.b.Pages())
+		p.scaleX, p.scaleY = unitScale(p.unit, dpiX), unitScale(p.unit, dpiY)
+		width, height := p.length(a["WIDTH"], p.scaleX), p.length(a["HEIGHT"], p.scaleY)
+		p.b.Page(width, height, dpiX, dpiY)
+		p.inPage = true
+	case "TextBlock", "TextLine":
+		p.styleRefs = append(p.styleRefs, a["STYLEREFS"])
+		if el.Name.Local == "TextLine" {
+			p.line = nil
+		}
+	case "String":
+		if !p.inPage {
+			return fmt.Errorf("alto: String outside of a Page")
+		}
+		p.word = &word{style: p.style(a["STYLEREFS"], a["STYLE"])}
+		p.glyphs = false
+		p.word.glyphs = builder.Split(a["CONTENT"], p.box(a), confidenceError(a["WC"]))
+		p.line = append(p.line, p.word)
+	case "Glyph":
+		if p.word == nil {
+			return nil
+		}
+		if !p.glyphs {
+			// Glyph elements replace the evenly divided String.
+			p.word.glyphs = nil
+			p.glyphs = true
+		}
+		p.word.glyphs = append(p.word.glyphs, builder.Split(a["CONTENT"], p.box(a), confidenceError(a["GC"]))...)
+	case "HYP":
+		if len(p.line) == 0 {
+			return nil
+		}
+		last := p.line[len(p.line)-1]
+		last.glyphs = append(last.glyphs, builder.Split(a["CONTENT"], p.box(a), 0)...)
+	}
+	return nil
+}
+
+func (p *parser) end(name string) error {
+	switch name {
+	case "MeasurementUnit":
+		unit := strings.TrimSpace(p.text.String())
+		if unit != UnitPixel && unit != UnitMM10 && unit != UnitInch1200 {
+			return fmt.Errorf("alto: unsupported MeasurementUnit %q", unit)
+		}
+		p.unit = unit
+	case "Page":
+		p.inPage = false
+	case "TextBlock":
+		p.styleRefs = p.styleRefs[:len(p.styleRefs)-1]
+	case "TextLine":
+		p.styleRefs = p.styleRefs[:len(p.styleRefs)-1]
+		for _, w := range p.line {
+			p.b.Word(w.glyphs, w.style)
+		}
+		p.b.EndLine()
+		p.line = nil
+	case "String":
+		p.word = nil
+	}
+	return nil
+}
+
+func attrs(list []xml.Attr) map[string]string {
+	m := make(map[string]string, len(list))
+	for _, a := range list {
+		m[a.Name.Local] = a.Value
+	}
+	return m
+}
+
+func parseTextStyle(a map[string]string) *textStyle {
+	s := &textStyle{
+		font:      a["FONTFAMILY"],
+		serif:     a["FONTTYPE"] == "serif",
+		monospace: a["FONTWIDTH"] == "fixed",
+	}
+	s.size, _ = strconv.ParseFloat(a["FONTSIZE"], 64)
+	s.styles = parseStyles(a["FONTSTYLE"])
+	return s
+}
+
+func parseStyles(s string) []ocr.FontStyle_Style {
+	var styles []ocr.FontStyle_Style
+	for _, name := range strings.Fields(s) {
+		if style, ok := styleNames[name]; ok {
+			styles = appendStyle(styles, style)
+		}
+	}
+	return styles
+}
+
+func appendStyle(styles []ocr.FontStyle_Style, s ocr.FontStyle_Style) []ocr.FontStyle_Style {
+	for _, e := range styles {
+		if e == s {
+			return styles
+		}
+	}
+	return append(styles, s)
+}
+
+// style returns the style of a String with the given STYLEREFS and STYLE
+// attributes. The nearest TextStyle reference wins.
+func (p *parser) style(refs, fontStyle string) builder.Style {
+	var ts *textStyle
+	for i := len(p.styleRefs); i >= 0 && ts == nil; i-- {
+		r := refs
+		if i < len(p.styleRefs) {
+			r = p.styleRefs[i]
+		}
+		for _, id := range strings.Fields(r) {
+			if ts = p.styles[id]; ts != nil {
+				break
+			}
+		}
+	}
+	var style builder.Style
+	if ts != nil {
+		style = builder.Style{Font: ts.font, Serif: ts.serif, Monospace: ts.monospace}
+		if ts.size > 0 {
+			style.Size = uint32(math.Round(ts.size))
+		}
+		style.Styles = append(style.Styles, ts.styles...)
+	}
+	for _, s := range parseStyles(fontStyle) {
+		style.Styles = appendStyle(style.Styles, s)
+	}
+	return style
+}
+
+// pageDPI returns the horizontal and vertical resolution of the page with the
+// given index.
+func (p *parser) pageDPI(page int) (uint32, uint32) {
+	x, y := p.opts.DPI, p.opts.DPI
+	if page < len(p.opts.PageDPI) {
+		if r := p.opts.PageDPI[page]; r.X != 0 && r.Y != 0 {
+			x, y = r.X, r.Y
+		}
+	}
+	return x, y
+}
+
+// unitScale returns the factor converting unit to pixels at dpi.
+func unitScale(unit string, dpi uint32) float64 {
+	switch unit {
+	case UnitMM10:
+		return float64(dpi) / 254
+	case UnitInch1200:
+		return float64(dpi) / 1200
+	}
+	return 1
+}
+
+// length converts a length in the measurement unit to pixels with scale.
+func (p *parser) length(v string, scale float64) uint32 {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f <= 0 {
+		return 0
+	}
+	return uint32(math.Round(f * scale))
+}
+
+// box returns the box of an element with HPOS, VPOS, WIDTH and HEIGHT, or nil
+// if it has no position.
+func (p *parser) box(a map[string]string) *ocr.BoundingBox {
+	if a["HPOS"] == "" || a["VPOS"] == "" {
+		return nil
+	}
+	hpos, _ := strconv.ParseFloat(a["HPOS"], 64)
+	vpos, _ := strconv.ParseFloat(a["VPOS"], 64)
+	width, _ := strconv.ParseFloat(a["WIDTH"], 64)
+	height, _ := strconv.ParseFloat(a["HEIGHT"], 64)
+	px := func(v, scale float64) uint32 {
+		if v <= 0 {
+			return 0
+		}
+		return uint32(math.Round(v * scale))
+	}
+	return &ocr.BoundingBox{
+		X1: px(hpos, p.scaleX),
+		Y1: px(vpos, p.scaleY),
+		X2: px(hpos+width, p.scaleX),
+		Y2: px(vpos+height, p.scaleY),
+	}
+}
+
+// confidenceError converts a WC or GC confidence between 0 and 1 to an error.
+// A missing confidence is an error of 0.
+func confidenceError(v string) uint32 {
+	c, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0
+	}
+	return builder.ErrorFromConfidence(c * 100)
+}
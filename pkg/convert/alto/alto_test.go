@@ -0,0 +1,95 @@
+package alto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestReadFile(t *testing.T) {
+	doc, err := ReadFile("../../../testdata/alto-v4.xml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Supply agree- A&B ", eocr.Text(doc))
+	assert.Equal(t, []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 18}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+	}, doc.Pages)
+	assert.Equal(t, &ocr.Character{Unicode: 'S', Error: 4, BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 300, X2: 350, Y2: 400}}, doc.Characters[0])
+	assert.Equal(t, &ocr.Character{Unicode: '-', BoundingBox: &ocr.BoundingBox{X1: 930, Y1: 300, X2: 959, Y2: 400}}, doc.Characters[12])
+	assert.Equal(t, &ocr.Character{Unicode: 'A', Error: 50, BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 500, X2: 350, Y2: 600}}, doc.Characters[14])
+	assert.Equal(t, &ocr.Character{Unicode: '&', BoundingBox: &ocr.BoundingBox{X1: 350, Y1: 500, X2: 399, Y2: 600}}, doc.Characters[15])
+	assert.Equal(t, []*ocr.Font{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 14}, Name: "Times New Roman", Serif: true},
+		{CharacterSpan: &ocr.Span{Start: 14, End: 18}, Name: "Courier", Monospace: true},
+	}, doc.Fonts)
+	assert.Equal(t, []*ocr.FontSize{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 14}, Size_: 12},
+		{CharacterSpan: &ocr.Span{Start: 14, End: 18}, Size_: 9},
+	}, doc.FontSizes)
+	assert.Equal(t, []*ocr.FontStyle{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 7}, Style: ocr.ITALIC},
+		{CharacterSpan: &ocr.Span{Start: 14, End: 18}, Style: ocr.BOLD},
+	}, doc.FontStyles)
+
+	assert.Empty(t, eocr.Validate(doc))
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"no pages": {
+			data:    `<alto><Layout/></alto>`,
+			wantErr: ErrNoPages.Error(),
+		},
+		"unit": {
+			data:    `<alto><Description><MeasurementUnit>point</MeasurementUnit></Description></alto>`,
+			wantErr: `alto: unsupported MeasurementUnit "point"`,
+		},
+		"string outside page": {
+			data:    `<alto><String CONTENT="a"/></alto>`,
+			wantErr: "alto: String outside of a Page",
+		},
+		"malformed": {
+			data:    `<alto>`,
+			wantErr: "alto: XML syntax error on line 1: unexpected EOF",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.data))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	doc, err := UnmarshalWithOptions([]byte(`<alto><Description><MeasurementUnit>inch1200</MeasurementUnit></Description>
+<Layout><Page WIDTH="10200" HEIGHT="13200"><PrintSpace><TextBlock><TextLine>
+<String CONTENT="x" HPOS="1200" VPOS="2400" WIDTH="120" HEIGHT="120"/>
+</TextLine></TextBlock></PrintSpace></Page></Layout></alto>`), Options{DPI: 200})
+	require.NoError(t, err)
+	assert.Equal(t, &ocr.Page{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Width: 1700, Height: 2200, DpiX: 200, DpiY: 200}, doc.Pages[0])
+	assert.Equal(t, &ocr.BoundingBox{X1: 200, Y1: 400, X2: 220, Y2: 420}, doc.Characters[0].BoundingBox)
+}
+
+func TestUnmarshalPageDPI(t *testing.T) {
+	page := `<Page WIDTH="10200" HEIGHT="13200"><PrintSpace><TextBlock><TextLine>
+<String CONTENT="x" HPOS="1200" VPOS="2400" WIDTH="120" HEIGHT="120"/>
+</TextLine></TextBlock></PrintSpace></Page>`
+	doc, err := UnmarshalWithOptions([]byte(`<alto><Description><MeasurementUnit>inch1200</MeasurementUnit></Description>
+<Layout>`+page+page+`</Layout></alto>`), Options{DPI: 200, PageDPI: []Resolution{{X: 300, Y: 150}}})
+	require.NoError(t, err)
+	require.Len(t, doc.Pages, 2)
+	// The first page has its own resolution, and the second one the default.
+	assert.Equal(t, []uint32{2550, 1650, 300, 150}, []uint32{doc.Pages[0].Width, doc.Pages[0].Height, doc.Pages[0].DpiX, doc.Pages[0].DpiY})
+	assert.Equal(t, &ocr.BoundingBox{X1: 300, Y1: 300, X2: 330, Y2: 315}, doc.Characters[0].BoundingBox)
+	assert.Equal(t, []uint32{1700, 2200, 200, 200}, []uint32{doc.Pages[1].Width, doc.Pages[1].Height, doc.Pages[1].DpiX, doc.Pages[1].DpiY})
+	assert.Equal(t, &ocr.BoundingBox{X1: 200, Y1: 400, X2: 220, Y2: 420}, doc.Characters[2].BoundingBox)
+}
@@ -0,0 +1,293 @@
+package alto
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/zuvaai/eocr-utils/internal/layout"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+const header = `<?xml version="1.0" encoding="UTF-8"?>
+<alto xmlns="http://www.loc.gov/standards/alto/ns-v4#" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.loc.gov/standards/alto/ns-v4# http://www.loc.gov/alto/v4/alto-4-2.xsd">
+  <Description>
+    <MeasurementUnit>%s</MeasurementUnit>
+    <OCRProcessing ID="ocr_processing">
+      <ocrProcessingStep>
+        <processingSoftware>
+          <softwareName>eocr-utils</softwareName>
+        </processingSoftware>
+      </ocrProcessingStep>
+    </OCRProcessing>
+  </Description>
+`
+
+// Marshal returns the ALTO encoding of doc with the default options.
+func Marshal(doc *ocr.Document) ([]byte, error) {
+	return MarshalWithOptions(doc, Options{})
+}
+
+// MarshalWithOptions returns the ALTO encoding of doc.
+func MarshalWithOptions(doc *ocr.Document, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := WriteWithOptions(&buf, doc, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write writes doc to w as ALTO with the default options.
+func Write(w io.Writer, doc *ocr.Document) error {
+	return WriteWithOptions(w, doc, Options{})
+}
+
+// WriteWithOptions writes doc to w as ALTO version 4.
+//
+// Each page becomes a Page with a PrintSpace covering it. Characters separated
+// by whitespace form String elements holding a Glyph for each character, and
+// are grouped into TextLine elements by their baseline. Lines separated by
+// more than a line height start a new TextBlock. Coordinates are converted to
+// opts.Unit using the resolution of the page, and character errors become WC
+// and GC confidences. Fonts and font sizes become TextStyle elements, and font
+// styles the STYLE of each String. Strike-through and drop caps can't be
+// represented, and neither can tables.
+func WriteWithOptions(w io.Writer, doc *ocr.Document, opts Options) error {
+	unit := opts.Unit
+	if unit == "" {
+		unit = UnitPixel
+	}
+	if unit != UnitPixel && unit != UnitMM10 && unit != UnitInch1200 {
+		return fmt.Errorf("alto: unsupported measurement unit %q", unit)
+	}
+	attrs := layout.NewAttrs(doc)
+	styles := newStyleTable(doc, attrs)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, header, unit)
+	styles.write(bw)
+	bw.WriteString("  <Layout>\n")
+	for i, p := range doc.Pages {
+		conv, err := newConverter(unit, p)
+		if err != nil {
+			return fmt.Errorf("alto: page %d: %w", i, err)
+		}
+		lines, err := layout.PageLines(doc, i)
+		if err != nil {
+			return fmt.Errorf("alto: %w", err)
+		}
+		id := fmt.Sprintf("page_%d", i+1)
+		fmt.Fprintf(bw, "    <Page ID=%q PHYSICAL_IMG_NR=\"%d\" WIDTH=\"%s\" HEIGHT=\"%s\">\n",
+			id, i+1, conv.x(p.Width), conv.y(p.Height))
+		fmt.Fprintf(bw, "      <PrintSpace HPOS=\"0\" VPOS=\"0\" WIDTH=\"%s\" HEIGHT=\"%s\">\n", conv.x(p.Width), conv.y(p.Height))
+		for b, block := range blocks(lines) {
+			blockID := fmt.Sprintf("%s_block_%d", id, b+1)
+			fmt.Fprintf(bw, "        <TextBlock ID=%q%s>\n", blockID, conv.position(blockBox(block)))
+			for l, line := range block {
+				lineID := fmt.Sprintf("%s_line_%d", blockID, l+1)
+				fmt.Fprintf(bw, "          <TextLine ID=%q%s>\n", lineID, conv.position(line.Box))
+				for n, word := range line.Words {
+					if n > 0 {
+						writeSpace(bw, conv, line.Words[n-1].Box, word.Box)
+					}
+					writeString(bw, conv, attrs, styles, fmt.Sprintf("%s_string_%d", lineID, n+1), word)
+				}
+				bw.WriteString("          </TextLine>\n")
+			}
+			bw.WriteString("        </TextBlock>\n")
+		}
+		bw.WriteString("      </PrintSpace>\n    </Page>\n")
+	}
+	bw.WriteString("  </Layout>\n</alto>\n")
+	return bw.Flush()
+}
+
+// blocks groups lines into blocks, starting a new block when the gap above a
+// line is larger than the height of the line before it.
+func blocks(lines []*layout.Line) [][]*layout.Line {
+	var blocks [][]*layout.Line
+	var prev *ocr.BoundingBox
+	for _, l := range lines {
+		if len(blocks) == 0 || (prev != nil && l.Box != nil && l.Box.Y1 > prev.Y2 && l.Box.Y1-prev.Y2 > prev.Y2-prev.Y1) {
+			blocks = append(blocks, nil)
+		}
+		blocks[len(blocks)-1] = append(blocks[len(blocks)-1], l)
+		if l.Box != nil {
+			prev = l.Box
+		}
+	}
+	return blocks
+}
+
+func blockBox(lines []*layout.Line) *ocr.BoundingBox {
+	var box *ocr.BoundingBox
+	for _, l := range lines {
+		box = layout.Union(box, l.Box)
+	}
+	return box
+}
+
+func writeSpace(w *bufio.Writer, conv *converter, prev, next *ocr.BoundingBox) {
+	if prev == nil || next == nil || next.X1 < prev.X2 {
+		w.WriteString("            <SP/>\n")
+		return
+	}
+	fmt.Fprintf(w, "            <SP WIDTH=\"%s\" HPOS=\"%s\" VPOS=\"%s\"/>\n",
+		conv.x(next.X1-prev.X2), conv.x(prev.X2), conv.y(prev.Y1))
+}
+
+func writeString(w *bufio.Writer, conv *converter, attrs *layout.Attrs, styles *styleTable, id string, word *layout.Word) {
+	first := word.Glyphs[0].Index
+	fmt.Fprintf(w, "            <String ID=%q CONTENT=\"%s\"%s WC=\"%s\"",
+		id, layout.EscapeXML(word.Text()), conv.position(word.Box), confidence(word.Error()))
+	if ref := styles.ref(attrs, first); ref != "" {
+		fmt.Fprintf(w, " STYLEREFS=%q", ref)
+	}
+	var names []string
+	for _, name := range styleOrder {
+		if attrs.Has(first, styleNames[name]) {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(w, " STYLE=%q", strings.Join(names, " "))
+	}
+	w.WriteString(">\n")
+	for _, g := range word.Glyphs {
+		fmt.Fprintf(w, "              <Glyph CONTENT=\"%s\"%s GC=\"%s\"/>\n",
+			layout.EscapeXML(string(g.Rune)), conv.position(g.Box()), confidence(g.Error()))
+	}
+	w.WriteString("            </String>\n")
+}
+
+// confidence converts a character error to a confidence between 0 and 1.
+func confidence(err uint32) string {
+	if err > 100 {
+		err = 100
+	}
+	return strconv.FormatFloat(float64(100-err)/100, 'f', -1, 64)
+}
+
+// converter converts pixels to a measurement unit.
+type converter struct {
+	scaleX, scaleY float64
+}
+
+func newConverter(unit string, p *ocr.Page) (*converter, error) {
+	if p == nil {
+		return nil, errors.New("missing page")
+	}
+	if unit == UnitPixel {
+		return &converter{scaleX: 1, scaleY: 1}, nil
+	}
+	if p.DpiX == 0 || p.DpiY == 0 {
+		return nil, fmt.Errorf("no resolution to convert pixels to %s", unit)
+	}
+	perInch := 1200.0
+	if unit == UnitMM10 {
+		perInch = 254
+	}
+	return &converter{scaleX: perInch / float64(p.DpiX), scaleY: perInch / float64(p.DpiY)}, nil
+}
+
+func (c *converter) x(v uint32) string {
+	return strconv.FormatFloat(math.Round(float64(v)*c.scaleX), 'f', -1, 64)
+}
+
+func (c *converter) y(v uint32) string {
+	return strconv.FormatFloat(math.Round(float64(v)*c.scaleY), 'f', -1, 64)
+}
+
+// position returns the HPOS, VPOS, WIDTH and HEIGHT attributes of box, or
+// nothing if it is nil.
+func (c *converter) position(box *ocr.BoundingBox) string {
+	if box == nil {
+		return ""
+	}
+	var width, height uint32
+	if box.X2 > box.X1 {
+		width = box.X2 - box.X1
+	}
+	if box.Y2 > box.Y1 {
+		height = box.Y2 - box.Y1
+	}
+	return fmt.Sprintf(" HPOS=\"%s\" VPOS=\"%s\" WIDTH=\"%s\" HEIGHT=\"%s\"", c.x(box.X1), c.y(box.Y1), c.x(width), c.y(height))
+}
+
+// styleKey identifies a TextStyle.
+type styleKey struct {
+	font      string
+	serif     bool
+	monospace bool
+	size      uint32
+}
+
+// styleTable holds the TextStyle elements of a document.
+type styleTable struct {
+	ids  map[styleKey]string
+	keys []styleKey
+}
+
+func newStyleTable(doc *ocr.Document, attrs *layout.Attrs) *styleTable {
+	t := &styleTable{ids: map[styleKey]string{}}
+	for i := range doc.Characters {
+		k, ok := key(attrs, i)
+		if !ok {
+			continue
+		}
+		if _, ok := t.ids[k]; !ok {
+			t.ids[k] = fmt.Sprintf("font%d", len(t.keys))
+			t.keys = append(t.keys, k)
+		}
+	}
+	return t
+}
+
+func key(attrs *layout.Attrs, i int) (styleKey, bool) {
+	k := styleKey{size: attrs.Size(i)}
+	if f := attrs.Font(i); f != nil {
+		k.font, k.serif, k.monospace = f.Name, f.Serif, f.Monospace
+	}
+	return k, k != styleKey{}
+}
+
+// ref returns the id of the TextStyle of the character at index i, or "" if it
+// has none.
+func (t *styleTable) ref(attrs *layout.Attrs, i int) string {
+	k, ok := key(attrs, i)
+	if !ok {
+		return ""
+	}
+	return t.ids[k]
+}
+
+// write writes the Styles element. The size of a font is omitted if it isn't
+// known.
+func (t *styleTable) write(w *bufio.Writer) {
+	if len(t.keys) == 0 {
+		return
+	}
+	w.WriteString("  <Styles>\n")
+	for _, k := range t.keys {
+		fmt.Fprintf(w, "    <TextStyle ID=%q", t.ids[k])
+		if k.font != "" {
+			fmt.Fprintf(w, " FONTFAMILY=\"%s\"", layout.EscapeXML(k.font))
+		}
+		if k.serif {
+			w.WriteString(` FONTTYPE="serif"`)
+		}
+		if k.monospace {
+			w.WriteString(` FONTWIDTH="fixed"`)
+		}
+		if k.size > 0 {
+			fmt.Fprintf(w, " FONTSIZE=\"%d\"", k.size)
+		}
+		w.WriteString("/>\n")
+	}
+	w.WriteString("  </Styles>\n")
+}
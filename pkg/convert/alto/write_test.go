@@ -0,0 +1,135 @@
+package alto
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	doc, err := ReadFile("../../../testdata/alto-v4.xml")
+	require.NoError(t, err)
+
+	for _, unit := range []string{UnitPixel, UnitMM10, UnitInch1200} {
+		t.Run(unit, func(t *testing.T) {
+			data, err := MarshalWithOptions(doc, Options{Unit: unit})
+			require.NoError(t, err)
+			assert.Contains(t, string(data), "<MeasurementUnit>"+unit+"</MeasurementUnit>")
+			got, err := Unmarshal(data)
+			require.NoError(t, err)
+
+			assert.Equal(t, eocr.Text(doc), eocr.Text(got))
+			assert.Equal(t, doc.Pages, got.Pages)
+			assert.Equal(t, doc.Fonts, got.Fonts)
+			assert.Equal(t, doc.FontSizes, got.FontSizes)
+			assert.Equal(t, doc.FontStyles, got.FontStyles)
+			report := eocr.Diff(doc, got, eocr.DiffOptions{BoxTolerance: 1})
+			for _, c := range report.Changes {
+				assert.Equal(t, eocr.ChangeDocument, c.Kind, c)
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTripPageDPI(t *testing.T) {
+	foo, err := eocr.NewDocumentFromText("foo ")
+	require.NoError(t, err)
+	bar, err := eocr.NewDocumentFromText("bar ")
+	require.NoError(t, err)
+	doc, err := eocr.Merge(foo, bar)
+	require.NoError(t, err)
+	doc.Pages[0].DpiX, doc.Pages[0].DpiY = 300, 200
+	doc.Pages[1].DpiX, doc.Pages[1].DpiY = 72, 72
+
+	for _, unit := range []string{UnitMM10, UnitInch1200} {
+		t.Run(unit, func(t *testing.T) {
+			data, err := MarshalWithOptions(doc, Options{Unit: unit})
+			require.NoError(t, err)
+			got, err := UnmarshalWithOptions(data, Options{PageDPI: []Resolution{{X: 300, Y: 200}, {X: 72, Y: 72}}})
+			require.NoError(t, err)
+
+			assert.Equal(t, eocr.Text(doc), eocr.Text(got))
+			assert.Equal(t, doc.Pages, got.Pages)
+			// mm10 is coarser than a pixel at 300 DPI.
+			for _, i := range []int{0, 2, 4, 6} {
+				want, box := doc.Characters[i].BoundingBox, got.Characters[i].BoundingBox
+				assert.InDelta(t, want.X1, box.X1, 1, i)
+				assert.InDelta(t, want.Y1, box.Y1, 1, i)
+				assert.InDelta(t, want.X2, box.X2, 1, i)
+				assert.InDelta(t, want.Y2, box.Y2, 1, i)
+			}
+		})
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	doc, err := eocr.NewDocumentFromText("foo bar")
+	require.NoError(t, err)
+	doc.FontStyles = []*ocr.FontStyle{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Style: ocr.BOLD},
+		{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Style: ocr.UNDERLINE},
+	}
+	doc.Characters[5].Error = 20
+
+	data, err := Marshal(doc)
+	require.NoError(t, err)
+	out := string(data)
+	assert.Contains(t, out, `<String ID="page_1_block_1_line_1_string_1" CONTENT="foo" HPOS="0" VPOS="0" WIDTH="30" HEIGHT="10" WC="1" STYLE="bold underline">`)
+	assert.Contains(t, out, `<SP WIDTH="10" HPOS="30" VPOS="0"/>`)
+	assert.Contains(t, out, `<Glyph CONTENT="a" HPOS="50" VPOS="0" WIDTH="10" HEIGHT="10" GC="0.8"/>`)
+	assert.NotContains(t, out, "<Styles>")
+	assert.Equal(t, 1, strings.Count(out, "<TextBlock"))
+
+	doc.Pages[0].DpiX = 0
+	_, err = MarshalWithOptions(doc, Options{Unit: UnitMM10})
+	assert.EqualError(t, err, "alto: page 0: no resolution to convert pixels to mm10")
+	doc.Pages[0] = nil
+	_, err = MarshalWithOptions(doc, Options{Unit: UnitMM10})
+	assert.EqualError(t, err, "alto: page 0: missing page")
+	_, err = MarshalWithOptions(doc, Options{Unit: "point"})
+	assert.EqualError(t, err, `alto: unsupported measurement unit "point"`)
+}
+
+func TestMarshalInvalidXMLCharacters(t *testing.T) {
+	doc, err := eocr.NewDocumentFromText("a\x01b \ufffec")
+	require.NoError(t, err)
+	doc.Fonts = []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Name: "Bad\x02Font"}}
+
+	data, err := Marshal(doc)
+	require.NoError(t, err)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	got, err := Unmarshal(data)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Fields("a\ufffdb \ufffdc"), strings.Fields(eocr.Text(got)))
+	assert.Equal(t, "Bad\ufffdFont", got.Fonts[0].Name)
+}
+
+func TestMarshalLegacyFile(t *testing.T) {
+	doc, err := eocr.ReadFile("../../../testdata/jbs.kiraocr")
+	require.NoError(t, err)
+
+	data, err := MarshalWithOptions(doc, Options{Unit: UnitMM10})
+	require.NoError(t, err)
+	got, err := Unmarshal(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, strings.Fields(eocr.Text(doc)), strings.Fields(eocr.Text(got)))
+	assert.Len(t, got.Pages, len(doc.Pages))
+	assert.Empty(t, eocr.Validate(got))
+}
@@ -0,0 +1,287 @@
+// Package azure converts the saved analyzeResult of Azure AI Document
+// Intelligence, formerly Form Recognizer, to ocr.Document. The results of the
+// prebuilt read and layout models of API version 2022-08-31 and later are
+// read.
+//
+// See https://learn.microsoft.com/en-us/azure/ai-services/document-intelligence/
+// for the format.
+package azure
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/zuvaai/eocr-utils/internal/builder"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Units of page dimensions and polygons.
+const (
+	unitInch  = "inch"
+	unitPixel = "pixel"
+)
+
+// defaultDPI is the resolution of pages when Options.DPI is zero.
+const defaultDPI = 300
+
+// ErrNoPages means that the result has no pages.
+var ErrNoPages = errors.New("azure: no pages")
+
+// Options configures the conversion of documents.
+type Options struct {
+	// DPI is the resolution used to convert pages measured in inches, as
+	// PDF pages are, to pixels. It is the resolution of every page. Zero
+	// means 300.
+	DPI uint32
+}
+
+// ReadFile reads a file holding an analyzeResult.
+func ReadFile(filename string) (*ocr.Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Read reads an analyzeResult from r.
+func Read(r io.Reader) (*ocr.Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal converts an analyzeResult to an ocr.Document with the default
+// options.
+func Unmarshal(data []byte) (*ocr.Document, error) {
+	return UnmarshalWithOptions(data, Options{})
+}
+
+// UnmarshalWithOptions converts an analyzeResult to an ocr.Document.
+//
+// data holds the response of an analyze operation, or its analyzeResult.
+// Pages are ordered by pageNumber and sized from their width, height and
+// unit. The words of a page are ordered by the offset of their span in the
+// content, and separated by a space unless their spans are adjacent, as the
+// characters of Chinese text are. A line ends after the last word within the
+// spans of a line. Boxes are the bounds of polygons, divided evenly between
+// the characters of a word, and word confidences become character errors.
+// Each table becomes a table on every page it covers, with a cell for each
+// of its cells ordered by rowIndex and columnIndex. A cell spanning rows or
+// columns is a single cell whose box, from its polygon, covers them, so its
+// rowSpan and columnSpan aren't needed.
+//
+// The md5 of the document is the md5 of data.
+func UnmarshalWithOptions(data []byte, opts Options) (*ocr.Document, error) {
+	if opts.DPI == 0 {
+		opts.DPI = defaultDPI
+	}
+	var resp response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("azure: %w", err)
+	}
+	res := &resp.result
+	if resp.AnalyzeResult != nil {
+		res = resp.AnalyzeResult
+	}
+	if len(res.Pages) == 0 {
+		return nil, ErrNoPages
+	}
+	sort.SliceStable(res.Pages, func(i, j int) bool { return res.Pages[i].PageNumber < res.Pages[j].PageNumber })
+
+	b := builder.New()
+	for i := range res.Pages {
+		p := &res.Pages[i]
+		scale, err := p.scale(opts.DPI)
+		if err != nil {
+			return nil, err
+		}
+		b.Page(pixels(p.Width*scale), pixels(p.Height*scale), opts.DPI, opts.DPI)
+		addWords(b, p, scale)
+		addTables(b, res.Tables, p.PageNumber, scale)
+	}
+	sum := md5.Sum(data)
+	return b.Document(sum[:], ""), nil
+}
+
+type response struct {
+	AnalyzeResult *result `json:"analyzeResult"`
+	result
+}
+
+type result struct {
+	Pages  []page  `json:"pages"`
+	Tables []table `json:"tables"`
+}
+
+type page struct {
+	PageNumber int     `json:"pageNumber"`
+	Width      float64 `json:"width"`
+	Height     float64 `json:"height"`
+	Unit       string  `json:"unit"`
+	Words      []word  `json:"words"`
+	Lines      []line  `json:"lines"`
+}
+
+type word struct {
+	Content    string  `json:"content"`
+	Polygon    polygon `json:"polygon"`
+	Confidence float64 `json:"confidence"`
+	Span       span    `json:"span"`
+}
+
+type line struct {
+	Spans []span `json:"spans"`
+}
+
+type span struct {
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+}
+
+func (s span) end() int {
+	return s.Offset + s.Length
+}
+
+type table struct {
+	Cells []cell `json:"cells"`
+}
+
+type cell struct {
+	RowIndex        int              `json:"rowIndex"`
+	ColumnIndex     int              `json:"columnIndex"`
+	BoundingRegions []boundingRegion `json:"boundingRegions"`
+}
+
+type boundingRegion struct {
+	PageNumber int     `json:"pageNumber"`
+	Polygon    polygon `json:"polygon"`
+}
+
+// polygon is a list of points, x and y alternating. API version 2022-06-30
+// wrote objects with x and y instead, which are read too.
+type polygon []float64
+
+func (p *polygon) UnmarshalJSON(data []byte) error {
+	var flat []float64
+	if err := json.Unmarshal(data, &flat); err == nil {
+		*p = flat
+		return nil
+	}
+	var points []struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+	}
+	if err := json.Unmarshal(data, &points); err != nil {
+		return fmt.Errorf("invalid polygon %s", data)
+	}
+	*p = make(polygon, 0, 2*len(points))
+	for _, pt := range points {
+		*p = append(*p, pt.X, pt.Y)
+	}
+	return nil
+}
+
+// box returns the bounds of p in pixels, or nil if it has no points.
+func (p polygon) box(scale float64) *ocr.BoundingBox {
+	if len(p) < 2 {
+		return nil
+	}
+	x1, y1, x2, y2 := p[0], p[1], p[0], p[1]
+	for i := 2; i+1 < len(p); i += 2 {
+		x1, y1 = math.Min(x1, p[i]), math.Min(y1, p[i+1])
+		x2, y2 = math.Max(x2, p[i]), math.Max(y2, p[i+1])
+	}
+	return &ocr.BoundingBox{X1: pixels(x1 * scale), Y1: pixels(y1 * scale), X2: pixels(x2 * scale), Y2: pixels(y2 * scale)}
+}
+
+func pixels(v float64) uint32 {
+	if v <= 0 {
+		return 0
+	}
+	return uint32(math.Round(v))
+}
+
+// scale returns the factor converting the unit of p to pixels.
+func (p *page) scale(dpi uint32) (float64, error) {
+	switch p.Unit {
+	case unitInch:
+		return float64(dpi), nil
+	case unitPixel:
+		return 1, nil
+	}
+	return 0, fmt.Errorf("azure: page %d: unsupported unit %q", p.PageNumber, p.Unit)
+}
+
+func addWords(b *builder.Builder, p *page, scale float64) {
+	words := append([]word(nil), p.Words...)
+	sort.SliceStable(words, func(i, j int) bool { return words[i].Span.Offset < words[j].Span.Offset })
+	// lineEnds holds the end offsets of the lines of the page.
+	var lineEnds []int
+	for _, l := range p.Lines {
+		if len(l.Spans) > 0 {
+			lineEnds = append(lineEnds, l.Spans[len(l.Spans)-1].end())
+		}
+	}
+	sort.Ints(lineEnds)
+
+	var glyphs []builder.Glyph
+	for i, w := range words {
+		glyphs = append(glyphs, builder.Split(w.Content, w.Polygon.box(scale), builder.ErrorFromConfidence(w.Confidence*100))...)
+		if i+1 < len(words) && words[i+1].Span.Offset == w.Span.end() && !endsLine(lineEnds, w.Span) {
+			continue
+		}
+		b.Word(glyphs, builder.Style{})
+		glyphs = nil
+		if i+1 == len(words) || endsLine(lineEnds, w.Span) || startsLine(lineEnds, w.Span, words[i+1].Span) {
+			b.EndLine()
+		}
+	}
+}
+
+// endsLine reports whether a word with span s ends a line.
+func endsLine(lineEnds []int, s span) bool {
+	i := sort.SearchInts(lineEnds, s.end())
+	return i < len(lineEnds) && lineEnds[i] == s.end()
+}
+
+// startsLine reports whether the word with span next is on a later line than
+// the word with span prev, which happens when prev isn't part of a line.
+func startsLine(lineEnds []int, prev, next span) bool {
+	i := sort.SearchInts(lineEnds, prev.end())
+	return i < len(lineEnds) && lineEnds[i] < next.end()
+}
+
+// addTables adds the cells of tables on the page with the given number. Each
+// table on the page becomes a table.
+func addTables(b *builder.Builder, tables []table, pageNumber int, scale float64) {
+	for _, t := range tables {
+		cells := append([]cell(nil), t.Cells...)
+		sort.SliceStable(cells, func(i, j int) bool {
+			if cells[i].RowIndex != cells[j].RowIndex {
+				return cells[i].RowIndex < cells[j].RowIndex
+			}
+			return cells[i].ColumnIndex < cells[j].ColumnIndex
+		})
+		id, ok := uint32(0), false
+		for _, c := range cells {
+			for _, r := range c.BoundingRegions {
+				if r.PageNumber != pageNumber {
+					continue
+				}
+				if !ok {
+					id, ok = b.Table(uint32(b.Pages()-1)), true
+				}
+				b.Cell(id, r.Polygon.box(scale))
+			}
+		}
+	}
+}
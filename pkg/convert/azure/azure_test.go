@@ -0,0 +1,121 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestReadFile(t *testing.T) {
+	doc, err := ReadFile("../../../testdata/azure.json")
+	require.NoError(t, err)
+
+	// Adjacent words, such as Chinese characters, aren't separated.
+	assert.Equal(t, "Supply Agreement 合同 Price $10 Total Signed ", eocr.Text(doc))
+	assert.Equal(t, []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 36}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+		{CharacterSpan: &ocr.Span{Start: 36, End: 43}, Width: 1000, Height: 800, DpiX: 300, DpiY: 300},
+	}, doc.Pages)
+	assert.Equal(t, &ocr.Character{Unicode: 'S', Error: 1, BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 300, X2: 350, Y2: 360}}, doc.Characters[0])
+	assert.Equal(t, &ocr.Character{Unicode: ' ', BoundingBox: &ocr.BoundingBox{X1: 600, Y1: 300, X2: 630, Y2: 360}}, doc.Characters[6])
+	assert.Equal(t, &ocr.Character{Unicode: '合', Error: 10, BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 450, X2: 360, Y2: 510}}, doc.Characters[17])
+	assert.Equal(t, &ocr.Character{Unicode: '同', Error: 10, BoundingBox: &ocr.BoundingBox{X1: 360, Y1: 450, X2: 420, Y2: 510}}, doc.Characters[18])
+	// Pixel pages aren't scaled.
+	assert.Equal(t, &ocr.Character{Unicode: 'S', Error: 50, BoundingBox: &ocr.BoundingBox{X1: 100, Y1: 100, X2: 150, Y2: 140}}, doc.Characters[36])
+
+	assert.Equal(t, []*ocr.Table{{Id: 0, PageNumber: 0}}, doc.Tables)
+	assert.Equal(t, []*ocr.TableCell{
+		{Id: 0, BoundingBox: &ocr.BoundingBox{X1: 270, Y1: 870, X2: 870, Y2: 1020}},
+		{Id: 0, BoundingBox: &ocr.BoundingBox{X1: 870, Y1: 870, X2: 1470, Y2: 1020}},
+		{Id: 0, BoundingBox: &ocr.BoundingBox{X1: 270, Y1: 1020, X2: 1470, Y2: 1170}},
+	}, doc.TableCells)
+
+	assert.Empty(t, eocr.Validate(doc))
+}
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		data       string
+		opts       Options
+		wantText   string
+		wantPages  []*ocr.Page
+		wantBox    *ocr.BoundingBox
+		wantTables []*ocr.Table
+	}{
+		"dpi": {
+			data: `{"pages": [{"pageNumber": 1, "width": 8.5, "height": 11, "unit": "inch",
+"words": [{"content": "a", "polygon": [1, 1, 2, 1, 2, 2, 1, 2], "confidence": 1, "span": {"offset": 0, "length": 1}}]}]}`,
+			opts:      Options{DPI: 100},
+			wantText:  "a ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Width: 850, Height: 1100, DpiX: 100, DpiY: 100}},
+			wantBox:   &ocr.BoundingBox{X1: 100, Y1: 100, X2: 200, Y2: 200},
+		},
+		"points and words outside lines": {
+			data: `{"analyzeResult": {"pages": [{"pageNumber": 1, "width": 100, "height": 100, "unit": "pixel",
+"words": [
+{"content": "a", "polygon": [{"x": 10, "y": 20}, {"x": 30, "y": 40}], "confidence": 1, "span": {"offset": 0, "length": 1}},
+{"content": "b", "confidence": 1, "span": {"offset": 2, "length": 1}},
+{"content": "c", "confidence": 1, "span": {"offset": 4, "length": 1}}],
+"lines": [{"spans": [{"offset": 2, "length": 1}]}]}]}}`,
+			wantText:  "a b c ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 6}, Width: 100, Height: 100, DpiX: 300, DpiY: 300}},
+			wantBox:   &ocr.BoundingBox{X1: 10, Y1: 20, X2: 30, Y2: 40},
+		},
+		"table across pages": {
+			data: `{"pages": [
+{"pageNumber": 1, "width": 100, "height": 100, "unit": "pixel"},
+{"pageNumber": 2, "width": 100, "height": 100, "unit": "pixel", "words": [{"content": "a", "span": {"offset": 0, "length": 1}}]}],
+"tables": [{"cells": [
+{"rowIndex": 0, "columnIndex": 0, "boundingRegions": [{"pageNumber": 1, "polygon": [0, 0, 10, 10]}]},
+{"rowIndex": 1, "columnIndex": 0, "boundingRegions": [{"pageNumber": 2, "polygon": [0, 0, 10, 10]}]}]}]}`,
+			wantText: "a ",
+			wantPages: []*ocr.Page{
+				{CharacterSpan: &ocr.Span{Start: 0, End: 0}, Width: 100, Height: 100, DpiX: 300, DpiY: 300},
+				{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Width: 100, Height: 100, DpiX: 300, DpiY: 300},
+			},
+			wantTables: []*ocr.Table{{Id: 0, PageNumber: 0}, {Id: 1, PageNumber: 1}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := UnmarshalWithOptions([]byte(tt.data), tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantText, eocr.Text(doc))
+			assert.Equal(t, tt.wantPages, doc.Pages)
+			assert.Equal(t, tt.wantBox, doc.Characters[0].BoundingBox)
+			assert.Equal(t, tt.wantTables, doc.Tables)
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"no pages": {
+			data:    `{"status": "running"}`,
+			wantErr: ErrNoPages.Error(),
+		},
+		"unit": {
+			data:    `{"pages": [{"pageNumber": 1, "width": 210, "height": 297, "unit": "mm"}]}`,
+			wantErr: `azure: page 1: unsupported unit "mm"`,
+		},
+		"polygon": {
+			data:    `{"pages": [{"words": [{"polygon": "1 2"}]}]}`,
+			wantErr: `azure: invalid polygon "1 2"`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.data))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Package docx converts Word documents in the Office Open XML format to
+// ocr.Document, laying out their text on virtual pages the way word2ocr does.
+//
+// The paragraphs and tables of the document body are read, with the fonts,
+// sizes and styles of their runs. Headers, footers, footnotes, comments,
+// numbering and the contents of drawings and text boxes aren't. The layout
+// approximates Word's: glyph widths come from the class of each character
+// rather than from font files, so lines and pages break at about the same
+// places but not exactly.
+//
+// See ECMA-376 Part 1 for the format.
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/zuvaai/eocr-utils/internal/builder"
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// defaultDPI is the resolution of pages when Options.DPI is zero.
+const defaultDPI = 300
+
+// Relationship types of the parts read.
+const (
+	relOfficeDocument = "/officeDocument"
+	relStyles         = "/styles"
+	relTheme          = "/theme"
+)
+
+// ErrNoDocument means that the package has no main document part.
+var ErrNoDocument = errors.New("docx: no document part")
+
+// Options configures the conversion of documents.
+type Options struct {
+	// DPI is the resolution of the virtual pages. Zero means 300.
+	DPI uint32
+}
+
+// ReadFile reads a docx file. See UnmarshalWithOptions.
+func ReadFile(filename string) (*ocr.Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Read reads a docx file from r. See UnmarshalWithOptions.
+func Read(r io.Reader) (*ocr.Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal converts a docx file to an ocr.Document with the default options.
+// See UnmarshalWithOptions.
+func Unmarshal(data []byte) (*ocr.Document, error) {
+	return UnmarshalWithOptions(data, Options{})
+}
+
+// UnmarshalWithOptions converts a docx file to an ocr.Document.
+//
+// Pages are sized by the section properties of the document, and the text of
+// each paragraph is broken into lines within the margins and indents, with
+// the alignment and spacing of the paragraph. Page breaks, section breaks and
+// paragraphs that don't fit start a new page. Hidden and deleted text is
+// skipped.
+//
+// Runs get a font and font size from their properties and styles, and the
+// bold, italic, underline, strikethrough, superscript, subscript and small
+// caps styles. Each table becomes a table on every page it covers, with a
+// cell for each cell of its rows, sized by the table grid. Cells spanning
+// columns with gridSpan are a single cell, and cells merged vertically with
+// vMerge are a single cell on each page. Cells get the color of their shading
+// and the widths of their borders. The text of a table nested in a cell is
+// laid out as paragraphs of the cell.
+//
+// The md5 of the document is the md5 of data and its source is
+// eocr.Word2ocr.
+func UnmarshalWithOptions(data []byte, opts Options) (*ocr.Document, error) {
+	if opts.DPI == 0 {
+		opts.DPI = defaultDPI
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("docx: %w", err)
+	}
+	pkg := &pkg{files: map[string]*zip.File{}}
+	for _, f := range zr.File {
+		pkg.files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	docPath := pkg.target("", relOfficeDocument)
+	if docPath == "" {
+		docPath = "word/document.xml"
+	}
+	doc, err := pkg.parse(docPath)
+	if err != nil {
+		return nil, err
+	}
+	body := doc.child("body")
+	if doc.XMLName.Local != "document" || body == nil {
+		return nil, ErrNoDocument
+	}
+	st := &styles{byID: map[string]*node{}}
+	if p := pkg.target(docPath, relTheme); p != "" {
+		if theme, err := pkg.parse(p); err == nil {
+			st.readTheme(theme)
+		}
+	}
+	if p := pkg.target(docPath, relStyles); p != "" {
+		if s, err := pkg.parse(p); err == nil {
+			st.read(s)
+		}
+	}
+
+	l := newLayout(builder.New(), st, opts.DPI)
+	l.body(body)
+	sum := md5.Sum(data)
+	return l.b.Document(sum[:], eocr.Word2ocr), nil
+}
+
+// pkg is an Open Packaging Conventions package.
+type pkg struct {
+	files map[string]*zip.File
+}
+
+// parse reads an XML part.
+func (p *pkg) parse(name string) (*node, error) {
+	f, ok := p.files[name]
+	if !ok {
+		return nil, ErrNoDocument
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("docx: %s: %w", name, err)
+	}
+	defer r.Close()
+	var n node
+	if err := xml.NewDecoder(r).Decode(&n); err != nil {
+		return nil, fmt.Errorf("docx: %s: %w", name, err)
+	}
+	return &n, nil
+}
+
+// target returns the path of the first part related to source, the empty
+// string for the package, by a relationship of the type ending in typ.
+func (p *pkg) target(source, typ string) string {
+	dir, file := path.Split(source)
+	rels, err := p.parse(dir + "_rels/" + file + ".rels")
+	if err != nil {
+		return ""
+	}
+	for _, rel := range rels.Nodes {
+		if !strings.HasSuffix(rel.attr("Type"), typ) || rel.attr("TargetMode") == "External" {
+			continue
+		}
+		target := rel.attr("Target")
+		if strings.HasPrefix(target, "/") {
+			return strings.TrimPrefix(target, "/")
+		}
+		return path.Join(dir, target)
+	}
+	return ""
+}
+
+// node is an element of an XML part. Elements and attributes are matched by
+// their local names, since the parts read use one namespace for each.
+type node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []*node    `xml:",any"`
+	Text    string     `xml:",chardata"`
+}
+
+// attr returns the value of an attribute, or the empty string.
+func (n *node) attr(local string) string {
+	if n == nil {
+		return ""
+	}
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (n *node) hasAttr(local string) bool {
+	if n == nil {
+		return false
+	}
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return true
+		}
+	}
+	return false
+}
+
+// child returns the first child element with a local name, or nil.
+func (n *node) child(local string) *node {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.Nodes {
+		if c.XMLName.Local == local {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestReadFile(t *testing.T) {
+	doc, err := ReadFile("../../../testdata/contract.docx")
+	require.NoError(t, err)
+
+	// Hidden text is skipped, and the page break starts a second page.
+	assert.Equal(t, "Supply Agreement The Supplier delivers H2O at noon daily per m2 Item Price Steel $10 each $90 per ten Signed Schedule A ", eocr.Text(doc))
+	assert.Equal(t, []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 109}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+		{CharacterSpan: &ocr.Span{Start: 109, End: 120}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+	}, doc.Pages)
+	// The heading is centered.
+	assert.Equal(t, &ocr.Character{Unicode: 'S', BoundingBox: &ocr.BoundingBox{X1: 1000, Y1: 360, X2: 1048, Y2: 427}}, doc.Characters[0])
+	assert.Equal(t, &ocr.Character{Unicode: 'T', BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 443, X2: 331, Y2: 489}}, doc.Characters[17])
+	// Subscripts are smaller and lowered.
+	assert.Equal(t, &ocr.Character{Unicode: '2', BoundingBox: &ocr.BoundingBox{X1: 771, Y1: 462, X2: 786, Y2: 492}}, doc.Characters[40])
+	// A tab moves to the next tab stop.
+	assert.Equal(t, &ocr.Character{Unicode: 'A', BoundingBox: &ocr.BoundingBox{X1: 600, Y1: 307, X2: 631, Y2: 353}}, doc.Characters[118])
+
+	assert.Equal(t, []*ocr.Font{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 17}, Name: "Calibri Light"},
+		{CharacterSpan: &ocr.Span{Start: 17, End: 120}, Name: "Calibri"},
+	}, doc.Fonts)
+	assert.Equal(t, []*ocr.FontSize{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 17}, Size_: 16},
+		{CharacterSpan: &ocr.Span{Start: 17, End: 120}, Size_: 11},
+	}, doc.FontSizes)
+	assert.Equal(t, []*ocr.FontStyle{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 17}, Style: ocr.BOLD},
+		{CharacterSpan: &ocr.Span{Start: 21, End: 30}, Style: ocr.BOLD},
+		{CharacterSpan: &ocr.Span{Start: 21, End: 30}, Style: ocr.ITALIC},
+		{CharacterSpan: &ocr.Span{Start: 40, End: 41}, Style: ocr.SUBSCRIPT},
+		{CharacterSpan: &ocr.Span{Start: 46, End: 51}, Style: ocr.UNDERLINE},
+		{CharacterSpan: &ocr.Span{Start: 51, End: 57}, Style: ocr.STRIKETHROUGH},
+		{CharacterSpan: &ocr.Span{Start: 57, End: 61}, Style: ocr.SMALLCAPS},
+		{CharacterSpan: &ocr.Span{Start: 62, End: 64}, Style: ocr.SUPERSCRIPT},
+	}, doc.FontStyles)
+
+	assert.Equal(t, []*ocr.Table{{Id: 0, PageNumber: 0}}, doc.Tables)
+	shaded := &ocr.Color{R: 0xd9, G: 0xe2, B: 0xf3}
+	assert.Equal(t, []*ocr.TableCell{
+		tableCell(300, 529, 925, 622, shaded),
+		// The cell spans two columns.
+		tableCell(925, 529, 2175, 622, shaded),
+		// The cell is merged with the one below it.
+		tableCell(300, 622, 925, 807, nil),
+		tableCell(925, 622, 1550, 714, nil),
+		tableCell(1550, 622, 2175, 714, nil),
+		tableCell(925, 714, 1550, 807, nil),
+		tableCell(1550, 714, 2175, 807, nil),
+	}, doc.TableCells)
+	assert.Equal(t, eocr.Word2ocr, doc.Source)
+
+	assert.Empty(t, eocr.Validate(doc))
+}
+
+// tableCell returns a cell of the table in contract.docx, whose borders are all
+// half a point wide.
+func tableCell(x1, y1, x2, y2 uint32, color *ocr.Color) *ocr.TableCell {
+	return &ocr.TableCell{
+		BoundingBox:     &ocr.BoundingBox{X1: x1, Y1: y1, X2: x2, Y2: y2},
+		BackgroundColor: color,
+		LeftBorderWidth: 2, RightBorderWidth: 2, TopBorderWidth: 2, BottomBorderWidth: 2,
+	}
+}
+
+// buildDocx returns a docx file with the given body and no styles.
+func buildDocx(t *testing.T, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body + `</w:body></w:document>`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		body      string
+		opts      Options
+		wantText  string
+		wantPages []*ocr.Page
+		wantBox   *ocr.BoundingBox
+		wantFonts []*ocr.Font
+	}{
+		"defaults": {
+			body:      `<w:p><w:r><w:t>Hi</w:t></w:r></w:p>`,
+			wantText:  "Hi ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300}},
+			wantBox:   &ocr.BoundingBox{X1: 300, Y1: 304, X2: 328, Y2: 346},
+			wantFonts: []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Name: "Times New Roman", Serif: true}},
+		},
+		"wrapping in a4 section": {
+			body: `<w:p><w:r><w:rPr><w:rFonts w:ascii="Courier New"/><w:caps/><w:sz w:val="20"/></w:rPr><w:t>aaaa bbbb</w:t></w:r></w:p>
+<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="720" w:right="5906" w:bottom="720" w:left="5000"/></w:sectPr>`,
+			opts:      Options{DPI: 72},
+			wantText:  "AAAA BBBB ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 10}, Width: 595, Height: 842, DpiX: 72, DpiY: 72}},
+			wantBox:   &ocr.BoundingBox{X1: 250, Y1: 37, X2: 256, Y2: 47},
+			wantFonts: []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 10}, Name: "Courier New", Serif: true, Monospace: true}},
+		},
+		"section break": {
+			body: `<w:p><w:pPr><w:sectPr><w:pgSz w:w="7200" w:h="7200"/></w:sectPr></w:pPr><w:r><w:t>a</w:t></w:r></w:p>
+<w:sdt><w:sdtContent><w:p><w:hyperlink><w:r><w:t>b</w:t></w:r></w:hyperlink><w:del><w:r><w:delText>c</w:delText></w:r></w:del></w:p></w:sdtContent></w:sdt>`,
+			opts:     Options{DPI: 72},
+			wantText: "a b ",
+			wantPages: []*ocr.Page{
+				{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Width: 360, Height: 360, DpiX: 72, DpiY: 72},
+				{CharacterSpan: &ocr.Span{Start: 2, End: 4}, Width: 612, Height: 792, DpiX: 72, DpiY: 72},
+			},
+			wantBox:   &ocr.BoundingBox{X1: 72, Y1: 73, X2: 77, Y2: 83},
+			wantFonts: []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 4}, Name: "Times New Roman", Serif: true}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := UnmarshalWithOptions(buildDocx(t, tt.body), tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantText, eocr.Text(doc))
+			assert.Equal(t, tt.wantPages, doc.Pages)
+			assert.Equal(t, tt.wantBox, doc.Characters[0].BoundingBox)
+			assert.Equal(t, tt.wantFonts, doc.Fonts)
+			assert.Empty(t, eocr.Validate(doc))
+		})
+	}
+}
+
+func TestUnmarshalTableWithoutGrid(t *testing.T) {
+	doc, err := Unmarshal(buildDocx(t, `<w:tbl><w:tr>
+<w:tc><w:p><w:r><w:t>a</w:t></w:r></w:p></w:tc>
+<w:tc><w:tcPr><w:gridSpan w:val="2"/></w:tcPr><w:p><w:r><w:t>b</w:t></w:r></w:p></w:tc>
+</w:tr></w:tbl>`))
+	require.NoError(t, err)
+	assert.Equal(t, "a b ", eocr.Text(doc))
+	require.Len(t, doc.Tables, 1)
+	// The three columns counted from the row share the text width.
+	require.Len(t, doc.TableCells, 2)
+	assert.Equal(t, []uint32{300, 950}, []uint32{doc.TableCells[0].BoundingBox.X1, doc.TableCells[0].BoundingBox.X2})
+	assert.Equal(t, []uint32{950, 2250}, []uint32{doc.TableCells[1].BoundingBox.X1, doc.TableCells[1].BoundingBox.X2})
+	assert.Empty(t, eocr.Validate(doc))
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var empty bytes.Buffer
+	require.NoError(t, zip.NewWriter(&empty).Close())
+
+	tests := map[string]struct {
+		data    []byte
+		wantErr string
+	}{
+		"not a zip file": {
+			data:    []byte("{\\rtf1}"),
+			wantErr: "docx: zip: not a valid zip file",
+		},
+		"no document": {
+			data:    empty.Bytes(),
+			wantErr: ErrNoDocument.Error(),
+		},
+		"malformed": {
+			data:    buildDocx(t, "<w:p>"),
+			wantErr: "docx: word/document.xml: XML syntax error on line 1: element <p> closed by </body>",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Unmarshal(tt.data)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
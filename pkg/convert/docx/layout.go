@@ -0,0 +1,757 @@
+package docx
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/zuvaai/eocr-utils/internal/builder"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Lengths are in twentieths of a point, twips, as WordprocessingML measures
+// them, until they are converted to pixels.
+const (
+	twipsPerInch = 1440
+	// Letter size with one inch margins, Word's default.
+	defaultPageWidth  = 12240
+	defaultPageHeight = 15840
+	defaultMargin     = 1440
+	tabStop           = 720
+	// cellMargin is the default left and right margin of table cells.
+	cellMargin = 108
+)
+
+// Font metrics, in ems.
+const (
+	// lineHeight is the height of a line of single spaced text.
+	lineHeight = 1.2
+	ascent     = 0.8
+	descent    = 0.2
+	spaceWidth = 0.25
+	// scriptScale is the size of superscript and subscript text, which is
+	// raised by superRise or lowered by subDrop.
+	scriptScale = 0.65
+	superRise   = 0.33
+	subDrop     = 0.14
+)
+
+// section is the page geometry of a section.
+type section struct {
+	width, height            float64
+	top, right, bottom, left float64
+	// breakType is the type of the section break starting the section.
+	breakType string
+}
+
+func readSection(sectPr *node) section {
+	s := section{
+		width: defaultPageWidth, height: defaultPageHeight,
+		top: defaultMargin, right: defaultMargin, bottom: defaultMargin, left: defaultMargin,
+		breakType: sectPr.child("type").attr("val"),
+	}
+	if sz := sectPr.child("pgSz"); sz != nil {
+		setTwips(&s.width, sz, "w")
+		setTwips(&s.height, sz, "h")
+	}
+	if m := sectPr.child("pgMar"); m != nil {
+		setTwips(&s.top, m, "top")
+		setTwips(&s.right, m, "right")
+		setTwips(&s.bottom, m, "bottom")
+		setTwips(&s.left, m, "left")
+		// Negative top and bottom margins let text overlap headers.
+		s.top, s.bottom = math.Abs(s.top), math.Abs(s.bottom)
+	}
+	return s
+}
+
+// run is a run of text with its resolved properties.
+type run struct {
+	props runProps
+	style builder.Style
+}
+
+// em returns the size at which the run is laid out.
+func (r *run) em() float64 {
+	em := r.props.size * 20
+	if r.props.superscript || r.props.subscript {
+		em *= scriptScale
+	}
+	return em
+}
+
+// advance returns the width of a character of the run. Characters are
+// measured by class, as no font files are available.
+func (r *run) advance(c rune) float64 {
+	var w float64
+	switch {
+	case r.style.Monospace:
+		w = 0.6
+	case isWide(c):
+		w = 1
+	case strings.ContainsRune("iljtfr.,;:!|'`", c):
+		w = 0.3
+	case strings.ContainsRune("mwMW", c):
+		w = 0.85
+	case unicode.IsUpper(c):
+		w = 0.68
+	case unicode.IsLower(c) && r.props.smallCaps:
+		w = 0.6
+	default:
+		w = 0.5
+	}
+	if r.props.bold {
+		w *= 1.05
+	}
+	return w * r.em()
+}
+
+// isWide reports whether c is a full width character, such as a Chinese
+// character.
+func isWide(c rune) bool {
+	return unicode.In(c, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || (c >= 0xff01 && c <= 0xff60)
+}
+
+// itemKind is the kind of a part of a paragraph.
+type itemKind int
+
+const (
+	itemText itemKind = iota
+	itemTab
+	itemLineBreak
+	itemPageBreak
+)
+
+type item struct {
+	kind itemKind
+	r    rune
+	run  *run
+}
+
+// placed is a character placed on a line.
+type placed struct {
+	r    rune
+	run  *run
+	x, w float64
+}
+
+// line is a line of a paragraph. Its characters are placed relative to the
+// left edge of the text.
+type line struct {
+	words [][]placed
+	// em is the size of the largest text on the line, and height the
+	// height of the line.
+	em, height float64
+	// pageBreak is set if a page break ends the line.
+	pageBreak bool
+}
+
+// layout lays out the blocks of a document on pages.
+type layout struct {
+	b   *builder.Builder
+	st  *styles
+	dpi float64
+	// sect is the geometry of the current section, and y the top of the
+	// next line on the current page.
+	sect section
+	y    float64
+	// empty is set while the current page has no content.
+	empty bool
+}
+
+func newLayout(b *builder.Builder, st *styles, dpi uint32) *layout {
+	return &layout{b: b, st: st, dpi: float64(dpi)}
+}
+
+func (l *layout) px(v float64) uint32 {
+	if v <= 0 {
+		return 0
+	}
+	return uint32(math.Round(v * l.dpi / twipsPerInch))
+}
+
+func (l *layout) newPage() {
+	l.b.Page(l.px(l.sect.width), l.px(l.sect.height), uint32(l.dpi), uint32(l.dpi))
+	l.y = l.sect.top
+	l.empty = true
+}
+
+// fits reports whether a block of the given height fits on the current page.
+// Anything fits on an empty page.
+func (l *layout) fits(height float64) bool {
+	return l.empty || l.y+height <= l.sect.height-l.sect.bottom
+}
+
+// body lays out the body of the document.
+func (l *layout) body(body *node) {
+	var blocks []*node
+	var flatten func(n *node)
+	flatten = func(n *node) {
+		for _, c := range n.Nodes {
+			switch c.XMLName.Local {
+			case "p", "tbl":
+				blocks = append(blocks, c)
+			case "sdt", "sdtContent", "customXml":
+				flatten(c)
+			}
+		}
+	}
+	flatten(body)
+
+	// A section ends with the paragraph holding its properties, and the
+	// last one with the body.
+	sections := make([]section, len(blocks))
+	index := make([]int, len(blocks))
+	sect, n := readSection(body.child("sectPr")), 0
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if s := blocks[i].child("pPr").child("sectPr"); s != nil {
+			sect, n = readSection(s), n+1
+		}
+		sections[i], index[i] = sect, n
+	}
+
+	l.sect = sect
+	if len(blocks) > 0 {
+		l.sect = sections[0]
+	}
+	l.newPage()
+	for i, block := range blocks {
+		if i > 0 && index[i] != index[i-1] {
+			l.sect = sections[i]
+			if l.sect.breakType != "continuous" {
+				l.newPage()
+			}
+		}
+		if block.XMLName.Local == "tbl" {
+			l.table(block)
+			continue
+		}
+		pp := l.st.paraProps(block.child("pPr"))
+		if pp.pageBreakBefore && !l.empty {
+			l.newPage()
+		}
+		l.y += pp.before
+		for _, ln := range l.paragraph(block, &pp, l.sect.width-l.sect.left-l.sect.right) {
+			if !l.fits(ln.height) {
+				l.newPage()
+			}
+			l.emit(ln, l.sect.left, l.y)
+			l.y += ln.height
+			l.empty = false
+			if ln.pageBreak {
+				l.newPage()
+			}
+		}
+		l.y += pp.after
+	}
+}
+
+// paragraph breaks a paragraph into lines of the given width.
+func (l *layout) paragraph(p *node, pp *paraProps, width float64) []*line {
+	items := l.items(p, pp)
+	left, right := pp.left, width-pp.right
+	if right < left+twipsPerInch/4 {
+		right = left + twipsPerInch/4
+	}
+
+	var lines []*line
+	cur := &line{}
+	var word []placed
+	x := left + pp.firstLine
+	finish := func() {
+		if cur.em == 0 {
+			mark := &run{props: l.st.runProps(pp.style, p.child("pPr").child("rPr"))}
+			cur.em = mark.em()
+		}
+		cur.height = pp.height(cur.em)
+		align(cur, pp.align, right)
+		lines = append(lines, cur)
+		cur, x = &line{}, left
+	}
+	endWord := func() {
+		if len(word) > 0 {
+			cur.words = append(cur.words, word)
+			word = nil
+		}
+	}
+
+	for _, it := range items {
+		if it.kind != itemText {
+			cur.em = math.Max(cur.em, it.run.em())
+		}
+		switch it.kind {
+		case itemTab:
+			endWord()
+			x = (math.Floor(x/tabStop) + 1) * tabStop
+		case itemLineBreak:
+			endWord()
+			finish()
+		case itemPageBreak:
+			endWord()
+			cur.pageBreak = true
+			finish()
+		case itemText:
+			if unicode.IsSpace(it.r) {
+				endWord()
+				x += spaceWidth * it.run.em()
+				continue
+			}
+			w := it.run.advance(it.r)
+			if x+w > right && len(cur.words) > 0 {
+				// Move the word to the next line.
+				moved := word
+				word = nil
+				finish()
+				if len(moved) > 0 {
+					dx := left - moved[0].x
+					for i := range moved {
+						moved[i].x += dx
+						cur.em = math.Max(cur.em, moved[i].run.em())
+					}
+					x = moved[len(moved)-1].x + moved[len(moved)-1].w
+				}
+				word = moved
+			} else if x+w > right && len(word) > 0 {
+				// The word is longer than the line, so break it.
+				endWord()
+				finish()
+			}
+			cur.em = math.Max(cur.em, it.run.em())
+			word = append(word, placed{r: it.r, run: it.run, x: x, w: w})
+			x += w
+		}
+	}
+	endWord()
+	finish()
+	return lines
+}
+
+// height returns the height of a line whose largest text has the given size.
+func (pp *paraProps) height(em float64) float64 {
+	switch pp.lineRule {
+	case "exact":
+		return pp.line
+	case "atLeast":
+		return math.Max(pp.line, em*lineHeight)
+	}
+	return em * lineHeight * pp.line / 240
+}
+
+// align moves the characters of a line for centered and right aligned
+// paragraphs.
+func align(ln *line, jc string, right float64) {
+	if len(ln.words) == 0 {
+		return
+	}
+	last := ln.words[len(ln.words)-1]
+	free := right - (last[len(last)-1].x + last[len(last)-1].w)
+	var dx float64
+	switch jc {
+	case "center":
+		dx = free / 2
+	case "right", "end":
+		dx = free
+	default:
+		return
+	}
+	for _, w := range ln.words {
+		for i := range w {
+			w[i].x += dx
+		}
+	}
+}
+
+// items returns the characters and breaks of a paragraph.
+func (l *layout) items(p *node, pp *paraProps) []item {
+	var items []item
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range n.Nodes {
+			switch c.XMLName.Local {
+			case "r":
+				props := l.st.runProps(pp.style, c.child("rPr"))
+				if props.hide {
+					continue
+				}
+				items = append(items, runItems(c, newRun(props))...)
+			case "hyperlink", "ins", "smartTag", "customXml", "fldSimple", "moveTo", "sdt", "sdtContent", "bdo", "dir":
+				walk(c)
+			}
+		}
+	}
+	walk(p)
+	return items
+}
+
+func newRun(props runProps) *run {
+	return &run{props: props, style: builder.Style{
+		Font:      props.font,
+		Serif:     hasAny(props.font, serifFonts),
+		Monospace: hasAny(props.font, monospaceFonts),
+		Size:      uint32(math.Round(props.size)),
+		Styles:    props.fontStyles(),
+	}}
+}
+
+// runItems returns the content of a run.
+func runItems(r *node, ru *run) []item {
+	var items []item
+	text := func(c rune) {
+		if ru.props.caps {
+			c = unicode.ToUpper(c)
+		}
+		items = append(items, item{kind: itemText, r: c, run: ru})
+	}
+	for _, c := range r.Nodes {
+		switch c.XMLName.Local {
+		case "t":
+			for _, ch := range c.Text {
+				text(ch)
+			}
+		case "tab", "ptab":
+			items = append(items, item{kind: itemTab, run: ru})
+		case "br":
+			if c.attr("type") == "page" {
+				items = append(items, item{kind: itemPageBreak, run: ru})
+			} else {
+				items = append(items, item{kind: itemLineBreak, run: ru})
+			}
+		case "cr":
+			items = append(items, item{kind: itemLineBreak, run: ru})
+		case "noBreakHyphen":
+			text('-')
+		case "sym":
+			if v, err := strconv.ParseUint(c.attr("char"), 16, 32); err == nil {
+				// Symbol fonts map their characters to the private
+				// use area.
+				if v >= 0xf020 && v <= 0xf0ff {
+					v -= 0xf000
+				}
+				text(rune(v))
+			}
+		}
+	}
+	return items
+}
+
+// emit adds a line to the builder, with its left edge at x and top at y.
+func (l *layout) emit(ln *line, x, y float64) {
+	baseline := y + (ln.height-ln.em*(ascent+descent))/2 + ln.em*ascent
+	for _, w := range ln.words {
+		var part []builder.Glyph
+		var partRun *run
+		first := true
+		flush := func() {
+			if len(part) == 0 {
+				return
+			}
+			if first {
+				l.b.Word(part, partRun.style)
+			} else {
+				l.b.Append(part, partRun.style)
+			}
+			part, first = nil, false
+		}
+		for _, c := range w {
+			if c.run != partRun {
+				flush()
+				partRun = c.run
+			}
+			em, rise := c.run.em(), 0.0
+			switch {
+			case c.run.props.superscript:
+				rise = superRise * c.run.props.size * 20
+			case c.run.props.subscript:
+				rise = -subDrop * c.run.props.size * 20
+			}
+			part = append(part, builder.Glyph{Rune: c.r, Box: &ocr.BoundingBox{
+				X1: l.px(x + c.x),
+				Y1: l.px(baseline - rise - em*ascent),
+				X2: l.px(x + c.x + c.w),
+				Y2: l.px(baseline - rise + em*descent),
+			}})
+		}
+		flush()
+	}
+	l.b.EndLine()
+}
+
+// cell is a table cell being laid out.
+type cell struct {
+	tc        *node
+	col, span int
+	x, width  float64
+	continued bool
+	lines     []*line
+	tops      []float64
+	height    float64
+}
+
+// mergedCell is the cell that vertically merged cells extend.
+type mergedCell struct {
+	cell *ocr.TableCell
+	page int
+}
+
+// table lays out a table, starting a page before rows that don't fit.
+func (l *layout) table(tbl *node) {
+	tblPr := tbl.child("tblPr")
+	var grid []float64
+	if tblGrid := tbl.child("tblGrid"); tblGrid != nil {
+		for _, col := range tblGrid.Nodes {
+			if col.XMLName.Local == "gridCol" {
+				var w float64
+				setTwips(&w, col, "w")
+				grid = append(grid, w)
+			}
+		}
+	}
+	var rows []*node
+	for _, c := range tbl.Nodes {
+		if c.XMLName.Local == "tr" {
+			rows = append(rows, c)
+		}
+	}
+	// The grid may be missing, so the rows count the columns too.
+	columns := len(grid)
+	for _, tr := range rows {
+		n := gridBefore(tr)
+		for _, tc := range cells(tr) {
+			n += gridSpan(tc)
+		}
+		if n > columns {
+			columns = n
+		}
+	}
+	// Columns missing from the grid share the rest of the text width.
+	textWidth := l.sect.width - l.sect.left - l.sect.right
+	var used float64
+	for _, w := range grid {
+		used += w
+	}
+	if missing := columns - len(grid); missing > 0 {
+		share := math.Max((textWidth-used)/float64(missing), twipsPerInch/2)
+		for len(grid) < columns {
+			grid = append(grid, share)
+		}
+	}
+	var indent float64
+	setTwips(&indent, tblPr.child("tblInd"), "w")
+	borders := l.tableBorders(tblPr)
+
+	tableID, tablePage := uint32(0), -1
+	merged := map[int]mergedCell{}
+	for ri, tr := range rows {
+		var row []*cell
+		col := gridBefore(tr)
+		for _, tc := range cells(tr) {
+			c := &cell{tc: tc, col: col, span: gridSpan(tc)}
+			c.x = l.sect.left + indent + sum(grid[:minInt(col, len(grid))])
+			c.width = sum(grid[minInt(col, len(grid)):minInt(col+c.span, len(grid))])
+			if vm := tc.child("tcPr").child("vMerge"); vm != nil && vm.attr("val") != "restart" {
+				c.continued = true
+			}
+			for _, p := range cellParagraphs(tc) {
+				pp := l.st.paraProps(p.child("pPr"))
+				c.height += pp.before
+				for _, ln := range l.paragraph(p, &pp, c.width-2*cellMargin) {
+					c.lines = append(c.lines, ln)
+					c.tops = append(c.tops, c.height)
+					c.height += ln.height
+				}
+				c.height += pp.after
+			}
+			row = append(row, c)
+			col += c.span
+		}
+
+		height := 0.0
+		for _, c := range row {
+			height = math.Max(height, c.height)
+		}
+		if h := tr.child("trPr").child("trHeight"); h != nil {
+			var v float64
+			setTwips(&v, h, "val")
+			if h.attr("hRule") == "exact" {
+				height = v
+			} else {
+				height = math.Max(height, v)
+			}
+		}
+		if !l.fits(height) {
+			l.newPage()
+		}
+		page := l.b.Pages() - 1
+		if page != tablePage {
+			tableID, tablePage = l.b.Table(uint32(page)), page
+		}
+		for _, c := range row {
+			for i, ln := range c.lines {
+				l.emit(ln, c.x+cellMargin, l.y+c.tops[i])
+			}
+			bottom := minUint32(l.px(l.y+height), l.px(l.sect.height))
+			if m, ok := merged[c.col]; ok && c.continued && m.page == page {
+				m.cell.BoundingBox.Y2 = bottom
+				m.cell.BottomBorderWidth = l.cellBorder(c, borders, "bottom", ri == len(rows)-1, columns)
+				continue
+			}
+			tc := l.b.Cell(tableID, &ocr.BoundingBox{X1: l.px(c.x), Y1: l.px(l.y), X2: l.px(c.x + c.width), Y2: bottom})
+			tc.BackgroundColor = shading(c.tc.child("tcPr").child("shd"))
+			tc.TopBorderWidth = l.cellBorder(c, borders, "top", ri == 0, columns)
+			tc.BottomBorderWidth = l.cellBorder(c, borders, "bottom", ri == len(rows)-1, columns)
+			tc.LeftBorderWidth = l.cellBorder(c, borders, "left", c.col == 0, columns)
+			tc.RightBorderWidth = l.cellBorder(c, borders, "right", c.col+c.span >= columns, columns)
+			merged[c.col] = mergedCell{cell: tc, page: page}
+		}
+		l.y += height
+		l.empty = false
+	}
+}
+
+func cells(tr *node) []*node {
+	var tcs []*node
+	for _, c := range tr.Nodes {
+		if c.XMLName.Local == "tc" {
+			tcs = append(tcs, c)
+		}
+	}
+	return tcs
+}
+
+func gridSpan(tc *node) int {
+	if n, err := strconv.Atoi(tc.child("tcPr").child("gridSpan").attr("val")); err == nil && n > 0 {
+		return n
+	}
+	return 1
+}
+
+func gridBefore(tr *node) int {
+	if n, err := strconv.Atoi(tr.child("trPr").child("gridBefore").attr("val")); err == nil && n > 0 {
+		return n
+	}
+	return 0
+}
+
+// cellParagraphs returns the paragraphs of a cell, including those of tables
+// nested in it.
+func cellParagraphs(n *node) []*node {
+	var paragraphs []*node
+	for _, c := range n.Nodes {
+		switch c.XMLName.Local {
+		case "p":
+			paragraphs = append(paragraphs, c)
+		case "tbl", "tr", "tc", "sdt", "sdtContent", "customXml":
+			paragraphs = append(paragraphs, cellParagraphs(c)...)
+		}
+	}
+	return paragraphs
+}
+
+// tableBorders returns the borders of a table, from its style and its
+// properties, by edge.
+func (l *layout) tableBorders(tblPr *node) map[string]*node {
+	borders := map[string]*node{}
+	add := func(tblBorders *node) {
+		if tblBorders == nil {
+			return
+		}
+		for _, b := range tblBorders.Nodes {
+			borders[edgeName(b.XMLName.Local)] = b
+		}
+	}
+	id := tblPr.child("tblStyle").attr("val")
+	if id == "" {
+		id = l.st.defaultTable
+	}
+	for _, st := range l.st.chain(id) {
+		add(st.child("tblPr").child("tblBorders"))
+	}
+	add(tblPr.child("tblBorders"))
+	return borders
+}
+
+// edgeName maps the logical edges of bidirectional documents to physical
+// ones.
+func edgeName(edge string) string {
+	switch edge {
+	case "start":
+		return "left"
+	case "end":
+		return "right"
+	}
+	return edge
+}
+
+// cellBorder returns the width of an edge of a cell in pixels, from the cell's
+// borders, or the table's outer borders on the outside of the table and its
+// inside borders elsewhere.
+func (l *layout) cellBorder(c *cell, table map[string]*node, edge string, outer bool, columns int) uint32 {
+	if tcBorders := c.tc.child("tcPr").child("tcBorders"); tcBorders != nil {
+		for _, b := range tcBorders.Nodes {
+			if edgeName(b.XMLName.Local) == edge {
+				return l.border(b)
+			}
+		}
+	}
+	if outer {
+		return l.border(table[edge])
+	}
+	if edge == "top" || edge == "bottom" {
+		return l.border(table["insideH"])
+	}
+	return l.border(table["insideV"])
+}
+
+// border returns the width of a border in pixels. Its size is in eighths of
+// a point.
+func (l *layout) border(b *node) uint32 {
+	switch b.attr("val") {
+	case "", "nil", "none":
+		return 0
+	}
+	sz, err := strconv.ParseFloat(b.attr("sz"), 64)
+	if err != nil {
+		sz = 4
+	}
+	// Hairlines are a pixel wide.
+	if px := l.px(sz / 8 * 20); px > 0 {
+		return px
+	}
+	return 1
+}
+
+// shading returns the fill color of a shd element, or nil.
+func shading(shd *node) *ocr.Color {
+	fill := shd.attr("fill")
+	if len(fill) != 6 {
+		return nil
+	}
+	v, err := strconv.ParseUint(fill, 16, 32)
+	if err != nil {
+		return nil
+	}
+	return &ocr.Color{R: uint32(v >> 16 & 0xff), G: uint32(v >> 8 & 0xff), B: uint32(v & 0xff)}
+}
+
+func sum(v []float64) float64 {
+	var s float64
+	for _, x := range v {
+		s += x
+	}
+	return s
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
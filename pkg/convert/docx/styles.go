@@ -0,0 +1,261 @@
+package docx
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Formatting used when neither the document defaults nor the styles give it.
+const (
+	defaultFont = "Times New Roman"
+	defaultSize = 10
+)
+
+// styles holds the styles part and the theme fonts.
+type styles struct {
+	runDefault, paraDefault *node
+	byID                    map[string]*node
+	// defaultPara and defaultTable are the ids of the default paragraph
+	// and table styles.
+	defaultPara, defaultTable string
+	majorFont, minorFont      string
+}
+
+func (s *styles) read(root *node) {
+	if d := root.child("docDefaults"); d != nil {
+		s.runDefault = d.child("rPrDefault").child("rPr")
+		s.paraDefault = d.child("pPrDefault").child("pPr")
+	}
+	for _, st := range root.Nodes {
+		if st.XMLName.Local != "style" {
+			continue
+		}
+		id := st.attr("styleId")
+		s.byID[id] = st
+		if isOn(st.attr("default")) && st.hasAttr("default") {
+			switch st.attr("type") {
+			case "paragraph":
+				s.defaultPara = id
+			case "table":
+				s.defaultTable = id
+			}
+		}
+	}
+}
+
+func (s *styles) readTheme(root *node) {
+	scheme := root.child("themeElements").child("fontScheme")
+	s.majorFont = scheme.child("majorFont").child("latin").attr("typeface")
+	s.minorFont = scheme.child("minorFont").child("latin").attr("typeface")
+}
+
+// chain returns a style and the styles it is based on, the base first.
+func (s *styles) chain(id string) []*node {
+	var chain []*node
+	seen := map[string]bool{}
+	for id != "" && !seen[id] {
+		seen[id] = true
+		st := s.byID[id]
+		if st == nil {
+			break
+		}
+		chain = append([]*node{st}, chain...)
+		id = st.child("basedOn").attr("val")
+	}
+	return chain
+}
+
+// runProps are the properties of a run that matter to its layout and style.
+type runProps struct {
+	font                                          string
+	size                                          float64
+	bold, italic, underline, strike               bool
+	superscript, subscript, smallCaps, caps, hide bool
+}
+
+// runProps resolves the properties of a run of a paragraph with the given
+// style, from the document defaults, the paragraph style, the character
+// style and the direct formatting rPr.
+func (s *styles) runProps(pStyle string, rPr *node) runProps {
+	p := runProps{font: defaultFont, size: defaultSize}
+	s.applyRun(&p, s.runDefault)
+	if pStyle == "" {
+		pStyle = s.defaultPara
+	}
+	for _, st := range s.chain(pStyle) {
+		s.applyRun(&p, st.child("rPr"))
+	}
+	for _, st := range s.chain(rPr.child("rStyle").attr("val")) {
+		s.applyRun(&p, st.child("rPr"))
+	}
+	s.applyRun(&p, rPr)
+	return p
+}
+
+func (s *styles) applyRun(p *runProps, rPr *node) {
+	if rPr == nil {
+		return
+	}
+	for _, c := range rPr.Nodes {
+		val := c.attr("val")
+		switch c.XMLName.Local {
+		case "rFonts":
+			if f := s.font(c); f != "" {
+				p.font = f
+			}
+		case "sz":
+			if v, err := strconv.ParseFloat(val, 64); err == nil && v > 0 {
+				p.size = v / 2
+			}
+		case "b":
+			p.bold = isOn(val)
+		case "i":
+			p.italic = isOn(val)
+		case "u":
+			p.underline = val != "none"
+		case "strike", "dstrike":
+			p.strike = isOn(val)
+		case "smallCaps":
+			p.smallCaps = isOn(val)
+		case "caps":
+			p.caps = isOn(val)
+		case "vanish":
+			p.hide = isOn(val)
+		case "vertAlign":
+			p.superscript, p.subscript = val == "superscript", val == "subscript"
+		}
+	}
+}
+
+// font returns the Latin font of an rFonts element.
+func (s *styles) font(rFonts *node) string {
+	if f := rFonts.attr("ascii"); f != "" {
+		return f
+	}
+	switch rFonts.attr("asciiTheme") {
+	case "majorHAnsi", "majorAscii":
+		return s.majorFont
+	case "minorHAnsi", "minorAscii":
+		return s.minorFont
+	}
+	return rFonts.attr("hAnsi")
+}
+
+// isOn reads the value of a toggle property, which is on when it is absent.
+func isOn(val string) bool {
+	switch val {
+	case "0", "false", "off":
+		return false
+	}
+	return true
+}
+
+// fontStyles returns the font styles of a run.
+func (p *runProps) fontStyles() []ocr.FontStyle_Style {
+	var styles []ocr.FontStyle_Style
+	for _, s := range []struct {
+		on    bool
+		style ocr.FontStyle_Style
+	}{
+		{p.bold, ocr.BOLD},
+		{p.italic, ocr.ITALIC},
+		{p.underline, ocr.UNDERLINE},
+		{p.strike, ocr.STRIKETHROUGH},
+		{p.superscript, ocr.SUPERSCRIPT},
+		{p.subscript, ocr.SUBSCRIPT},
+		{p.smallCaps, ocr.SMALLCAPS},
+	} {
+		if s.on {
+			styles = append(styles, s.style)
+		}
+	}
+	return styles
+}
+
+// Font names containing these are serif or monospace fonts.
+var (
+	serifFonts     = []string{"times", "cambria", "georgia", "garamond", "palatino", "book antiqua", "bookman", "century", "constantia", "minion", "courier", "mincho", "simsun", "batang"}
+	monospaceFonts = []string{"courier", "consolas", "mono", "lucida console"}
+)
+
+func hasAny(fontName string, names []string) bool {
+	fontName = strings.ToLower(fontName)
+	for _, n := range names {
+		if strings.Contains(fontName, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// paraProps are the properties of a paragraph that matter to its layout, in
+// twentieths of a point.
+type paraProps struct {
+	style                  string
+	align                  string
+	left, right, firstLine float64
+	before, after          float64
+	line                   float64
+	lineRule               string
+	pageBreakBefore        bool
+}
+
+// paraProps resolves the properties of a paragraph from the document
+// defaults, its style and its direct formatting pPr.
+func (s *styles) paraProps(pPr *node) paraProps {
+	p := paraProps{style: pPr.child("pStyle").attr("val"), line: 240, lineRule: "auto"}
+	if p.style == "" {
+		p.style = s.defaultPara
+	}
+	s.applyPara(&p, s.paraDefault)
+	for _, st := range s.chain(p.style) {
+		s.applyPara(&p, st.child("pPr"))
+	}
+	s.applyPara(&p, pPr)
+	return p
+}
+
+func (s *styles) applyPara(p *paraProps, pPr *node) {
+	if pPr == nil {
+		return
+	}
+	for _, c := range pPr.Nodes {
+		switch c.XMLName.Local {
+		case "jc":
+			p.align = c.attr("val")
+		case "ind":
+			setTwips(&p.left, c, "left", "start")
+			setTwips(&p.right, c, "right", "end")
+			if setTwips(&p.firstLine, c, "firstLine") {
+				break
+			}
+			var hanging float64
+			if setTwips(&hanging, c, "hanging") {
+				p.firstLine = -hanging
+			}
+		case "spacing":
+			setTwips(&p.before, c, "before")
+			setTwips(&p.after, c, "after")
+			setTwips(&p.line, c, "line")
+			if rule := c.attr("lineRule"); rule != "" {
+				p.lineRule = rule
+			}
+		case "pageBreakBefore":
+			p.pageBreakBefore = isOn(c.attr("val"))
+		}
+	}
+}
+
+// setTwips sets v to the first of the given attributes of n that is a
+// number, and reports whether one was.
+func setTwips(v *float64, n *node, attrs ...string) bool {
+	for _, a := range attrs {
+		if f, err := strconv.ParseFloat(n.attr(a), 64); err == nil {
+			*v = f
+			return true
+		}
+	}
+	return false
+}
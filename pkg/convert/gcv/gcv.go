@@ -0,0 +1,364 @@
+// Package gcv converts the saved output of Google Cloud Vision text detection
+// and Document AI OCR to ocr.Document.
+//
+// Vision's fullTextAnnotation is read from image and file annotation
+// responses, or on its own. See
+// https://cloud.google.com/vision/docs/fulltext-annotations for the format.
+// Document AI documents are read from process responses, or on their own.
+package gcv
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/zuvaai/eocr-utils/internal/builder"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// ErrNoPages means that the input has no pages.
+var ErrNoPages = errors.New("gcv: no pages")
+
+// Break types of Vision symbols and Document AI tokens.
+const (
+	breakSpace        = "SPACE"
+	breakSureSpace    = "SURE_SPACE"
+	breakEOLSureSpace = "EOL_SURE_SPACE"
+	breakHyphen       = "HYPHEN"
+	breakLineBreak    = "LINE_BREAK"
+	breakWideSpace    = "WIDE_SPACE"
+)
+
+// Options configures the conversion of documents.
+type Options struct {
+	// DPI is the resolution given to the pages, which neither format
+	// records. Zero leaves it unknown.
+	DPI uint32
+}
+
+// ReadFile reads a file holding Vision or Document AI output.
+func ReadFile(filename string) (*ocr.Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Read reads Vision or Document AI output from r.
+func Read(r io.Reader) (*ocr.Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal converts Vision or Document AI output to an ocr.Document with the
+// default options.
+func Unmarshal(data []byte) (*ocr.Document, error) {
+	return UnmarshalWithOptions(data, Options{})
+}
+
+// UnmarshalWithOptions converts Vision or Document AI output to an
+// ocr.Document.
+//
+// Each page becomes a page of its width and height. The symbols of a Vision
+// page are visited block by block, paragraph by paragraph and word by word,
+// and the detectedBreak of each symbol gives the spacing: SPACE and
+// SURE_SPACE end a word, EOL_SURE_SPACE and LINE_BREAK end a line, and HYPHEN
+// adds a hyphen and ends a line. Symbols without a break continue the word,
+// even across Vision's words, so punctuation stays attached. The tokens of a
+// Document AI page are visited in order, with the text their anchors
+// select, and end a line when that text holds a line break. Boxes are the
+// bounds of bounding polygons, and confidences become character errors.
+// Document AI tables become tables holding their header and body cells.
+//
+// The md5 of the document is the md5 of data.
+func UnmarshalWithOptions(data []byte, opts Options) (*ocr.Document, error) {
+	var root node
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("gcv: %w", err)
+	}
+	var annotations []*node
+	if err := root.collect(&annotations); err != nil {
+		return nil, err
+	}
+	c := &converter{opts: opts, b: builder.New()}
+	for _, a := range annotations {
+		for i := range a.Pages {
+			c.page([]rune(a.Text), &a.Pages[i])
+		}
+	}
+	if c.b.Pages() == 0 {
+		return nil, ErrNoPages
+	}
+	sum := md5.Sum(data)
+	return c.b.Document(sum[:], ""), nil
+}
+
+// node is any object holding pages: a Vision TextAnnotation or a Document AI
+// Document, or a response holding them.
+type node struct {
+	Text               string `json:"text"`
+	Pages              []page `json:"pages"`
+	FullTextAnnotation *node  `json:"fullTextAnnotation"`
+	Document           *node  `json:"document"`
+	Responses          []node `json:"responses"`
+	Error              *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// collect appends the nodes of n holding pages to annotations, in order.
+func (n *node) collect(annotations *[]*node) error {
+	if n.Error != nil && n.Error.Message != "" {
+		return fmt.Errorf("gcv: response error %d: %s", n.Error.Code, n.Error.Message)
+	}
+	if len(n.Pages) > 0 {
+		*annotations = append(*annotations, n)
+	}
+	for _, child := range []*node{n.FullTextAnnotation, n.Document} {
+		if child != nil {
+			if err := child.collect(annotations); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range n.Responses {
+		if err := n.Responses[i].collect(annotations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// page is a Vision or a Document AI page.
+type page struct {
+	// Vision
+	Width  uint32  `json:"width"`
+	Height uint32  `json:"height"`
+	Blocks []block `json:"blocks"`
+
+	// Document AI
+	Dimension *struct {
+		Width  float64 `json:"width"`
+		Height float64 `json:"height"`
+	} `json:"dimension"`
+	Tokens []token `json:"tokens"`
+	Tables []table `json:"tables"`
+}
+
+type block struct {
+	Paragraphs []struct {
+		Words []struct {
+			Symbols []symbol `json:"symbols"`
+		} `json:"words"`
+	} `json:"paragraphs"`
+}
+
+type symbol struct {
+	Text        string   `json:"text"`
+	Confidence  *float64 `json:"confidence"`
+	BoundingBox poly     `json:"boundingBox"`
+	Property    *struct {
+		DetectedBreak *detectedBreak `json:"detectedBreak"`
+	} `json:"property"`
+}
+
+type detectedBreak struct {
+	Type     string `json:"type"`
+	IsPrefix bool   `json:"isPrefix"`
+}
+
+type token struct {
+	Layout        layout         `json:"layout"`
+	DetectedBreak *detectedBreak `json:"detectedBreak"`
+}
+
+type layout struct {
+	TextAnchor *struct {
+		TextSegments []struct {
+			StartIndex index `json:"startIndex"`
+			EndIndex   index `json:"endIndex"`
+		} `json:"textSegments"`
+	} `json:"textAnchor"`
+	Confidence   *float64 `json:"confidence"`
+	BoundingPoly poly     `json:"boundingPoly"`
+}
+
+type table struct {
+	HeaderRows []row `json:"headerRows"`
+	BodyRows   []row `json:"bodyRows"`
+}
+
+type row struct {
+	Cells []struct {
+		Layout layout `json:"layout"`
+	} `json:"cells"`
+}
+
+type vertex struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// poly is a bounding polygon, in pixels or relative to the page.
+type poly struct {
+	Vertices           []vertex `json:"vertices"`
+	NormalizedVertices []vertex `json:"normalizedVertices"`
+}
+
+// box returns the bounds of p in pixels on a page of the given size, or nil
+// if p has no vertices.
+func (p poly) box(width, height uint32) *ocr.BoundingBox {
+	vs, sx, sy := p.Vertices, 1.0, 1.0
+	if len(vs) == 0 {
+		vs, sx, sy = p.NormalizedVertices, float64(width), float64(height)
+	}
+	if len(vs) == 0 {
+		return nil
+	}
+	x1, y1, x2, y2 := vs[0].X, vs[0].Y, vs[0].X, vs[0].Y
+	for _, v := range vs[1:] {
+		x1, y1 = math.Min(x1, v.X), math.Min(y1, v.Y)
+		x2, y2 = math.Max(x2, v.X), math.Max(y2, v.Y)
+	}
+	return &ocr.BoundingBox{X1: pixels(x1 * sx), Y1: pixels(y1 * sy), X2: pixels(x2 * sx), Y2: pixels(y2 * sy)}
+}
+
+func pixels(v float64) uint32 {
+	if v <= 0 {
+		return 0
+	}
+	return uint32(math.Round(v))
+}
+
+// index is a Document AI text index, an int64 that protobuf JSON writes as
+// a string.
+type index int64
+
+func (i *index) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid text index %s", data)
+	}
+	*i = index(v)
+	return nil
+}
+
+// confidenceError converts a confidence between 0 and 1 to an error. A
+// missing confidence is an error of 0.
+func confidenceError(c *float64) uint32 {
+	if c == nil {
+		return 0
+	}
+	return builder.ErrorFromConfidence(*c * 100)
+}
+
+type converter struct {
+	opts Options
+	b    *builder.Builder
+	// word holds the glyphs of the current word.
+	word []builder.Glyph
+}
+
+func (c *converter) page(text []rune, p *page) {
+	width, height := p.Width, p.Height
+	if p.Dimension != nil {
+		width, height = pixels(p.Dimension.Width), pixels(p.Dimension.Height)
+	}
+	c.b.Page(width, height, c.opts.DPI, c.opts.DPI)
+	for _, bl := range p.Blocks {
+		for _, par := range bl.Paragraphs {
+			for _, w := range par.Words {
+				for _, s := range w.Symbols {
+					var brk *detectedBreak
+					if s.Property != nil {
+						brk = s.Property.DetectedBreak
+					}
+					if brk != nil && brk.IsPrefix {
+						c.applyBreak(brk.Type)
+					}
+					c.word = append(c.word, builder.Split(s.Text, s.BoundingBox.box(width, height), confidenceError(s.Confidence))...)
+					if brk != nil && !brk.IsPrefix {
+						c.applyBreak(brk.Type)
+					}
+				}
+			}
+		}
+		c.endLine()
+	}
+	for _, t := range p.Tokens {
+		c.token(text, t, width, height)
+	}
+	c.endLine()
+	for _, t := range p.Tables {
+		id := c.b.Table(uint32(c.b.Pages() - 1))
+		for _, r := range append(t.HeaderRows, t.BodyRows...) {
+			for _, cell := range r.Cells {
+				c.b.Cell(id, cell.Layout.BoundingPoly.box(width, height))
+			}
+		}
+	}
+}
+
+func (c *converter) token(text []rune, t token, width, height uint32) {
+	var raw strings.Builder
+	if a := t.Layout.TextAnchor; a != nil {
+		for _, s := range a.TextSegments {
+			start, end := int(s.StartIndex), int(s.EndIndex)
+			if start < 0 || end > len(text) || start > end {
+				continue
+			}
+			raw.WriteString(string(text[start:end]))
+		}
+	}
+	s := raw.String()
+	c.word = append(c.word, builder.Split(strings.TrimSpace(s), t.Layout.BoundingPoly.box(width, height), confidenceError(t.Layout.Confidence))...)
+	switch {
+	case strings.ContainsAny(s, "\n\r"):
+		c.endLine()
+	case t.DetectedBreak != nil && t.DetectedBreak.Type == breakHyphen:
+		c.endLine()
+	case t.DetectedBreak != nil, strings.TrimRightFunc(s, unicode.IsSpace) != s:
+		c.endWord()
+	}
+}
+
+// applyBreak applies the detectedBreak of a Vision symbol.
+func (c *converter) applyBreak(typ string) {
+	switch typ {
+	case breakSpace, breakSureSpace, breakWideSpace:
+		c.endWord()
+	case breakEOLSureSpace, breakLineBreak:
+		c.endLine()
+	case breakHyphen:
+		// The hyphen isn't a symbol. It is at the right edge of the word.
+		var box *ocr.BoundingBox
+		if n := len(c.word); n > 0 && c.word[n-1].Box != nil {
+			last := c.word[n-1].Box
+			box = &ocr.BoundingBox{X1: last.X2, Y1: last.Y1, X2: last.X2, Y2: last.Y2}
+		}
+		c.word = append(c.word, builder.Glyph{Rune: '-', Box: box})
+		c.endLine()
+	}
+}
+
+func (c *converter) endWord() {
+	c.b.Word(c.word, builder.Style{})
+	c.word = nil
+}
+
+func (c *converter) endLine() {
+	c.endWord()
+	c.b.EndLine()
+}
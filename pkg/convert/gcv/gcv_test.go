@@ -0,0 +1,114 @@
+package gcv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestReadFile(t *testing.T) {
+	doc, err := ReadFile("../../../testdata/gcv.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello, world agree- ment Fin ", eocr.Text(doc))
+	assert.Equal(t, []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 29}, Width: 1000, Height: 800},
+	}, doc.Pages)
+	assert.Equal(t, &ocr.Character{Unicode: 'H', Error: 1, BoundingBox: &ocr.BoundingBox{X1: 0, Y1: 0, X2: 20, Y2: 30}}, doc.Characters[0])
+	// A symbol without a break joins the next word.
+	assert.Equal(t, &ocr.Character{Unicode: ',', Error: 50, BoundingBox: &ocr.BoundingBox{X1: 100, Y1: 0, X2: 120, Y2: 30}}, doc.Characters[5])
+	assert.Equal(t, &ocr.Character{Unicode: ' ', BoundingBox: &ocr.BoundingBox{X1: 120, Y1: 0, X2: 140, Y2: 30}}, doc.Characters[6])
+	assert.Equal(t, &ocr.Character{Unicode: 'a', Error: 13, BoundingBox: &ocr.BoundingBox{X1: 0, Y1: 80, X2: 20, Y2: 110}}, doc.Characters[13])
+	// HYPHEN adds a hyphen at the right edge of the line.
+	assert.Equal(t, &ocr.Character{Unicode: '-', BoundingBox: &ocr.BoundingBox{X1: 100, Y1: 80, X2: 100, Y2: 110}}, doc.Characters[18])
+	// A symbol without a confidence has no error.
+	assert.Equal(t, &ocr.Character{Unicode: 'm', BoundingBox: &ocr.BoundingBox{X1: 0, Y1: 160, X2: 20, Y2: 190}}, doc.Characters[20])
+
+	assert.Empty(t, eocr.Validate(doc))
+}
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		data           string
+		opts           Options
+		wantText       string
+		wantPages      []*ocr.Page
+		wantBox        *ocr.BoundingBox
+		wantTableCells []*ocr.TableCell
+	}{
+		"text annotation": {
+			data: `{"pages": [{"width": 100, "height": 50, "blocks": [{"paragraphs": [{"words": [
+{"symbols": [{"text": "a", "boundingBox": {"normalizedVertices": [{"x": 0.1, "y": 0.2}, {"x": 0.2, "y": 0.4}]}}]},
+{"symbols": [{"text": "b", "property": {"detectedBreak": {"type": "SPACE", "isPrefix": true}}}]}
+]}]}]}], "text": "a b"}`,
+			opts:      Options{DPI: 72},
+			wantText:  "a b ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 4}, Width: 100, Height: 50, DpiX: 72, DpiY: 72}},
+			wantBox:   &ocr.BoundingBox{X1: 10, Y1: 10, X2: 20, Y2: 20},
+		},
+		"document ai": {
+			data: `{"document": {"text": "Total: $5\nPaid", "pages": [{"pageNumber": 1, "dimension": {"width": 200, "height": 100, "unit": "pixels"},
+"tokens": [
+{"layout": {"textAnchor": {"textSegments": [{"endIndex": "7"}]}, "confidence": 0.9, "boundingPoly": {"normalizedVertices": [{"x": 0.1, "y": 0.1}, {"x": 0.4, "y": 0.2}]}}, "detectedBreak": {"type": "SPACE"}},
+{"layout": {"textAnchor": {"textSegments": [{"startIndex": "7", "endIndex": 10}]}}},
+{"layout": {"textAnchor": {"textSegments": [{"startIndex": "10", "endIndex": "14"}]}}}
+],
+"tables": [{"headerRows": [{"cells": [{"layout": {"boundingPoly": {"vertices": [{"x": 10, "y": 10}, {"x": 100, "y": 20}]}}}]}],
+"bodyRows": [{"cells": [{"layout": {"boundingPoly": {"vertices": [{"x": 10, "y": 20}, {"x": 100, "y": 30}]}}}]}]}]
+}]}}`,
+			wantText:  "Total: $5 Paid ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 15}, Width: 200, Height: 100}},
+			wantBox:   &ocr.BoundingBox{X1: 20, Y1: 10, X2: 30, Y2: 20},
+			wantTableCells: []*ocr.TableCell{
+				{Id: 0, BoundingBox: &ocr.BoundingBox{X1: 10, Y1: 10, X2: 100, Y2: 20}},
+				{Id: 0, BoundingBox: &ocr.BoundingBox{X1: 10, Y1: 20, X2: 100, Y2: 30}},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := UnmarshalWithOptions([]byte(tt.data), tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantText, eocr.Text(doc))
+			assert.Equal(t, tt.wantPages, doc.Pages)
+			assert.Equal(t, tt.wantBox, doc.Characters[0].BoundingBox)
+			assert.Equal(t, tt.wantTableCells, doc.TableCells)
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"no pages": {
+			data:    `{"responses": [{}]}`,
+			wantErr: ErrNoPages.Error(),
+		},
+		"response error": {
+			data:    `{"responses": [{"error": {"code": 3, "message": "Bad image data."}}]}`,
+			wantErr: "gcv: response error 3: Bad image data.",
+		},
+		"invalid index": {
+			data:    `{"text": "a", "pages": [{"tokens": [{"layout": {"textAnchor": {"textSegments": [{"endIndex": "one"}]}}}]}]}`,
+			wantErr: `gcv: invalid text index "one"`,
+		},
+		"malformed": {
+			data:    `{`,
+			wantErr: "gcv: unexpected end of JSON input",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.data))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
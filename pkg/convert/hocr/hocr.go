@@ -0,0 +1,446 @@
+// Package hocr converts between hOCR, the HTML format written by Tesseract
+// and other OCR engines, and ocr.Document.
+//
+// See http://kba.github.io/hocr-spec/1.2/ for the format.
+package hocr
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zuvaai/eocr-utils/internal/builder"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// ErrNoPages means that the input has no ocr_page elements.
+var ErrNoPages = errors.New("hocr: no ocr_page elements")
+
+// lineClasses are the classes of elements holding a line of text.
+var lineClasses = []string{"ocr_line", "ocr_header", "ocr_footer", "ocr_caption", "ocr_textfloat", "ocrx_line"}
+
+// ReadFile reads an hOCR file.
+func ReadFile(filename string) (*ocr.Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Read reads an hOCR document from r.
+func Read(r io.Reader) (*ocr.Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal converts an hOCR document to an ocr.Document.
+//
+// Each ocr_page becomes a page, sized by its bbox, with the resolution from
+// scan_res and ordered by ppageno. The ocrx_word elements of each line become
+// characters separated by spaces. Characters take their boxes from ocrx_cinfo
+// elements when there are any, otherwise the word's box is divided evenly
+// between them. The error of a character is derived from the x_conf of its
+// ocrx_cinfo or the x_wconf of its word. Fonts and sizes come from x_font and
+// x_fsize, and strong and em elements make text bold and italic.
+//
+// The md5 of the document is the md5 of data.
+func Unmarshal(data []byte) (*ocr.Document, error) {
+	pages, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, ErrNoPages
+	}
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].number < pages[j].number })
+
+	b := builder.New()
+	for _, p := range pages {
+		b.Page(p.width, p.height, p.dpiX, p.dpiY)
+		for _, l := range p.lines {
+			for _, w := range l.words {
+				b.Word(w.glyphs, w.style)
+			}
+			b.EndLine()
+		}
+	}
+	sum := md5.Sum(data)
+	return b.Document(sum[:], ""), nil
+}
+
+type page struct {
+	width, height uint32
+	dpiX, dpiY    uint32
+	// number is the ppageno of the page, or the position of the page in the
+	// file if it has none.
+	number int
+	lines  []*line
+}
+
+type line struct {
+	words []*word
+}
+
+type word struct {
+	box    *ocr.BoundingBox
+	err    uint32
+	style  builder.Style
+	text   strings.Builder
+	glyphs []builder.Glyph
+	// cinfo is set once the word has an ocrx_cinfo element.
+	cinfo bool
+}
+
+type cinfo struct {
+	box  *ocr.BoundingBox
+	err  uint32
+	text strings.Builder
+}
+
+// element kinds that matter to the parser.
+const (
+	otherElement = iota
+	pageElement
+	lineElement
+	wordElement
+	cinfoElement
+	boldElement
+	italicElement
+)
+
+// parser holds the state of the elements being parsed.
+type parser struct {
+	// stack holds the open elements, innermost last.
+	stack []openElement
+	pages []*page
+	page  *page
+	line  *line
+	word  *word
+	cinfo *cinfo
+	// bold and italic count the enclosing strong and em elements.
+	bold, italic int
+}
+
+// openElement is an element whose end tag hasn't been read yet.
+type openElement struct {
+	name string
+	kind int
+}
+
+// parse returns the pages of an hOCR document. The document is parsed as
+// loosely as HTML, so unclosed elements, stray end tags and HTML entities are
+// accepted.
+func parse(data []byte) ([]*page, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	p := &parser{}
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("hocr: %w", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			kind, err := p.start(tok)
+			if err != nil {
+				return nil, err
+			}
+			p.stack = append(p.stack, openElement{name: strings.ToLower(tok.Name.Local), kind: kind})
+		case xml.EndElement:
+			// Close the innermost open element of the same name, and the
+			// elements left open inside it. Stray end tags are ignored.
+			name := strings.ToLower(tok.Name.Local)
+			i := len(p.stack) - 1
+			for i >= 0 && p.stack[i].name != name {
+				i--
+			}
+			if i >= 0 {
+				p.closeTo(i)
+			}
+		case xml.CharData:
+			if p.word == nil {
+				continue
+			}
+			if len(bytes.TrimSpace(tok)) > 0 {
+				p.addStyles()
+			}
+			if p.cinfo != nil {
+				p.cinfo.text.Write(tok)
+			} else {
+				p.word.text.Write(tok)
+			}
+		}
+	}
+	return p.pages, nil
+}
+
+func (p *parser) start(el xml.StartElement) (int, error) {
+	switch strings.ToLower(el.Name.Local) {
+	case "strong", "b":
+		p.bold++
+		return boldElement, nil
+	case "em", "i":
+		p.italic++
+		return italicElement, nil
+	}
+	var classes []string
+	var title properties
+	for _, a := range el.Attr {
+		switch strings.ToLower(a.Name.Local) {
+		case "class":
+			classes = strings.Fields(a.Value)
+		case "title":
+			title = parseProperties(a.Value)
+		}
+	}
+	switch {
+	case hasClass(classes, "ocr_page"):
+		p.closeOpen(pageElement)
+		pg := &page{number: len(p.pages)}
+		if box, ok := title.box("bbox"); ok {
+			pg.width, pg.height = box.X2, box.Y2
+		}
+		if res := title.ints("scan_res"); len(res) == 2 {
+			pg.dpiX, pg.dpiY = uint32(res[0]), uint32(res[1])
+		}
+		if n := title.ints("ppageno"); len(n) == 1 {
+			pg.number = n[0]
+		}
+		p.pages = append(p.pages, pg)
+		p.page, p.line = pg, nil
+		return pageElement, nil
+	case hasClass(classes, lineClasses...):
+		if p.page == nil {
+			return 0, fmt.Errorf("hocr: %s outside of an ocr_page", classes[0])
+		}
+		p.closeOpen(lineElement)
+		p.line = &line{}
+		p.page.lines = append(p.page.lines, p.line)
+		return lineElement, nil
+	case hasClass(classes, "ocrx_word", "ocr_word"):
+		if p.page == nil {
+			return 0, fmt.Errorf("hocr: %s outside of an ocr_page", classes[0])
+		}
+		p.closeOpen(wordElement)
+		w := &word{}
+		w.box, _ = title.box("bbox")
+		if conf, ok := title.float("x_wconf"); ok {
+			w.err = builder.ErrorFromConfidence(conf)
+		}
+		if font := title.args("x_font"); len(font) > 0 {
+			w.style.Font = strings.Join(font, " ")
+		}
+		if size, ok := title.float("x_fsize"); ok && size > 0 {
+			w.style.Size = uint32(size + 0.5)
+		}
+		p.word = w
+		return wordElement, nil
+	case hasClass(classes, "ocrx_cinfo") && p.word != nil:
+		p.closeOpen(cinfoElement)
+		c := &cinfo{err: p.word.err}
+		var ok bool
+		if c.box, ok = title.box("x_bboxes"); !ok {
+			c.box, _ = title.box("bbox")
+		}
+		if conf, ok := title.float("x_conf"); ok {
+			c.err = builder.ErrorFromConfidence(conf)
+		}
+		p.cinfo = c
+		return cinfoElement, nil
+	}
+	return otherElement, nil
+}
+
+// closeOpen closes the innermost open element of the given kind, if any, and
+// the elements inside it, since pages, lines, words and cinfo elements don't
+// nest.
+func (p *parser) closeOpen(kind int) {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i].kind == kind {
+			p.closeTo(i)
+			return
+		}
+	}
+}
+
+// closeTo closes the open elements from the innermost to the one at index i
+// of the stack.
+func (p *parser) closeTo(i int) {
+	for j := len(p.stack) - 1; j >= i; j-- {
+		p.end(p.stack[j].kind)
+	}
+	p.stack = p.stack[:i]
+}
+
+func (p *parser) end(kind int) {
+	switch kind {
+	case boldElement:
+		p.bold--
+	case italicElement:
+		p.italic--
+	case pageElement:
+		p.page, p.line = nil, nil
+	case lineElement:
+		p.line = nil
+	case cinfoElement:
+		c := p.cinfo
+		p.cinfo = nil
+		if c == nil || p.word == nil {
+			return
+		}
+		p.word.cinfo = true
+		p.word.glyphs = append(p.word.glyphs, builder.Split(strings.TrimSpace(c.text.String()), c.box, c.err)...)
+	case wordElement:
+		w := p.word
+		p.word = nil
+		if w == nil {
+			return
+		}
+		if !w.cinfo {
+			text := strings.Join(strings.Fields(w.text.String()), "")
+			w.glyphs = builder.Split(text, w.box, w.err)
+		}
+		if len(w.glyphs) == 0 || p.page == nil {
+			return
+		}
+		if p.line == nil {
+			// A word outside of a line is a line of its own.
+			p.page.lines = append(p.page.lines, &line{words: []*word{w}})
+			return
+		}
+		p.line.words = append(p.line.words, w)
+	}
+}
+
+// addStyles adds the styles of the enclosing strong and em elements to the
+// current word.
+func (p *parser) addStyles() {
+	if p.bold > 0 && !hasStyle(p.word.style.Styles, ocr.BOLD) {
+		p.word.style.Styles = append(p.word.style.Styles, ocr.BOLD)
+	}
+	if p.italic > 0 && !hasStyle(p.word.style.Styles, ocr.ITALIC) {
+		p.word.style.Styles = append(p.word.style.Styles, ocr.ITALIC)
+	}
+}
+
+func hasStyle(styles []ocr.FontStyle_Style, s ocr.FontStyle_Style) bool {
+	for _, e := range styles {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasClass(classes []string, want ...string) bool {
+	for _, c := range classes {
+		for _, w := range want {
+			if c == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// properties are the properties of the title attribute of an hOCR element,
+// e.g. "bbox 0 0 100 20; x_wconf 95", by name.
+type properties map[string][]string
+
+func parseProperties(title string) properties {
+	props := properties{}
+	for _, prop := range strings.Split(title, ";") {
+		fields := splitArgs(prop)
+		if len(fields) > 0 {
+			props[fields[0]] = fields[1:]
+		}
+	}
+	return props
+}
+
+// splitArgs splits a property into its name and arguments. Quoted arguments,
+// like font names, may contain spaces.
+func splitArgs(s string) []string {
+	var args []string
+	for {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return args
+		}
+		if s[0] == '"' {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return append(args, s[1:])
+			}
+			args = append(args, s[1:end+1])
+			s = s[end+2:]
+			continue
+		}
+		end := strings.IndexAny(s, " \t\r\n")
+		if end < 0 {
+			return append(args, s)
+		}
+		args = append(args, s[:end])
+		s = s[end:]
+	}
+}
+
+func (p properties) args(name string) []string {
+	return p[name]
+}
+
+// ints returns the arguments of a property as integers, or nil if any of
+// them isn't one.
+func (p properties) ints(name string) []int {
+	args := p[name]
+	ints := make([]int, len(args))
+	for i, a := range args {
+		v, err := strconv.Atoi(a)
+		if err != nil {
+			return nil
+		}
+		ints[i] = v
+	}
+	return ints
+}
+
+func (p properties) float(name string) (float64, bool) {
+	args := p[name]
+	if len(args) != 1 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(args[0], 64)
+	return v, err == nil
+}
+
+// box returns a property holding the x1, y1, x2 and y2 coordinates of a box.
+// Negative coordinates are clamped to 0.
+func (p properties) box(name string) (*ocr.BoundingBox, bool) {
+	c := p.ints(name)
+	if len(c) < 4 {
+		return nil, false
+	}
+	for i := range c {
+		if c[i] < 0 {
+			c[i] = 0
+		}
+	}
+	return &ocr.BoundingBox{X1: uint32(c[0]), Y1: uint32(c[1]), X2: uint32(c[2]), Y2: uint32(c[3])}, true
+}
@@ -0,0 +1,101 @@
+package hocr
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestReadFile(t *testing.T) {
+	doc, err := ReadFile("../../../testdata/tesseract.hocr")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Supply Agreement A& B ", eocr.Text(doc))
+	assert.Equal(t, []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 22}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+	}, doc.Pages)
+	assert.Equal(t, &ocr.Character{Unicode: 'S', Error: 4, BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 200, X2: 333, Y2: 250}}, doc.Characters[0])
+	assert.Equal(t, &ocr.Character{Unicode: ' ', BoundingBox: &ocr.BoundingBox{X1: 500, Y1: 200, X2: 530, Y2: 250}}, doc.Characters[6])
+	assert.Equal(t, &ocr.Character{Unicode: 'A', Error: 1, BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 270, X2: 340, Y2: 320}}, doc.Characters[17])
+	assert.Equal(t, &ocr.Character{Unicode: '&', Error: 60, BoundingBox: &ocr.BoundingBox{X1: 345, Y1: 280, X2: 400, Y2: 320}}, doc.Characters[18])
+	assert.Equal(t, &ocr.Character{Unicode: ' ', BoundingBox: &ocr.BoundingBox{X1: 460, Y1: 270, X2: 460, Y2: 320}}, doc.Characters[21])
+	assert.Equal(t, []*ocr.FontStyle{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 7}, Style: ocr.BOLD},
+		{CharacterSpan: &ocr.Span{Start: 20, End: 22}, Style: ocr.ITALIC},
+	}, doc.FontStyles)
+	assert.Equal(t, []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 17, End: 20}, Name: "Times New Roman"}}, doc.Fonts)
+	assert.Equal(t, []*ocr.FontSize{{CharacterSpan: &ocr.Span{Start: 17, End: 20}, Size_: 12}}, doc.FontSizes)
+	assert.Len(t, doc.Md5, 16)
+
+	assert.Empty(t, eocr.Validate(doc))
+	data, err := eocr.Marshal(doc)
+	require.NoError(t, err)
+	require.NoError(t, eocr.Verify(data))
+}
+
+func TestUnmarshalPageOrder(t *testing.T) {
+	doc, err := Unmarshal([]byte(`<html><body>
+<div class="ocr_page" title="bbox 0 0 100 100; ppageno 1"><span class="ocrx_word" title="bbox 0 0 10 10">two</span></div>
+<div class="ocr_page" title="bbox 0 0 100 100; ppageno 0"><span class="ocrx_word" title="bbox 0 0 10 10">one</span></div>
+</body></html>`))
+	require.NoError(t, err)
+	assert.Equal(t, "one two ", eocr.Text(doc))
+	require.Len(t, doc.Pages, 2)
+	assert.Equal(t, &ocr.Span{Start: 4, End: 8}, doc.Pages[1].CharacterSpan)
+}
+
+func TestUnmarshalMalformed(t *testing.T) {
+	data, err := os.ReadFile("../../../testdata/tesseract.hocr")
+	require.NoError(t, err)
+
+	// A broken end tag is text, and the next cinfo element closes the one
+	// left open.
+	broken := strings.Replace(string(data), "A</span>", "A/span>", 1)
+	doc, err := Unmarshal([]byte(broken))
+	require.NoError(t, err)
+	assert.Equal(t, "Supply Agreement A/span>& B ", eocr.Text(doc))
+
+	// No truncation or deleted byte makes the reader panic.
+	for i := range data {
+		_, _ = Unmarshal(data[:i])
+		_, _ = Unmarshal(append(append([]byte(nil), data[:i]...), data[i+1:]...))
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"no pages": {
+			data:    `<html><body><p>text</p></body></html>`,
+			wantErr: ErrNoPages.Error(),
+		},
+		"word outside page": {
+			data:    `<html><body><span class="ocrx_word" title="bbox 0 0 1 1">a</span></body></html>`,
+			wantErr: "hocr: ocrx_word outside of an ocr_page",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.data))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestParseProperties(t *testing.T) {
+	props := parseProperties(`bbox 1 2 3 4; x_font "Times New Roman"; x_wconf 95.5;`)
+	assert.Equal(t, properties{
+		"bbox":    {"1", "2", "3", "4"},
+		"x_font":  {"Times New Roman"},
+		"x_wconf": {"95.5"},
+	}, props)
+}
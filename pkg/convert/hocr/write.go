@@ -0,0 +1,140 @@
+package hocr
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zuvaai/eocr-utils/internal/layout"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+const header = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN"
+    "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en" lang="en">
+ <head>
+  <title></title>
+  <meta http-equiv="Content-Type" content="text/html;charset=utf-8"/>
+  <meta name="ocr-system" content="eocr-utils"/>
+  <meta name="ocr-capabilities" content="ocr_page ocr_line ocrx_word ocrx_cinfo ocrp_wconf ocrp_font ocr_table"/>
+ </head>
+ <body>
+`
+
+const footer = ` </body>
+</html>
+`
+
+// Marshal returns the hOCR encoding of doc.
+func Marshal(doc *ocr.Document) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Write(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write writes doc to w as an hOCR document, for viewing in hOCR tools.
+//
+// Each page becomes an ocr_page. Characters separated by whitespace form
+// ocrx_word elements, and words are grouped into ocr_line elements by their
+// baseline. Every character is written as an ocrx_cinfo with its box and
+// confidence, so Unmarshal restores the characters. Fonts and font sizes
+// become x_font and x_fsize, bold and italic text is wrapped in strong and em,
+// and tables become ocr_table elements holding the boxes of their cells.
+func Write(w io.Writer, doc *ocr.Document) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(header)
+	attrs := layout.NewAttrs(doc)
+	for i, p := range doc.Pages {
+		lines, err := layout.PageLines(doc, i)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "  <div class=\"ocr_page\" id=\"page_%d\" title=\"bbox 0 0 %d %d; ppageno %d; scan_res %d %d\">\n",
+			i+1, p.Width, p.Height, i, p.DpiX, p.DpiY)
+		for l, line := range lines {
+			fmt.Fprintf(bw, "   <span class=\"ocr_line\" id=\"line_%d_%d\" title=\"bbox %s\">\n", i+1, l+1, boxString(line.Box))
+			for _, wd := range line.Words {
+				writeWord(bw, attrs, wd)
+			}
+			bw.WriteString("   </span>\n")
+		}
+		writeTables(bw, doc, uint32(i))
+		bw.WriteString("  </div>\n")
+	}
+	bw.WriteString(footer)
+	return bw.Flush()
+}
+
+func boxString(b *ocr.BoundingBox) string {
+	if b == nil {
+		return "0 0 0 0"
+	}
+	return fmt.Sprintf("%d %d %d %d", b.X1, b.Y1, b.X2, b.Y2)
+}
+
+// writeWord writes an ocrx_word. The font, size and styles of a word are those
+// of its first character.
+func writeWord(w *bufio.Writer, attrs *layout.Attrs, wd *layout.Word) {
+	title := fmt.Sprintf("bbox %s; x_wconf %d", boxString(wd.Box), confidence(wd.Error()))
+	first := wd.Glyphs[0].Index
+	if f := attrs.Font(first); f != nil && f.Name != "" {
+		title += fmt.Sprintf("; x_font %q", strings.ReplaceAll(f.Name, `"`, ""))
+	}
+	if size := attrs.Size(first); size > 0 {
+		title += fmt.Sprintf("; x_fsize %d", size)
+	}
+	fmt.Fprintf(w, "    <span class=\"ocrx_word\" title=\"%s\">", layout.EscapeXML(title))
+	bold, italic := attrs.Has(first, ocr.BOLD), attrs.Has(first, ocr.ITALIC)
+	if bold {
+		w.WriteString("<strong>")
+	}
+	if italic {
+		w.WriteString("<em>")
+	}
+	for _, g := range wd.Glyphs {
+		fmt.Fprintf(w, "<span class=\"ocrx_cinfo\" title=\"x_bboxes %s; x_conf %d\">%s</span>",
+			boxString(g.Box()), confidence(g.Error()), layout.EscapeXML(string(g.Rune)))
+	}
+	if italic {
+		w.WriteString("</em>")
+	}
+	if bold {
+		w.WriteString("</strong>")
+	}
+	w.WriteString("</span>\n")
+}
+
+// confidence converts a character error to a confidence between 0 and 100.
+func confidence(err uint32) uint32 {
+	if err > 100 {
+		return 0
+	}
+	return 100 - err
+}
+
+// writeTables writes the tables on the page with the given index.
+func writeTables(w *bufio.Writer, doc *ocr.Document, page uint32) {
+	for _, t := range doc.Tables {
+		if t == nil || t.PageNumber != page {
+			continue
+		}
+		var box *ocr.BoundingBox
+		var cells []*ocr.TableCell
+		for _, c := range doc.TableCells {
+			if c != nil && c.Id == t.Id {
+				cells = append(cells, c)
+				box = layout.Union(box, c.BoundingBox)
+			}
+		}
+		fmt.Fprintf(w, "   <div class=\"ocr_table\" id=\"table_%d\" title=\"bbox %s\">\n", t.Id, boxString(box))
+		for _, c := range cells {
+			fmt.Fprintf(w, "    <div class=\"ocrx_cell\" title=\"bbox %s\"></div>\n", boxString(c.BoundingBox))
+		}
+		w.WriteString("   </div>\n")
+	}
+}
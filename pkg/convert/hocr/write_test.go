@@ -0,0 +1,91 @@
+package hocr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	doc, err := ReadFile("../../../testdata/tesseract.hocr")
+	require.NoError(t, err)
+
+	data, err := Marshal(doc)
+	require.NoError(t, err)
+	got, err := Unmarshal(data)
+	require.NoError(t, err)
+
+	got.Md5 = doc.Md5
+	assert.Equal(t, doc, got)
+}
+
+func TestMarshal(t *testing.T) {
+	doc, err := eocr.NewDocumentFromText("foo <bar>\nbaz")
+	require.NoError(t, err)
+	doc.FontStyles = []*ocr.FontStyle{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Style: ocr.BOLD}}
+	doc.Fonts = []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 4, End: 9}, Name: "Courier"}}
+	doc.Tables = []*ocr.Table{{Id: 7}}
+	doc.TableCells = []*ocr.TableCell{
+		{Id: 7, BoundingBox: &ocr.BoundingBox{X1: 0, Y1: 0, X2: 20, Y2: 10}},
+		{Id: 7, BoundingBox: &ocr.BoundingBox{X1: 20, Y1: 0, X2: 40, Y2: 10}},
+	}
+
+	data, err := Marshal(doc)
+	require.NoError(t, err)
+	out := string(data)
+	assert.Contains(t, out, `<div class="ocr_page" id="page_1" title="bbox 0 0 800 2000; ppageno 0; scan_res 300 300">`)
+	assert.Contains(t, out, `<span class="ocrx_word" title="bbox 0 0 30 10; x_wconf 100"><strong><span class="ocrx_cinfo" title="x_bboxes 0 0 10 10; x_conf 100">f</span>`)
+	assert.Contains(t, out, `x_font &#34;Courier&#34;`)
+	assert.Contains(t, out, `>&lt;</span>`)
+	assert.Equal(t, 2, strings.Count(out, `class="ocr_line"`))
+	assert.Contains(t, out, `<div class="ocr_table" id="table_7" title="bbox 0 0 40 10">`)
+	assert.Equal(t, 2, strings.Count(out, `class="ocrx_cell"`))
+
+	got, err := Unmarshal(data)
+	require.NoError(t, err)
+	assert.Equal(t, "foo <bar> baz ", eocr.Text(got))
+}
+
+func TestMarshalInvalidXMLCharacters(t *testing.T) {
+	doc, err := eocr.NewDocumentFromText("a\x01b \ufffec")
+	require.NoError(t, err)
+	doc.Fonts = []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Name: "Bad\x02Font"}}
+
+	data, err := Marshal(doc)
+	require.NoError(t, err)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	// The characters are replaced, so every character keeps its box.
+	got, err := Unmarshal(data)
+	require.NoError(t, err)
+	assert.Equal(t, "a\ufffdb \ufffdc ", eocr.Text(got))
+}
+
+func TestMarshalLegacyFile(t *testing.T) {
+	doc, err := eocr.ReadFile("../../../testdata/jbs.kiraocr")
+	require.NoError(t, err)
+
+	data, err := Marshal(doc)
+	require.NoError(t, err)
+	got, err := Unmarshal(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, strings.Fields(eocr.Text(doc)), strings.Fields(eocr.Text(got)))
+	assert.Len(t, got.Pages, len(doc.Pages))
+	assert.Empty(t, eocr.Validate(got))
+}
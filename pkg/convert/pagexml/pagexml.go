@@ -0,0 +1,449 @@
+// Package pagexml converts PAGE XML, the format of the PRImA Research Lab used
+// for historical documents, to ocr.Document.
+//
+// The 2010 to 2019 versions of the format are read. See
+// https://github.com/PRImA-Research-Lab/PAGE-XML for the format.
+package pagexml
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/zuvaai/eocr-utils/internal/builder"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// ErrNoPages means that the input has no Page elements.
+var ErrNoPages = errors.New("pagexml: no Page elements")
+
+// Unsupported is an element that has no equivalent in an ocr.Document and was
+// skipped, such as an ImageRegion.
+type Unsupported struct {
+	// Element is the name of the element.
+	Element string
+	// ID is the id of the element.
+	ID string
+	// Page is the index of the page of the element.
+	Page int
+}
+
+func (u Unsupported) String() string {
+	return fmt.Sprintf("page %d: %s %q", u.Page, u.Element, u.ID)
+}
+
+// ReadFile reads a PAGE XML file.
+func ReadFile(filename string) (*ocr.Document, []Unsupported, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Read reads a PAGE XML document from r.
+func Read(r io.Reader) (*ocr.Document, []Unsupported, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal converts a PAGE XML document to an ocr.Document, and returns the
+// elements it had to skip.
+//
+// Each Page becomes a page of the size and resolution of its image. Regions
+// are visited in the reading order, followed by the regions it doesn't list in
+// document order. The Word elements of each TextLine become words separated by
+// spaces. Text is taken from the TextEquiv of the Glyph elements of a word if
+// it has any, then from the word itself, and from the line when it has no
+// words. Boxes are the bounds of the Coords polygons, divided evenly between
+// the characters of a word or line without glyphs. The conf of a TextEquiv
+// becomes the error of its characters, and TextStyle elements give fonts,
+// font sizes and font styles. A TableRegion becomes a table whose cells are
+// the TextRegion elements it contains. Other regions have no equivalent and
+// are returned as unsupported.
+//
+// The md5 of the document is the md5 of data.
+func Unmarshal(data []byte) (*ocr.Document, []Unsupported, error) {
+	var root pcGts
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+		return nil, nil, fmt.Errorf("pagexml: %w", err)
+	}
+	if len(root.Pages) == 0 {
+		return nil, nil, ErrNoPages
+	}
+	c := &converter{b: builder.New()}
+	for i := range root.Pages {
+		c.page(i, &root.Pages[i])
+	}
+	sum := md5.Sum(data)
+	return c.b.Document(sum[:], ""), c.unsupported, nil
+}
+
+type pcGts struct {
+	Pages []page `xml:"Page"`
+}
+
+type page struct {
+	ImageWidth     uint32        `xml:"imageWidth,attr"`
+	ImageHeight    uint32        `xml:"imageHeight,attr"`
+	XResolution    float64       `xml:"imageXResolution,attr"`
+	YResolution    float64       `xml:"imageYResolution,attr"`
+	ResolutionUnit string        `xml:"imageResolutionUnit,attr"`
+	ReadingOrder   *readingOrder `xml:"ReadingOrder"`
+	TextStyle      *textStyle    `xml:"TextStyle"`
+	// Regions holds every other child element. Only the regions among them
+	// are used, the rest describe the image rather than its content.
+	Regions []region `xml:",any"`
+}
+
+type readingOrder struct {
+	Groups []group `xml:",any"`
+}
+
+// group is an OrderedGroup, UnorderedGroup, or one of their members.
+type group struct {
+	XMLName   xml.Name
+	RegionRef string  `xml:"regionRef,attr"`
+	Index     int     `xml:"index,attr"`
+	Members   []group `xml:",any"`
+}
+
+// region is a region element, or a TableCell as written by Transkribus.
+type region struct {
+	XMLName   xml.Name
+	ID        string     `xml:"id,attr"`
+	Coords    coords     `xml:"Coords"`
+	TextStyle *textStyle `xml:"TextStyle"`
+	Lines     []textLine `xml:"TextLine"`
+	Regions   []region   `xml:",any"`
+}
+
+type textLine struct {
+	Coords     coords      `xml:"Coords"`
+	TextStyle  *textStyle  `xml:"TextStyle"`
+	TextEquivs []textEquiv `xml:"TextEquiv"`
+	Words      []word      `xml:"Word"`
+}
+
+type word struct {
+	Coords     coords      `xml:"Coords"`
+	TextStyle  *textStyle  `xml:"TextStyle"`
+	TextEquivs []textEquiv `xml:"TextEquiv"`
+	Glyphs     []glyph     `xml:"Glyph"`
+}
+
+type glyph struct {
+	Coords     coords      `xml:"Coords"`
+	TextStyle  *textStyle  `xml:"TextStyle"`
+	TextEquivs []textEquiv `xml:"TextEquiv"`
+}
+
+type textEquiv struct {
+	Index   *int   `xml:"index,attr"`
+	Conf    string `xml:"conf,attr"`
+	Unicode string `xml:"Unicode"`
+}
+
+type textStyle struct {
+	FontFamily    string  `xml:"fontFamily,attr"`
+	FontSize      float64 `xml:"fontSize,attr"`
+	Serif         bool    `xml:"serif,attr"`
+	Monospace     bool    `xml:"monospace,attr"`
+	Bold          bool    `xml:"bold,attr"`
+	Italic        bool    `xml:"italic,attr"`
+	Underlined    bool    `xml:"underlined,attr"`
+	Subscript     bool    `xml:"subscript,attr"`
+	Superscript   bool    `xml:"superscript,attr"`
+	Strikethrough bool    `xml:"strikethrough,attr"`
+	SmallCaps     bool    `xml:"smallCaps,attr"`
+}
+
+// coords is a polygon, either as a points attribute, as in "1,2 3,4", or as
+// Point elements in the 2010 version of the format.
+type coords struct {
+	Points     string `xml:"points,attr"`
+	PointElems []struct {
+		X int `xml:"x,attr"`
+		Y int `xml:"y,attr"`
+	} `xml:"Point"`
+}
+
+// box returns the bounds of the polygon, or nil if it has no points.
+func (c coords) box() *ocr.BoundingBox {
+	var xs, ys []int
+	for _, p := range strings.Fields(c.Points) {
+		x, y, ok := strings.Cut(p, ",")
+		if !ok {
+			continue
+		}
+		xv, errX := strconv.ParseFloat(x, 64)
+		yv, errY := strconv.ParseFloat(y, 64)
+		if errX != nil || errY != nil {
+			continue
+		}
+		xs, ys = append(xs, int(math.Round(xv))), append(ys, int(math.Round(yv)))
+	}
+	for _, p := range c.PointElems {
+		xs, ys = append(xs, p.X), append(ys, p.Y)
+	}
+	if len(xs) == 0 {
+		return nil
+	}
+	minX, maxX := bounds(xs)
+	minY, maxY := bounds(ys)
+	return &ocr.BoundingBox{X1: minX, Y1: minY, X2: maxX, Y2: maxY}
+}
+
+// bounds returns the smallest and largest of vs, clamped to 0.
+func bounds(vs []int) (uint32, uint32) {
+	lo, hi := vs[0], vs[0]
+	for _, v := range vs[1:] {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	if lo < 0 {
+		lo = 0
+	}
+	if hi < 0 {
+		hi = 0
+	}
+	return uint32(lo), uint32(hi)
+}
+
+// text returns the first alternative of a TextEquiv and the error of its
+// confidence.
+func text(equivs []textEquiv) (string, uint32, bool) {
+	if len(equivs) == 0 {
+		return "", 0, false
+	}
+	best := equivs[0]
+	for _, e := range equivs[1:] {
+		if e.Index != nil && (best.Index == nil || *e.Index < *best.Index) {
+			best = e
+		}
+	}
+	var err uint32
+	if conf, perr := strconv.ParseFloat(best.Conf, 64); perr == nil {
+		err = builder.ErrorFromConfidence(conf * 100)
+	}
+	return best.Unicode, err, true
+}
+
+// style returns the style of the nearest of the given text styles.
+func style(styles ...*textStyle) builder.Style {
+	for _, s := range styles {
+		if s == nil {
+			continue
+		}
+		st := builder.Style{Font: s.FontFamily, Serif: s.Serif, Monospace: s.Monospace}
+		if s.FontSize > 0 {
+			st.Size = uint32(math.Round(s.FontSize))
+		}
+		for _, f := range []struct {
+			set   bool
+			style ocr.FontStyle_Style
+		}{
+			{s.Bold, ocr.BOLD},
+			{s.Italic, ocr.ITALIC},
+			{s.Underlined, ocr.UNDERLINE},
+			{s.Strikethrough, ocr.STRIKETHROUGH},
+			{s.Superscript, ocr.SUPERSCRIPT},
+			{s.Subscript, ocr.SUBSCRIPT},
+			{s.SmallCaps, ocr.SMALLCAPS},
+		} {
+			if f.set {
+				st.Styles = append(st.Styles, f.style)
+			}
+		}
+		return st
+	}
+	return builder.Style{}
+}
+
+// converter adds the pages of a PAGE XML document to a builder.
+type converter struct {
+	b           *builder.Builder
+	unsupported []Unsupported
+
+	// The state of the current page.
+	index   int
+	current *page
+	parents map[*region]*region
+	byID    map[string]*region
+	done    map[*region]bool
+	tables  map[*region]uint32
+}
+
+func (c *converter) page(index int, p *page) {
+	dpiX, dpiY := resolution(p.XResolution, p.ResolutionUnit), resolution(p.YResolution, p.ResolutionUnit)
+	c.b.Page(p.ImageWidth, p.ImageHeight, dpiX, dpiY)
+	c.index, c.current = index, p
+	c.parents = map[*region]*region{}
+	c.byID = map[string]*region{}
+	c.done = map[*region]bool{}
+	c.tables = map[*region]uint32{}
+	for i := range p.Regions {
+		c.indexRegion(&p.Regions[i], nil)
+	}
+
+	if p.ReadingOrder != nil {
+		var refs []string
+		for _, g := range p.ReadingOrder.Groups {
+			refs = flatten(refs, g)
+		}
+		for _, ref := range refs {
+			if r := c.byID[ref]; r != nil {
+				c.region(r)
+			}
+		}
+	}
+	for i := range p.Regions {
+		c.region(&p.Regions[i])
+	}
+}
+
+// resolution converts a resolution to pixels per inch. The unit may be PPI,
+// the default, or PPCM.
+func resolution(v float64, unit string) uint32 {
+	if unit == "PPCM" {
+		v *= 2.54
+	}
+	if v <= 0 {
+		return 0
+	}
+	return uint32(math.Round(v))
+}
+
+func (c *converter) indexRegion(r *region, parent *region) {
+	c.parents[r] = parent
+	if r.ID != "" {
+		c.byID[r.ID] = r
+	}
+	for i := range r.Regions {
+		c.indexRegion(&r.Regions[i], r)
+	}
+}
+
+// flatten appends the region references of g to refs in reading order. The
+// members of ordered groups are sorted by their index.
+func flatten(refs []string, g group) []string {
+	if g.RegionRef != "" {
+		refs = append(refs, g.RegionRef)
+	}
+	members := g.Members
+	if strings.HasPrefix(g.XMLName.Local, "OrderedGroup") {
+		members = append([]group(nil), members...)
+		sort.SliceStable(members, func(i, j int) bool { return members[i].Index < members[j].Index })
+	}
+	for _, m := range members {
+		refs = flatten(refs, m)
+	}
+	return refs
+}
+
+// region adds the text of a region and of the regions it contains, unless
+// it was already added.
+func (c *converter) region(r *region) {
+	if c.done[r] {
+		return
+	}
+	c.done[r] = true
+	switch r.XMLName.Local {
+	case "TextRegion", "TableCell":
+		if parent := c.parents[r]; parent != nil && parent.XMLName.Local == "TableRegion" {
+			id, ok := c.tables[parent]
+			if !ok {
+				id = c.b.Table(uint32(c.b.Pages() - 1))
+				c.tables[parent] = id
+			}
+			c.b.Cell(id, r.Coords.box())
+		}
+		for i := range r.Lines {
+			c.line(r, &r.Lines[i])
+		}
+	case "TableRegion":
+		if _, ok := c.tables[r]; !ok {
+			c.tables[r] = c.b.Table(uint32(c.b.Pages() - 1))
+		}
+	default:
+		if strings.HasSuffix(r.XMLName.Local, "Region") {
+			c.unsupported = append(c.unsupported, Unsupported{Element: r.XMLName.Local, ID: r.ID, Page: c.index})
+		}
+		return
+	}
+	for i := range r.Regions {
+		c.region(&r.Regions[i])
+	}
+}
+
+// regionStyle returns the text style of r or of the nearest region containing
+// it.
+func (c *converter) regionStyle(r *region) *textStyle {
+	for ; r != nil; r = c.parents[r] {
+		if r.TextStyle != nil {
+			return r.TextStyle
+		}
+	}
+	return c.current.TextStyle
+}
+
+func (c *converter) line(r *region, l *textLine) {
+	regionStyle := c.regionStyle(r)
+	if len(l.Words) == 0 {
+		if t, err, ok := text(l.TextEquivs); ok {
+			c.lineText(t, l.Coords.box(), err, style(l.TextStyle, regionStyle))
+		}
+		c.b.EndLine()
+		return
+	}
+	for _, w := range l.Words {
+		var glyphs []builder.Glyph
+		for _, g := range w.Glyphs {
+			t, err, ok := text(g.TextEquivs)
+			if !ok {
+				continue
+			}
+			glyphs = append(glyphs, builder.Split(t, g.Coords.box(), err)...)
+		}
+		if len(glyphs) == 0 {
+			t, err, ok := text(w.TextEquivs)
+			if !ok {
+				continue
+			}
+			glyphs = builder.Split(strings.TrimSpace(t), w.Coords.box(), err)
+		}
+		c.b.Word(glyphs, style(w.TextStyle, l.TextStyle, regionStyle))
+	}
+	c.b.EndLine()
+}
+
+// lineText adds the words of the text of a line without Word elements. The
+// box of the line is divided evenly between its runes, spaces included.
+func (c *converter) lineText(t string, box *ocr.BoundingBox, err uint32, s builder.Style) {
+	var word []builder.Glyph
+	for _, g := range builder.Split(t, box, err) {
+		if unicode.IsSpace(g.Rune) {
+			c.b.Word(word, s)
+			word = nil
+			continue
+		}
+		word = append(word, g)
+	}
+	c.b.Word(word, s)
+}
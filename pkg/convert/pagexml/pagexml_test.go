@@ -0,0 +1,131 @@
+package pagexml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestReadFile(t *testing.T) {
+	doc, unsupported, err := ReadFile("../../../testdata/page-2019.xml")
+	require.NoError(t, err)
+
+	assert.Equal(t, "The Agreement Dear Sir Price $10 ", eocr.Text(doc))
+	assert.Equal(t, []Unsupported{
+		{Element: "ImageRegion", ID: "i_1"},
+		{Element: "SeparatorRegion", ID: "s_1"},
+	}, unsupported)
+	assert.Equal(t, []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 33}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+	}, doc.Pages)
+
+	// Glyph polygons become their bounds, and the first TextEquiv by index
+	// wins.
+	assert.Equal(t, &ocr.Character{Unicode: 'T', Error: 1, BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 205, X2: 340, Y2: 300}}, doc.Characters[0])
+	assert.Equal(t, &ocr.Character{Unicode: 'h', Error: 20, BoundingBox: &ocr.BoundingBox{X1: 340, Y1: 200, X2: 380, Y2: 300}}, doc.Characters[1])
+	// Words without glyphs are divided evenly.
+	assert.Equal(t, &ocr.Character{Unicode: 'A', Error: 25, BoundingBox: &ocr.BoundingBox{X1: 460, Y1: 200, X2: 520, Y2: 300}}, doc.Characters[4])
+	// So are lines without words, spaces included.
+	assert.Equal(t, &ocr.Character{Unicode: 'D', Error: 10, BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 500, X2: 412, Y2: 560}}, doc.Characters[14])
+
+	assert.Equal(t, []*ocr.Font{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 14}, Name: "Times New Roman", Serif: true},
+	}, doc.Fonts)
+	assert.Equal(t, []*ocr.FontSize{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 14}, Size_: 18},
+	}, doc.FontSizes)
+	assert.Equal(t, []*ocr.FontStyle{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 14}, Style: ocr.BOLD},
+		{CharacterSpan: &ocr.Span{Start: 4, End: 14}, Style: ocr.ITALIC},
+	}, doc.FontStyles)
+
+	assert.Equal(t, []*ocr.Table{{Id: 0, PageNumber: 0}}, doc.Tables)
+	assert.Equal(t, []*ocr.TableCell{
+		{Id: 0, BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 1000, X2: 800, Y2: 1100}},
+		{Id: 0, BoundingBox: &ocr.BoundingBox{X1: 800, Y1: 1000, X2: 1300, Y2: 1100}},
+	}, doc.TableCells)
+
+	assert.Empty(t, eocr.Validate(doc))
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := map[string]struct {
+		data            string
+		wantText        string
+		wantPages       []*ocr.Page
+		wantUnsupported []Unsupported
+	}{
+		"2010 points": {
+			data: `<PcGts><Page imageWidth="100" imageHeight="100"><TextRegion id="r">
+<TextLine><Coords><Point x="10" y="10"/><Point x="50" y="10"/><Point x="50" y="20"/></Coords>
+<TextEquiv><Unicode>ab</Unicode></TextEquiv></TextLine></TextRegion></Page></PcGts>`,
+			wantText:  "ab ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Width: 100, Height: 100}},
+		},
+		"nested groups": {
+			data: `<PcGts><Page imageWidth="100" imageHeight="100"><ReadingOrder><OrderedGroup>
+<OrderedGroupIndexed index="1"><RegionRefIndexed index="0" regionRef="a"/></OrderedGroupIndexed>
+<UnorderedGroupIndexed index="0"><RegionRef regionRef="c"/><RegionRef regionRef="b"/></UnorderedGroupIndexed>
+</OrderedGroup></ReadingOrder>
+<TextRegion id="a"><TextLine><TextEquiv><Unicode>a</Unicode></TextEquiv></TextLine></TextRegion>
+<TextRegion id="b"><TextLine><TextEquiv><Unicode>b</Unicode></TextEquiv></TextLine></TextRegion>
+<TextRegion id="c"><TextLine><TextEquiv><Unicode>c</Unicode></TextEquiv></TextLine>
+<TextRegion id="d"><TextLine><TextEquiv><Unicode>d</Unicode></TextEquiv></TextLine></TextRegion></TextRegion>
+</Page></PcGts>`,
+			wantText:  "c d b a ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 8}, Width: 100, Height: 100}},
+		},
+		"pages": {
+			data: `<PcGts><Page imageWidth="100" imageHeight="200" imageXResolution="40" imageYResolution="40" imageResolutionUnit="PPCM">
+<MathsRegion id="m"/></Page>
+<Page imageWidth="100" imageHeight="200"><TableRegion id="t"><TableCell><TextLine><TextEquiv><Unicode>x</Unicode></TextEquiv></TextLine></TableCell></TableRegion></Page></PcGts>`,
+			wantText: "x ",
+			wantPages: []*ocr.Page{
+				{CharacterSpan: &ocr.Span{Start: 0, End: 0}, Width: 100, Height: 200, DpiX: 102, DpiY: 102},
+				{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Width: 100, Height: 200},
+			},
+			wantUnsupported: []Unsupported{{Element: "MathsRegion", ID: "m"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, unsupported, err := Unmarshal([]byte(tt.data))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantText, eocr.Text(doc))
+			assert.Equal(t, tt.wantPages, doc.Pages)
+			assert.Equal(t, tt.wantUnsupported, unsupported)
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"no pages": {
+			data:    `<PcGts><Metadata/></PcGts>`,
+			wantErr: ErrNoPages.Error(),
+		},
+		"malformed": {
+			data:    `<PcGts>`,
+			wantErr: "pagexml: XML syntax error on line 1: unexpected EOF",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Unmarshal([]byte(tt.data))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUnsupportedString(t *testing.T) {
+	assert.Equal(t, `page 2: ImageRegion "i_1"`, Unsupported{Element: "ImageRegion", ID: "i_1", Page: 2}.String())
+}
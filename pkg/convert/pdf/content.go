@@ -0,0 +1,264 @@
+package pdf
+
+import (
+	"bytes"
+	"math"
+)
+
+// matrix is a transformation matrix [a b c d e f].
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns m × n, the transformation m followed by n.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m matrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// glyph is a glyph shown on a page, in the default user space of the page.
+type glyph struct {
+	text []rune
+	font *font
+	// x1, y1, x2 and y2 bound the glyph from its descent to its ascent.
+	x1, y1, x2, y2 float64
+	// size is the size of the font as shown, in points.
+	size float64
+}
+
+// gstate is the part of the graphics state that matters to text.
+type gstate struct {
+	ctm matrix
+	// The text state parameters.
+	font                 *font
+	fontSize             float64
+	charSpace, wordSpace float64
+	scale, leading, rise float64
+}
+
+// interpreter runs content streams and collects the glyphs they show.
+type interpreter struct {
+	f *file
+	// fonts caches the fonts read, by reference.
+	fonts  map[ref]*font
+	glyphs []glyph
+	// images counts the images drawn.
+	images int
+}
+
+func newInterpreter(f *file) *interpreter {
+	return &interpreter{f: f, fonts: map[ref]*font{}}
+}
+
+// run interprets a content stream with the given resources and initial
+// transformation. Unknown operators and malformed operands are skipped, as
+// viewers do.
+func (in *interpreter) run(data []byte, resources dict, ctm matrix, depth int) {
+	if depth > maxDepth {
+		return
+	}
+	gs := gstate{ctm: ctm, scale: 1}
+	var stack []gstate
+	var tm, tlm matrix
+	var operands []interface{}
+	l := &lexer{data: data}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return
+		}
+		op, ok := tok.(keyword)
+		if !ok {
+			operands = append(operands, tok)
+			continue
+		}
+		num := func(i int) float64 {
+			if i < len(operands) {
+				v, _ := in.f.number(operands[i])
+				return v
+			}
+			return 0
+		}
+		switch op {
+		case "q":
+			stack = append(stack, gs)
+		case "Q":
+			if n := len(stack); n > 0 {
+				gs, stack = stack[n-1], stack[:n-1]
+			}
+		case "cm":
+			if len(operands) == 6 {
+				gs.ctm = matrix{num(0), num(1), num(2), num(3), num(4), num(5)}.mul(gs.ctm)
+			}
+		case "BT":
+			tm, tlm = identity, identity
+		case "Tf":
+			if len(operands) == 2 {
+				n, _ := operands[0].(name)
+				gs.font = in.font(in.f.dict(resources["Font"])[n])
+				gs.fontSize = num(1)
+			}
+		case "Tc":
+			gs.charSpace = num(0)
+		case "Tw":
+			gs.wordSpace = num(0)
+		case "Tz":
+			gs.scale = num(0) / 100
+		case "TL":
+			gs.leading = num(0)
+		case "Ts":
+			gs.rise = num(0)
+		case "Td", "TD":
+			if op == "TD" {
+				gs.leading = -num(1)
+			}
+			tlm = matrix{1, 0, 0, 1, num(0), num(1)}.mul(tlm)
+			tm = tlm
+		case "Tm":
+			if len(operands) == 6 {
+				tlm = matrix{num(0), num(1), num(2), num(3), num(4), num(5)}
+				tm = tlm
+			}
+		case "T*":
+			tlm = matrix{1, 0, 0, 1, 0, -gs.leading}.mul(tlm)
+			tm = tlm
+		case "Tj", "'", "\"":
+			if op != "Tj" {
+				if op == "\"" && len(operands) == 3 {
+					gs.wordSpace, gs.charSpace = num(0), num(1)
+				}
+				tlm = matrix{1, 0, 0, 1, 0, -gs.leading}.mul(tlm)
+				tm = tlm
+			}
+			if len(operands) > 0 {
+				s, _ := operands[len(operands)-1].(str)
+				tm = in.show(&gs, tm, []byte(s))
+			}
+		case "TJ":
+			if len(operands) == 1 {
+				a, _ := operands[0].(array)
+				for _, obj := range a {
+					if s, ok := obj.(str); ok {
+						tm = in.show(&gs, tm, []byte(s))
+					} else if v, ok := in.f.number(obj); ok {
+						tm = matrix{1, 0, 0, 1, -v / 1000 * gs.fontSize * gs.scale, 0}.mul(tm)
+					}
+				}
+			}
+		case "Do":
+			if len(operands) == 1 {
+				n, _ := operands[0].(name)
+				in.xobject(in.f.dict(resources["XObject"])[n], resources, gs.ctm, depth)
+			}
+		case "BI":
+			in.images++
+			l.pos = inlineImageEnd(data, l.pos)
+		}
+		operands = operands[:0]
+	}
+}
+
+// inlineImageEnd returns the position after the EI ending the inline image
+// starting at pos.
+func inlineImageEnd(data []byte, pos int) int {
+	for {
+		i := bytes.Index(data[pos:], []byte("EI"))
+		if i < 0 {
+			return len(data)
+		}
+		end := pos + i + 2
+		if pos+i > 0 && isSpace(data[pos+i-1]) && (end == len(data) || isSpace(data[end]) || isDelim(data[end])) {
+			return end
+		}
+		pos = end
+	}
+}
+
+// xobject draws an external object: a form is interpreted and an image
+// counted.
+func (in *interpreter) xobject(obj interface{}, resources dict, ctm matrix, depth int) {
+	s, ok := in.f.resolve(obj).(*stream)
+	if !ok {
+		return
+	}
+	switch s.dict["Subtype"] {
+	case name("Image"):
+		in.images++
+	case name("Form"):
+		data, err := in.f.decode(s)
+		if err != nil {
+			return
+		}
+		m := identity
+		if a := in.f.array(s.dict["Matrix"]); len(a) == 6 {
+			for i := range m {
+				m[i], _ = in.f.number(a[i])
+			}
+		}
+		if res := in.f.dict(s.dict["Resources"]); res != nil {
+			resources = res
+		}
+		in.run(data, resources, m.mul(ctm), depth+1)
+	}
+}
+
+func (in *interpreter) font(obj interface{}) *font {
+	r, isRef := obj.(ref)
+	if isRef {
+		if ft, ok := in.fonts[r]; ok {
+			return ft
+		}
+	}
+	d := in.f.dict(obj)
+	if d == nil {
+		return nil
+	}
+	ft := in.f.loadFont(d)
+	if isRef {
+		in.fonts[r] = ft
+	}
+	return ft
+}
+
+// show shows a string with the current font and returns the text matrix
+// after it.
+func (in *interpreter) show(gs *gstate, tm matrix, s []byte) matrix {
+	ft := gs.font
+	if ft == nil {
+		return tm
+	}
+	for len(s) > 0 {
+		code, n := ft.next(s)
+		s = s[n:]
+		text := ft.text(code, n)
+		w := ft.width(code, n, text)
+
+		trm := matrix{gs.fontSize * gs.scale, 0, 0, gs.fontSize, 0, gs.rise}.mul(tm).mul(gs.ctm)
+		g := glyph{text: text, font: ft, x1: math.Inf(1), y1: math.Inf(1), x2: math.Inf(-1), y2: math.Inf(-1)}
+		for _, corner := range [4][2]float64{{0, ft.descent}, {w, ft.descent}, {0, ft.ascent}, {w, ft.ascent}} {
+			x, y := trm.apply(corner[0], corner[1])
+			g.x1, g.y1 = math.Min(g.x1, x), math.Min(g.y1, y)
+			g.x2, g.y2 = math.Max(g.x2, x), math.Max(g.y2, y)
+		}
+		m := tm.mul(gs.ctm)
+		g.size = math.Abs(gs.fontSize) * math.Hypot(m[2], m[3])
+		in.glyphs = append(in.glyphs, g)
+
+		advance := w*gs.fontSize + gs.charSpace
+		if n == 1 && code == ' ' {
+			advance += gs.wordSpace
+		}
+		tm = matrix{1, 0, 0, 1, advance * gs.scale, 0}.mul(tm)
+	}
+	return tm
+}
@@ -0,0 +1,157 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+)
+
+// asciiNames are the glyph names of the characters from space to asciitilde.
+var asciiNames = strings.Fields(`space exclam quotedbl numbersign dollar percent ampersand quotesingle
+	parenleft parenright asterisk plus comma hyphen period slash
+	zero one two three four five six seven eight nine colon semicolon less equal greater question
+	at A B C D E F G H I J K L M N O P Q R S T U V W X Y Z bracketleft backslash bracketright asciicircum underscore
+	grave a b c d e f g h i j k l m n o p q r s t u v w x y z braceleft bar braceright asciitilde`)
+
+// latin1Names are the glyph names of the characters from U+00A1 to U+00FF.
+var latin1Names = strings.Fields(`exclamdown cent sterling currency yen brokenbar section dieresis copyright
+	ordfeminine guillemotleft logicalnot hyphen registered macron degree plusminus twosuperior threesuperior
+	acute mu paragraph periodcentered cedilla onesuperior ordmasculine guillemotright onequarter onehalf
+	threequarters questiondown Agrave Aacute Acircumflex Atilde Adieresis Aring AE Ccedilla Egrave Eacute
+	Ecircumflex Edieresis Igrave Iacute Icircumflex Idieresis Eth Ntilde Ograve Oacute Ocircumflex Otilde
+	Odieresis multiply Oslash Ugrave Uacute Ucircumflex Udieresis Yacute Thorn germandbls agrave aacute
+	acircumflex atilde adieresis aring ae ccedilla egrave eacute ecircumflex edieresis igrave iacute
+	icircumflex idieresis eth ntilde ograve oacute ocircumflex otilde odieresis divide oslash ugrave uacute
+	ucircumflex udieresis yacute thorn ydieresis`)
+
+// otherNames are the glyph names outside ASCII and Latin-1 used by the
+// standard encodings, and a few common ones.
+var otherNames = map[string]rune{
+	"quoteright": '’', "quoteleft": '‘', "quotesinglbase": '‚', "quotedblbase": '„',
+	"quotedblleft": '“', "quotedblright": '”', "guilsinglleft": '‹', "guilsinglright": '›',
+	"endash": '–', "emdash": '—', "bullet": '•', "ellipsis": '…', "dagger": '†', "daggerdbl": '‡',
+	"perthousand": '‰', "trademark": '™', "Euro": '€', "florin": 'ƒ', "circumflex": 'ˆ', "tilde": '˜',
+	"Scaron": 'Š', "scaron": 'š', "Zcaron": 'Ž', "zcaron": 'ž', "OE": 'Œ', "oe": 'œ', "Ydieresis": 'Ÿ',
+	"fi": 'ﬁ', "fl": 'ﬂ', "ff": 'ﬀ', "ffi": 'ﬃ', "ffl": 'ﬄ', "dotlessi": 'ı', "fraction": '⁄',
+	"breve": '˘', "dotaccent": '˙', "ring": '˚', "hungarumlaut": '˝', "ogonek": '˛', "caron": 'ˇ',
+	"Lslash": 'Ł', "lslash": 'ł', "minus": '−', "nbspace": ' ', "sfthyphen": '­',
+	"notequal": '≠', "infinity": '∞', "lessequal": '≤', "greaterequal": '≥', "partialdiff": '∂',
+	"summation": '∑', "product": '∏', "pi": 'π', "integral": '∫', "Omega": 'Ω', "radical": '√',
+	"approxequal": '≈', "Delta": '∆', "lozenge": '◊', "middot": '·', "arrowright": '→',
+	"arrowleft": '←', "checkmark": '✓',
+}
+
+// glyphNames maps glyph names to runes.
+var glyphNames = map[string]rune{}
+
+// The standard encodings of simple fonts.
+var (
+	standardEncoding [256]rune
+	winAnsiEncoding  [256]rune
+	macRomanEncoding [256]rune
+)
+
+// winAnsiCodes maps the characters of WinAnsiEncoding to their codes, to
+// write text.
+var winAnsiCodes = map[rune]byte{}
+
+// standardHigh are the glyph names of StandardEncoding above 0x7f.
+var standardHigh = map[byte]string{
+	0xa1: "exclamdown", 0xa2: "cent", 0xa3: "sterling", 0xa4: "fraction", 0xa5: "yen", 0xa6: "florin",
+	0xa7: "section", 0xa8: "currency", 0xa9: "quotesingle", 0xaa: "quotedblleft", 0xab: "guillemotleft",
+	0xac: "guilsinglleft", 0xad: "guilsinglright", 0xae: "fi", 0xaf: "fl", 0xb1: "endash", 0xb2: "dagger",
+	0xb3: "daggerdbl", 0xb4: "periodcentered", 0xb6: "paragraph", 0xb7: "bullet", 0xb8: "quotesinglbase",
+	0xb9: "quotedblbase", 0xba: "quotedblright", 0xbb: "guillemotright", 0xbc: "ellipsis",
+	0xbd: "perthousand", 0xbf: "questiondown", 0xc1: "grave", 0xc2: "acute", 0xc3: "circumflex",
+	0xc4: "tilde", 0xc5: "macron", 0xc6: "breve", 0xc7: "dotaccent", 0xc8: "dieresis", 0xca: "ring",
+	0xcb: "cedilla", 0xcd: "hungarumlaut", 0xce: "ogonek", 0xcf: "caron", 0xd0: "emdash", 0xe1: "AE",
+	0xe3: "ordfeminine", 0xe8: "Lslash", 0xe9: "Oslash", 0xea: "OE", 0xeb: "ordmasculine", 0xf1: "ae",
+	0xf5: "dotlessi", 0xf8: "lslash", 0xf9: "oslash", 0xfa: "oe", 0xfb: "germandbls",
+}
+
+// winAnsiHigh are the characters of WinAnsiEncoding from 0x80 to 0x9f. The
+// rest of the upper half is Latin-1.
+var winAnsiHigh = []rune("€\x00‚ƒ„…†‡ˆ‰Š‹Œ\x00Ž\x00\x00‘’“”•–—˜™š›œ\x00žŸ")
+
+// macRomanHigh are the characters of MacRomanEncoding from 0x80 to 0xff.
+var macRomanHigh = []rune("ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø" +
+	"¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ")
+
+func init() {
+	for i, n := range asciiNames {
+		glyphNames[n] = rune(0x20 + i)
+	}
+	for i, n := range latin1Names {
+		if n != "hyphen" {
+			glyphNames[n] = rune(0xa1 + i)
+		}
+	}
+	for n, r := range otherNames {
+		glyphNames[n] = r
+	}
+
+	for c := 0x20; c < 0x7f; c++ {
+		standardEncoding[c] = rune(c)
+		winAnsiEncoding[c] = rune(c)
+		macRomanEncoding[c] = rune(c)
+	}
+	standardEncoding['\''] = '’'
+	standardEncoding['`'] = '‘'
+	for c, n := range standardHigh {
+		standardEncoding[c] = glyphNames[n]
+	}
+	for i, r := range winAnsiHigh {
+		winAnsiEncoding[0x80+i] = r
+	}
+	for c := 0xa0; c < 0x100; c++ {
+		winAnsiEncoding[c] = rune(c)
+	}
+	for c, r := range winAnsiEncoding {
+		if r != 0 {
+			winAnsiCodes[r] = byte(c)
+		}
+	}
+	for i, r := range macRomanHigh {
+		macRomanEncoding[0x80+i] = r
+	}
+}
+
+// glyphRunes returns the text of a glyph name, following the conventions of
+// the Adobe Glyph List: a suffix after a period is ignored, underscores join
+// ligatures, and uniXXXX and uXXXX[XX] give code points. It returns nil for
+// unknown names.
+func glyphRunes(glyph string) []rune {
+	if i := strings.IndexByte(glyph, '.'); i > 0 {
+		glyph = glyph[:i]
+	}
+	if strings.Contains(glyph, "_") {
+		var runes []rune
+		for _, part := range strings.Split(glyph, "_") {
+			r := glyphRunes(part)
+			if r == nil {
+				return nil
+			}
+			runes = append(runes, r...)
+		}
+		return runes
+	}
+	if r, ok := glyphNames[glyph]; ok {
+		return []rune{r}
+	}
+	if strings.HasPrefix(glyph, "uni") && len(glyph) >= 7 && (len(glyph)-3)%4 == 0 {
+		var runes []rune
+		for i := 3; i < len(glyph); i += 4 {
+			v, err := strconv.ParseUint(glyph[i:i+4], 16, 16)
+			if err != nil {
+				return nil
+			}
+			runes = append(runes, rune(v))
+		}
+		return runes
+	}
+	if strings.HasPrefix(glyph, "u") && len(glyph) >= 5 && len(glyph) <= 7 {
+		if v, err := strconv.ParseUint(glyph[1:], 16, 32); err == nil && v <= 0x10ffff {
+			return []rune{rune(v)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,894 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// maxDepth bounds the nesting of references, page trees and form XObjects,
+// which malformed files can make cyclic.
+const maxDepth = 32
+
+// ErrEncrypted means that the file is encrypted, which isn't supported.
+var ErrEncrypted = errors.New("pdf: encrypted files are not supported")
+
+// xrefEntry locates an object, either at an offset in the file or at an index
+// in an object stream.
+type xrefEntry struct {
+	offset int64
+	stream int64
+	index  int
+	free   bool
+}
+
+// file is a parsed PDF file. Objects are read when they are first resolved.
+type file struct {
+	data    []byte
+	xref    map[int64]xrefEntry
+	trailer dict
+	objects map[int64]interface{}
+	// objStms holds the objects of the object streams read so far.
+	objStms map[int64][]interface{}
+}
+
+func newFile(data []byte) (*file, error) {
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if !bytes.Contains(head, []byte("%PDF-")) {
+		return nil, errors.New("pdf: missing %PDF header")
+	}
+	f := &file{
+		data:    data,
+		xref:    map[int64]xrefEntry{},
+		objects: map[int64]interface{}{},
+		objStms: map[int64][]interface{}{},
+	}
+	if err := f.readXrefs(); err != nil || f.trailer["Root"] == nil {
+		// Damaged cross-reference data is common, and the objects can
+		// be found without it.
+		if err := f.rebuild(); err != nil {
+			return nil, err
+		}
+	}
+	if f.trailer["Encrypt"] != nil {
+		return nil, ErrEncrypted
+	}
+	return f, nil
+}
+
+// readXrefs reads the cross-reference sections, starting from the last one.
+func (f *file) readXrefs() error {
+	i := bytes.LastIndex(f.data, []byte("startxref"))
+	if i < 0 {
+		return errors.New("missing startxref")
+	}
+	l := &lexer{data: f.data, pos: i + len("startxref")}
+	tok, err := l.next()
+	if err != nil {
+		return err
+	}
+	offset, ok := tok.(int64)
+	seen := map[int64]bool{}
+	for ok && !seen[offset] && len(seen) < maxDepth {
+		seen[offset] = true
+		trailer, err := f.readXref(offset)
+		if err != nil {
+			return err
+		}
+		if f.trailer == nil {
+			f.trailer = trailer
+		}
+		if stm, isInt := trailer["XRefStm"].(int64); isInt {
+			if _, err := f.readXref(stm); err != nil {
+				return err
+			}
+		}
+		offset, ok = trailer["Prev"].(int64)
+	}
+	return nil
+}
+
+// readXref reads the cross-reference table or stream at offset and returns
+// its trailer. Entries of later sections, which are read first, win.
+func (f *file) readXref(offset int64) (dict, error) {
+	if offset < 0 || offset >= int64(len(f.data)) {
+		return nil, fmt.Errorf("xref offset %d out of range", offset)
+	}
+	l := &lexer{data: f.data, pos: int(offset)}
+	tok, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok != keyword("xref") {
+		_, obj, err := f.readObjectAt(offset)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := obj.(*stream)
+		if !ok || s.dict["Type"] != name("XRef") {
+			return nil, fmt.Errorf("no xref at offset %d", offset)
+		}
+		return s.dict, f.readXrefStream(s)
+	}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok == keyword("trailer") {
+			tok, err := l.next()
+			if err != nil {
+				return nil, err
+			}
+			trailer, ok := tok.(dict)
+			if !ok {
+				return nil, errors.New("invalid trailer")
+			}
+			return trailer, nil
+		}
+		start, ok1 := tok.(int64)
+		tok, err = l.next()
+		if err != nil {
+			return nil, err
+		}
+		count, ok2 := tok.(int64)
+		if !ok1 || !ok2 {
+			return nil, errors.New("invalid xref subsection")
+		}
+		for i := int64(0); i < count; i++ {
+			var fields [3]interface{}
+			for j := range fields {
+				if fields[j], err = l.next(); err != nil {
+					return nil, err
+				}
+			}
+			off, _ := fields[0].(int64)
+			if _, ok := f.xref[start+i]; !ok {
+				f.xref[start+i] = xrefEntry{offset: off, free: fields[2] != keyword("n")}
+			}
+		}
+	}
+}
+
+func (f *file) readXrefStream(s *stream) error {
+	data, err := f.decode(s)
+	if err != nil {
+		return err
+	}
+	w, _ := s.dict["W"].(array)
+	if len(w) != 3 {
+		return errors.New("invalid xref stream W")
+	}
+	var widths [3]int
+	for i := range widths {
+		v, _ := w[i].(int64)
+		widths[i] = int(v)
+	}
+	index, _ := s.dict["Index"].(array)
+	if index == nil {
+		size, _ := s.dict["Size"].(int64)
+		index = array{int64(0), size}
+	}
+	rowLen := widths[0] + widths[1] + widths[2]
+	if rowLen == 0 {
+		return errors.New("invalid xref stream W")
+	}
+	row := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, _ := index[i].(int64)
+		count, _ := index[i+1].(int64)
+		for n := int64(0); n < count; n++ {
+			if (row+1)*rowLen > len(data) {
+				return nil
+			}
+			b := data[row*rowLen : (row+1)*rowLen]
+			row++
+			typ := int64(1)
+			if widths[0] > 0 {
+				typ = field(b[:widths[0]])
+			}
+			f2 := field(b[widths[0] : widths[0]+widths[1]])
+			f3 := field(b[widths[0]+widths[1]:])
+			if _, ok := f.xref[start+n]; ok {
+				continue
+			}
+			switch typ {
+			case 1:
+				f.xref[start+n] = xrefEntry{offset: f2}
+			case 2:
+				f.xref[start+n] = xrefEntry{stream: f2, index: int(f3)}
+			default:
+				f.xref[start+n] = xrefEntry{free: true}
+			}
+		}
+	}
+	return nil
+}
+
+// field decodes a big endian field of an xref stream.
+func field(b []byte) int64 {
+	var v int64
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	return v
+}
+
+var objPattern = regexp.MustCompile(`(?m)(\d+)[ \t\r\n\f\x00]+(\d+)[ \t\r\n\f\x00]+obj\b`)
+
+// rebuild finds the objects of a file with damaged cross-reference data by
+// scanning it, and takes the trailer from the last trailer dictionary or the
+// catalog.
+func (f *file) rebuild() error {
+	f.xref = map[int64]xrefEntry{}
+	f.objects = map[int64]interface{}{}
+	f.trailer = nil
+	for _, m := range objPattern.FindAllSubmatchIndex(f.data, -1) {
+		num, _ := strconv.ParseInt(string(f.data[m[2]:m[3]]), 10, 64)
+		f.xref[num] = xrefEntry{offset: int64(m[0])}
+	}
+	if i := bytes.LastIndex(f.data, []byte("trailer")); i >= 0 {
+		l := &lexer{data: f.data, pos: i + len("trailer")}
+		if tok, err := l.next(); err == nil {
+			f.trailer, _ = tok.(dict)
+		}
+	}
+	// Objects in object streams aren't found by the scan.
+	var streams []int64
+	for num := range f.xref {
+		streams = append(streams, num)
+	}
+	for _, num := range streams {
+		obj, err := f.object(num)
+		if err != nil {
+			continue
+		}
+		if s, ok := obj.(*stream); ok {
+			switch s.dict["Type"] {
+			case name("ObjStm"):
+				objs, _ := f.objStm(num)
+				for i, n := range objStmNumbers(f, s) {
+					if _, ok := f.xref[n]; !ok && i < len(objs) {
+						f.xref[n] = xrefEntry{stream: num, index: i}
+					}
+				}
+			case name("XRef"):
+				if f.trailer == nil {
+					f.trailer = s.dict
+				}
+			}
+		}
+		if d, ok := obj.(dict); ok && d["Type"] == name("Catalog") && (f.trailer == nil || f.trailer["Root"] == nil) {
+			if f.trailer == nil {
+				f.trailer = dict{}
+			}
+			f.trailer["Root"] = ref{num: num}
+		}
+	}
+	if f.trailer == nil || f.trailer["Root"] == nil {
+		return errors.New("pdf: no document catalog")
+	}
+	return nil
+}
+
+// resolve follows references to the object they refer to. Missing objects are
+// null.
+func (f *file) resolve(obj interface{}) interface{} {
+	for i := 0; i < maxDepth; i++ {
+		r, ok := obj.(ref)
+		if !ok {
+			return obj
+		}
+		obj, _ = f.object(r.num)
+	}
+	return nil
+}
+
+// dict resolves obj to a dictionary, or the dictionary of a stream.
+func (f *file) dict(obj interface{}) dict {
+	switch v := f.resolve(obj).(type) {
+	case dict:
+		return v
+	case *stream:
+		return v.dict
+	}
+	return nil
+}
+
+func (f *file) array(obj interface{}) array {
+	a, _ := f.resolve(obj).(array)
+	return a
+}
+
+// number resolves obj to a number.
+func (f *file) number(obj interface{}) (float64, bool) {
+	switch v := f.resolve(obj).(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func (f *file) object(num int64) (interface{}, error) {
+	if obj, ok := f.objects[num]; ok {
+		return obj, nil
+	}
+	// Mark the object while it is read, so cycles read as null.
+	f.objects[num] = nil
+	e, ok := f.xref[num]
+	if !ok || e.free {
+		return nil, nil
+	}
+	var obj interface{}
+	if e.stream != 0 {
+		objs, err := f.objStm(e.stream)
+		if err != nil {
+			return nil, err
+		}
+		if e.index < len(objs) {
+			obj = objs[e.index]
+		}
+	} else {
+		var err error
+		if _, obj, err = f.readObjectAt(e.offset); err != nil {
+			return nil, err
+		}
+	}
+	f.objects[num] = obj
+	return obj, nil
+}
+
+// objStm returns the objects of the object stream with the given number.
+func (f *file) objStm(num int64) ([]interface{}, error) {
+	if objs, ok := f.objStms[num]; ok {
+		return objs, nil
+	}
+	obj, err := f.object(num)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := obj.(*stream)
+	if !ok {
+		return nil, fmt.Errorf("object %d is not an object stream", num)
+	}
+	data, err := f.decode(s)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := f.number(s.dict["N"])
+	first, _ := f.number(s.dict["First"])
+	l := &lexer{data: data}
+	offsets := make([]int, int(n))
+	for i := range offsets {
+		if _, err := l.next(); err != nil {
+			return nil, err
+		}
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		off, _ := tok.(int64)
+		offsets[i] = int(first) + int(off)
+	}
+	objs := make([]interface{}, len(offsets))
+	for i, off := range offsets {
+		if off < 0 || off >= len(data) {
+			continue
+		}
+		l.pos = off
+		if objs[i], err = l.next(); err != nil {
+			return nil, err
+		}
+	}
+	f.objStms[num] = objs
+	return objs, nil
+}
+
+// objStmNumbers returns the numbers of the objects in an object stream.
+func objStmNumbers(f *file, s *stream) []int64 {
+	data, err := f.decode(s)
+	if err != nil {
+		return nil
+	}
+	n, _ := f.number(s.dict["N"])
+	l := &lexer{data: data}
+	nums := make([]int64, 0, int(n))
+	for i := 0; i < int(n); i++ {
+		tok, err := l.next()
+		if err != nil {
+			break
+		}
+		num, _ := tok.(int64)
+		nums = append(nums, num)
+		if _, err := l.next(); err != nil {
+			break
+		}
+	}
+	return nums
+}
+
+// readObjectAt reads the indirect object at offset.
+func (f *file) readObjectAt(offset int64) (int64, interface{}, error) {
+	if offset < 0 || offset >= int64(len(f.data)) {
+		return 0, nil, fmt.Errorf("object offset %d out of range", offset)
+	}
+	l := &lexer{data: f.data, pos: int(offset)}
+	var head [3]interface{}
+	for i := range head {
+		tok, err := l.next()
+		if err != nil {
+			return 0, nil, err
+		}
+		head[i] = tok
+	}
+	num, ok := head[0].(int64)
+	if !ok || head[2] != keyword("obj") {
+		return 0, nil, fmt.Errorf("no object at offset %d", offset)
+	}
+	obj, err := l.next()
+	if err != nil {
+		return 0, nil, err
+	}
+	d, ok := obj.(dict)
+	if !ok {
+		return num, obj, nil
+	}
+	start, ok := l.streamData()
+	if !ok {
+		return num, d, nil
+	}
+	end := -1
+	if length, ok := f.number(d["Length"]); ok && start+int(length) <= len(f.data) {
+		end = start + int(length)
+		if !bytes.Contains(f.data[end:minInt(end+32, len(f.data))], []byte("endstream")) {
+			end = -1
+		}
+	}
+	if end < 0 {
+		// The length is wrong, so look for the end of the stream.
+		i := bytes.Index(f.data[start:], []byte("endstream"))
+		if i < 0 {
+			return 0, nil, fmt.Errorf("object %d: unterminated stream", num)
+		}
+		end = start + i
+		for end > start && (f.data[end-1] == '\n' || f.data[end-1] == '\r') {
+			end--
+		}
+	}
+	return num, &stream{dict: d, data: f.data[start:end]}, nil
+}
+
+// decode returns the data of s with its filters applied.
+func (f *file) decode(s *stream) ([]byte, error) {
+	var filters, params array
+	switch v := f.resolve(s.dict["Filter"]).(type) {
+	case name:
+		filters = array{v}
+		params = array{s.dict["DecodeParms"]}
+	case array:
+		filters = v
+		params = f.array(s.dict["DecodeParms"])
+	}
+	data := s.data
+	for i, filter := range filters {
+		var p dict
+		if i < len(params) {
+			p = f.dict(params[i])
+		}
+		var err error
+		if data, err = f.filter(f.resolve(filter), data, p); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (f *file) filter(filter interface{}, data []byte, params dict) ([]byte, error) {
+	var err error
+	switch filter {
+	case name("FlateDecode"), name("Fl"):
+		data, err = inflate(data)
+	case name("LZWDecode"), name("LZW"):
+		early := int64(1)
+		if v, ok := params["EarlyChange"].(int64); ok {
+			early = v
+		}
+		data, err = lzwDecode(data, early == 1)
+	case name("ASCIIHexDecode"), name("AHx"):
+		l := &lexer{data: append(append([]byte{'<'}, data...), '>')}
+		var s str
+		s, err = l.hex()
+		data = []byte(s)
+	case name("ASCII85Decode"), name("A85"):
+		data, err = ascii85Decode(data)
+	case name("RunLengthDecode"), name("RL"):
+		data = runLengthDecode(data)
+	default:
+		return nil, fmt.Errorf("pdf: unsupported filter %v", filter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %v: %w", filter, err)
+	}
+	if params != nil {
+		return f.predict(data, params)
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data. Data after a corrupt or truncated part is
+// lost, but what came before it is kept.
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	out, err := io.ReadAll(r)
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+// predict undoes the PNG and TIFF predictors of Flate and LZW data.
+func (f *file) predict(data []byte, params dict) ([]byte, error) {
+	predictor, _ := f.number(params["Predictor"])
+	if predictor <= 1 {
+		return data, nil
+	}
+	colors, columns, bpc := 1.0, 1.0, 8.0
+	if v, ok := f.number(params["Colors"]); ok {
+		colors = v
+	}
+	if v, ok := f.number(params["Columns"]); ok {
+		columns = v
+	}
+	if v, ok := f.number(params["BitsPerComponent"]); ok {
+		bpc = v
+	}
+	bpp := int(colors*bpc+7) / 8
+	rowLen := int(colors*bpc*columns+7) / 8
+	if rowLen <= 0 {
+		return nil, errors.New("pdf: invalid predictor parameters")
+	}
+	if predictor == 2 {
+		if bpc != 8 {
+			return nil, fmt.Errorf("pdf: unsupported TIFF predictor with %v bits per component", bpc)
+		}
+		out := append([]byte(nil), data...)
+		for row := 0; row+rowLen <= len(out); row += rowLen {
+			for i := bpp; i < rowLen; i++ {
+				out[row+i] += out[row+i-bpp]
+			}
+		}
+		return out, nil
+	}
+	var out []byte
+	prev := make([]byte, rowLen)
+	for pos := 0; pos+1+rowLen <= len(data); pos += 1 + rowLen {
+		typ, row := data[pos], append([]byte(nil), data[pos+1:pos+1+rowLen]...)
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			up := prev[i]
+			switch typ {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func ascii85Decode(data []byte) ([]byte, error) {
+	var out []byte
+	var group [5]byte
+	n := 0
+	for _, c := range data {
+		switch {
+		case c == '~':
+			goto done
+		case isSpace(c):
+			continue
+		case c == 'z' && n == 0:
+			out = append(out, 0, 0, 0, 0)
+			continue
+		case c < '!' || c > 'u':
+			return nil, fmt.Errorf("invalid character %q", c)
+		}
+		group[n] = c - '!'
+		n++
+		if n == 5 {
+			out = append(out, decode85(group, 4)...)
+			n = 0
+		}
+	}
+done:
+	if n == 1 {
+		return nil, errors.New("truncated group")
+	}
+	if n > 1 {
+		for i := n; i < 5; i++ {
+			group[i] = 'u' - '!'
+		}
+		out = append(out, decode85(group, n-1)...)
+	}
+	return out, nil
+}
+
+func decode85(group [5]byte, n int) []byte {
+	var v uint32
+	for _, c := range group {
+		v = v*85 + uint32(c)
+	}
+	return []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}[:n]
+}
+
+func runLengthDecode(data []byte) []byte {
+	var out []byte
+	for i := 0; i < len(data); {
+		n := int(data[i])
+		i++
+		switch {
+		case n == 128:
+			return out
+		case n < 128:
+			end := i + n + 1
+			if end > len(data) {
+				end = len(data)
+			}
+			out = append(out, data[i:end]...)
+			i = end
+		case i < len(data):
+			for j := 0; j < 257-n; j++ {
+				out = append(out, data[i])
+			}
+			i++
+		}
+	}
+	return out
+}
+
+// lzwDecode decodes LZW data, which compress/lzw can't since PDF increases
+// the code width one code early by default.
+func lzwDecode(data []byte, early bool) ([]byte, error) {
+	const clear, eod = 256, 257
+	var out []byte
+	var table [][]byte
+	reset := func() {
+		table = table[:0]
+		for i := 0; i < 256; i++ {
+			table = append(table, []byte{byte(i)})
+		}
+		table = append(table, nil, nil)
+	}
+	reset()
+	width := 9
+	var bits, nbits uint32
+	var prev []byte
+	for _, c := range data {
+		bits = bits<<8 | uint32(c)
+		nbits += 8
+		for nbits >= uint32(width) {
+			code := int(bits>>(nbits-uint32(width))) & (1<<width - 1)
+			nbits -= uint32(width)
+			switch {
+			case code == clear:
+				reset()
+				width, prev = 9, nil
+				continue
+			case code == eod:
+				return out, nil
+			}
+			var entry []byte
+			switch {
+			case code < len(table) && table[code] != nil:
+				entry = table[code]
+			case code == len(table) && prev != nil:
+				entry = append(append([]byte(nil), prev...), prev[0])
+			default:
+				return nil, fmt.Errorf("invalid code %d", code)
+			}
+			out = append(out, entry...)
+			if prev != nil {
+				table = append(table, append(append([]byte(nil), prev...), entry[0]))
+			}
+			prev = entry
+			size := len(table)
+			if early {
+				size++
+			}
+			switch {
+			case size > 2048:
+				width = 12
+			case size > 1024:
+				width = 11
+			case size > 512:
+				width = 10
+			}
+		}
+	}
+	return out, nil
+}
+
+// page is a page of the document with its inherited attributes.
+type page struct {
+	dict      dict
+	resources dict
+	// box is the visible area of the page, as llx lly urx ury.
+	box    [4]float64
+	rotate int
+}
+
+// pages returns the pages of the document in order.
+func (f *file) pages() ([]*page, error) {
+	root := f.dict(f.trailer["Root"])
+	if root == nil {
+		return nil, errors.New("pdf: no document catalog")
+	}
+	var pages []*page
+	inherited := &page{box: [4]float64{0, 0, 612, 792}}
+	// visited holds the indirect nodes walked, as only they can form cycles.
+	visited := map[ref]bool{}
+	var walk func(node interface{}, parent *page, depth int) error
+	walk = func(node interface{}, parent *page, depth int) error {
+		r, indirect := node.(ref)
+		if depth > maxDepth || (indirect && visited[r]) {
+			return errors.New("pdf: page tree is too deep or cyclic")
+		}
+		if indirect {
+			visited[r] = true
+		}
+		d := f.dict(node)
+		if d == nil {
+			return nil
+		}
+		p := *parent
+		if res := f.dict(d["Resources"]); res != nil {
+			p.resources = res
+		}
+		if box, ok := f.rect(d["MediaBox"]); ok {
+			p.box = box
+		}
+		if rotate, ok := f.number(d["Rotate"]); ok {
+			p.rotate = int(rotate)
+		}
+		kids := f.array(d["Kids"])
+		if d["Type"] == name("Pages") || (d["Type"] == nil && kids != nil) {
+			for _, kid := range kids {
+				if f.dict(kid) == nil {
+					return errors.New("pdf: page tree node is not a dictionary")
+				}
+				if err := walk(kid, &p, depth+1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if box, ok := f.rect(d["CropBox"]); ok {
+			p.box = intersect(p.box, box)
+		}
+		p.dict = d
+		p.rotate = ((p.rotate % 360) + 360) % 360
+		pages = append(pages, &p)
+		return nil
+	}
+	if err := walk(root["Pages"], inherited, 0); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// rect reads a rectangle, normalizing it so that the first corner is the
+// lower left one.
+func (f *file) rect(obj interface{}) ([4]float64, bool) {
+	a := f.array(obj)
+	if len(a) != 4 {
+		return [4]float64{}, false
+	}
+	var r [4]float64
+	for i := range r {
+		v, ok := f.number(a[i])
+		if !ok {
+			return [4]float64{}, false
+		}
+		r[i] = v
+	}
+	if r[0] > r[2] {
+		r[0], r[2] = r[2], r[0]
+	}
+	if r[1] > r[3] {
+		r[1], r[3] = r[3], r[1]
+	}
+	return r, true
+}
+
+func intersect(a, b [4]float64) [4]float64 {
+	r := [4]float64{maxf(a[0], b[0]), maxf(a[1], b[1]), minf(a[2], b[2]), minf(a[3], b[3])}
+	if r[0] >= r[2] || r[1] >= r[3] {
+		return a
+	}
+	return r
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func minf(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxf(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// contents returns the decoded content streams of a page, concatenated.
+func (f *file) contents(p *page) ([]byte, error) {
+	var streams []interface{}
+	switch v := f.resolve(p.dict["Contents"]).(type) {
+	case *stream:
+		streams = append(streams, v)
+	case array:
+		streams = v
+	}
+	var out []byte
+	for _, obj := range streams {
+		s, ok := f.resolve(obj).(*stream)
+		if !ok {
+			continue
+		}
+		data, err := f.decode(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(append(out, data...), '\n')
+	}
+	return out, nil
+}
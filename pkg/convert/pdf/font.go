@@ -0,0 +1,466 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf16"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Font descriptor flags.
+const (
+	flagFixedPitch = 1 << 0
+	flagSerif      = 1 << 1
+	flagSymbolic   = 1 << 2
+	flagItalic     = 1 << 6
+	flagForceBold  = 1 << 18
+)
+
+// Metrics of fonts whose descriptor doesn't give them, in thousandths of an
+// em.
+const (
+	defaultAscent  = 750
+	defaultDescent = -250
+	defaultWidth   = 500
+)
+
+// font is a font resource, reduced to what is needed to find the text and
+// boxes of the glyphs it shows.
+type font struct {
+	name      string
+	serif     bool
+	monospace bool
+	// styles are the bold and italic styles of the font.
+	styles []ocr.FontStyle_Style
+	// composite is set for Type0 fonts, whose codes are read with encoding
+	// and whose widths are indexed by CID.
+	composite bool
+	encoding  *cmap
+	toUnicode *cmap
+	// simple holds the text of each code of a simple font.
+	simple [256][]rune
+	// widths holds the advance of codes of simple fonts and CIDs of
+	// composite ones, in ems.
+	widths       map[uint32]float64
+	defaultWidth float64
+	// standard holds the widths of a standard font by rune, for simple fonts
+	// without widths.
+	standard map[rune]float64
+	// ascent and descent are the extent of glyphs above and below the
+	// baseline, in ems.
+	ascent, descent float64
+}
+
+// loadFont reads a font dictionary.
+func (f *file) loadFont(d dict) *font {
+	ft := &font{
+		name:         baseName(d["BaseFont"]),
+		widths:       map[uint32]float64{},
+		defaultWidth: defaultWidth / 1000.0,
+		ascent:       defaultAscent / 1000.0,
+		descent:      defaultDescent / 1000.0,
+	}
+	ft.serif, ft.monospace, ft.standard = standardFont(ft.name)
+	if ft.monospace {
+		ft.defaultWidth = 0.6
+	}
+	if tu, ok := f.resolve(d["ToUnicode"]).(*stream); ok {
+		if data, err := f.decode(tu); err == nil {
+			ft.toUnicode = parseCMap(data)
+		}
+	}
+
+	descriptor := f.dict(d["FontDescriptor"])
+	if d["Subtype"] == name("Type0") {
+		ft.composite = true
+		switch enc := f.resolve(d["Encoding"]).(type) {
+		case *stream:
+			if data, err := f.decode(enc); err == nil {
+				ft.encoding = parseCMap(data)
+			}
+		}
+		ft.defaultWidth = 1
+		if descendants := f.array(d["DescendantFonts"]); len(descendants) > 0 {
+			cid := f.dict(descendants[0])
+			descriptor = f.dict(cid["FontDescriptor"])
+			if dw, ok := f.number(cid["DW"]); ok {
+				ft.defaultWidth = dw / 1000
+			}
+			f.cidWidths(ft, f.array(cid["W"]))
+		}
+	} else {
+		scale := 0.001
+		if m := f.array(d["FontMatrix"]); len(m) == 6 {
+			// Type 3 glyphs are measured in the units of their
+			// font matrix.
+			if v, ok := f.number(m[0]); ok {
+				scale = v
+			}
+			if v, ok := f.number(m[3]); ok {
+				ft.ascent, ft.descent = defaultAscent*v, defaultDescent*v
+			}
+		}
+		first, _ := f.number(d["FirstChar"])
+		for i, w := range f.array(d["Widths"]) {
+			if v, ok := f.number(w); ok {
+				ft.widths[uint32(int(first)+i)] = v * scale
+			}
+		}
+		symbolic := false
+		if flags, ok := f.number(descriptor["Flags"]); ok {
+			symbolic = int(flags)&flagSymbolic != 0
+		}
+		f.simpleEncoding(ft, d, symbolic)
+	}
+
+	lower := strings.ToLower(ft.name)
+	bold := strings.Contains(lower, "bold") || strings.Contains(lower, "black") || strings.Contains(lower, "heavy")
+	italic := strings.Contains(lower, "italic") || strings.Contains(lower, "oblique")
+	if descriptor != nil {
+		if flags, ok := f.number(descriptor["Flags"]); ok {
+			ft.serif = int(flags)&flagSerif != 0
+			ft.monospace = int(flags)&flagFixedPitch != 0
+			bold = bold || int(flags)&flagForceBold != 0
+			italic = italic || int(flags)&flagItalic != 0
+		}
+		if weight, ok := f.number(descriptor["FontWeight"]); ok && weight >= 700 {
+			bold = true
+		}
+		if v, ok := f.number(descriptor["MissingWidth"]); ok && v > 0 && !ft.composite {
+			ft.defaultWidth = v / 1000
+		}
+		ascent, _ := f.number(descriptor["Ascent"])
+		descent, _ := f.number(descriptor["Descent"])
+		if ascent > 0 && descent <= 0 && d["Subtype"] != name("Type3") {
+			ft.ascent, ft.descent = ascent/1000, descent/1000
+		}
+	}
+	if bold {
+		ft.styles = append(ft.styles, ocr.BOLD)
+	}
+	if italic {
+		ft.styles = append(ft.styles, ocr.ITALIC)
+	}
+	return ft
+}
+
+// baseName returns the name of a font without the tag of a subset.
+func baseName(obj interface{}) string {
+	n, _ := obj.(name)
+	s := string(n)
+	if len(s) > 7 && s[6] == '+' && strings.ToUpper(s[:6]) == s[:6] {
+		s = s[7:]
+	}
+	return s
+}
+
+// standardFont returns what is known about the standard fonts, and others
+// named like them.
+func standardFont(n string) (serif, monospace bool, widths map[rune]float64) {
+	switch {
+	case strings.HasPrefix(n, "Courier"):
+		return true, true, nil
+	case strings.HasPrefix(n, "Times"):
+		return true, false, timesWidths
+	case strings.HasPrefix(n, "Helvetica"), strings.HasPrefix(n, "Arial"):
+		return false, false, helveticaWidths
+	}
+	return false, false, nil
+}
+
+// simpleEncoding sets the text of the codes of a simple font from its
+// encoding and differences.
+func (f *file) simpleEncoding(ft *font, d dict, symbolic bool) {
+	base := &standardEncoding
+	if d["Subtype"] == name("TrueType") {
+		base = &winAnsiEncoding
+	}
+	var differences array
+	switch enc := f.resolve(d["Encoding"]).(type) {
+	case name:
+		base = namedEncoding(enc, base)
+	case dict:
+		if n, ok := f.resolve(enc["BaseEncoding"]).(name); ok {
+			base = namedEncoding(n, base)
+		}
+		differences = f.array(enc["Differences"])
+	default:
+		if symbolic {
+			// Symbolic fonts without an encoding use their
+			// built-in one, which is unknown, so codes are taken
+			// as Latin-1.
+			base = nil
+		}
+	}
+	for c := range ft.simple {
+		switch {
+		case base == nil && c >= 0x20:
+			ft.simple[c] = []rune{rune(c)}
+		case base != nil && base[c] != 0:
+			ft.simple[c] = []rune{base[c]}
+		}
+	}
+	code := 0
+	for _, obj := range differences {
+		switch v := f.resolve(obj).(type) {
+		case int64:
+			code = int(v)
+		case name:
+			if code >= 0 && code < 256 {
+				ft.simple[code] = glyphRunes(string(v))
+			}
+			code++
+		}
+	}
+}
+
+func namedEncoding(n name, def *[256]rune) *[256]rune {
+	switch n {
+	case "WinAnsiEncoding":
+		return &winAnsiEncoding
+	case "MacRomanEncoding":
+		return &macRomanEncoding
+	case "StandardEncoding":
+		return &standardEncoding
+	}
+	return def
+}
+
+// cidWidths reads the W array of a CIDFont.
+func (f *file) cidWidths(ft *font, w array) {
+	for i := 0; i < len(w); {
+		first, ok := f.number(w[i])
+		if !ok || i+1 >= len(w) {
+			return
+		}
+		if list, ok := f.resolve(w[i+1]).(array); ok {
+			for j, obj := range list {
+				if v, ok := f.number(obj); ok {
+					ft.widths[uint32(first)+uint32(j)] = v / 1000
+				}
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(w) {
+			return
+		}
+		last, _ := f.number(w[i+1])
+		v, _ := f.number(w[i+2])
+		for c := first; c <= last && c-first < 0x10000; c++ {
+			ft.widths[uint32(c)] = v / 1000
+		}
+		i += 3
+	}
+}
+
+// next returns the first character code of s and its length in bytes.
+func (ft *font) next(s []byte) (uint32, int) {
+	if !ft.composite {
+		return uint32(s[0]), 1
+	}
+	if ft.encoding != nil && len(ft.encoding.codespace) > 0 {
+		return ft.encoding.next(s)
+	}
+	// Identity-H and Identity-V have two byte codes.
+	if len(s) < 2 {
+		return uint32(s[0]), 1
+	}
+	return uint32(s[0])<<8 | uint32(s[1]), 2
+}
+
+// text returns the text of a character code. Codes whose text is unknown
+// become the replacement character.
+func (ft *font) text(code uint32, n int) []rune {
+	if ft.toUnicode != nil {
+		if r, ok := ft.toUnicode.unicode(code, n); ok {
+			return r
+		}
+	}
+	if !ft.composite && ft.simple[code&0xff] != nil {
+		return ft.simple[code&0xff]
+	}
+	return []rune{'�'}
+}
+
+// width returns the advance of a character code in ems.
+func (ft *font) width(code uint32, n int, text []rune) float64 {
+	key := code
+	if ft.composite {
+		key = ft.cid(code, n)
+	}
+	if w, ok := ft.widths[key]; ok {
+		return w
+	}
+	if !ft.composite && ft.standard != nil && len(text) == 1 {
+		if w, ok := ft.standard[text[0]]; ok {
+			return w / 1000
+		}
+	}
+	return ft.defaultWidth
+}
+
+// cid returns the CID of a code of a composite font.
+func (ft *font) cid(code uint32, n int) uint32 {
+	if ft.encoding != nil {
+		if cid, ok := ft.encoding.cid(code, n); ok {
+			return cid
+		}
+	}
+	return code
+}
+
+// cmap is a CMap, mapping character codes to CIDs or to text.
+type cmap struct {
+	codespace []codeRange
+	ranges    []codeRange
+}
+
+// codeRange is a range of codes of n bytes, and what they map to: text
+// starting at dst, a list of texts, or CIDs starting at cid.
+type codeRange struct {
+	n      int
+	lo, hi uint32
+	dst    []rune
+	list   [][]rune
+	cid    uint32
+}
+
+// parseCMap reads the mappings of a CMap, ignoring the rest of the program.
+func parseCMap(data []byte) *cmap {
+	c := &cmap{}
+	l := &lexer{data: data}
+	var operands []interface{}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return c
+		}
+		kw, ok := tok.(keyword)
+		if !ok {
+			operands = append(operands, tok)
+			continue
+		}
+		switch kw {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				lo, hi := bytesOf(operands[i]), bytesOf(operands[i+1])
+				if len(lo) > 0 && len(lo) == len(hi) {
+					c.codespace = append(c.codespace, codeRange{n: len(lo), lo: code(lo), hi: code(hi)})
+				}
+			}
+		case "endbfchar", "endcidchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				if src := bytesOf(operands[i]); len(src) > 0 {
+					c.add(src, src, operands[i+1])
+				}
+			}
+		case "endbfrange", "endcidrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, hi := bytesOf(operands[i]), bytesOf(operands[i+1])
+				if len(lo) > 0 && len(lo) == len(hi) {
+					c.add(lo, hi, operands[i+2])
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func (c *cmap) add(lo, hi []byte, dst interface{}) {
+	r := codeRange{n: len(lo), lo: code(lo), hi: code(hi)}
+	switch v := dst.(type) {
+	case str:
+		r.dst = utf16Runes([]byte(v))
+	case name:
+		r.dst = glyphRunes(string(v))
+	case int64:
+		r.cid = uint32(v)
+	case array:
+		for _, obj := range v {
+			s, _ := obj.(str)
+			r.list = append(r.list, utf16Runes([]byte(s)))
+		}
+	default:
+		return
+	}
+	c.ranges = append(c.ranges, r)
+}
+
+// next returns the first code of s, whose length is given by the codespace
+// ranges.
+func (c *cmap) next(s []byte) (uint32, int) {
+	for n := 1; n <= 4 && n <= len(s); n++ {
+		v := code(s[:n])
+		for _, r := range c.codespace {
+			if r.n == n && v >= r.lo && v <= r.hi {
+				return v, n
+			}
+		}
+	}
+	return uint32(s[0]), 1
+}
+
+// find returns the range mapping a code of n bytes.
+func (c *cmap) find(v uint32, n int) *codeRange {
+	// Later mappings override earlier ones.
+	for i := len(c.ranges) - 1; i >= 0; i-- {
+		r := &c.ranges[i]
+		if r.n == n && v >= r.lo && v <= r.hi {
+			return r
+		}
+	}
+	return nil
+}
+
+func (c *cmap) unicode(v uint32, n int) ([]rune, bool) {
+	r := c.find(v, n)
+	switch {
+	case r == nil:
+		return nil, false
+	case r.list != nil:
+		if i := int(v - r.lo); i < len(r.list) {
+			return r.list[i], true
+		}
+		return nil, false
+	case len(r.dst) > 0:
+		text := append([]rune(nil), r.dst...)
+		text[len(text)-1] += rune(v - r.lo)
+		return text, true
+	}
+	return nil, false
+}
+
+func (c *cmap) cid(v uint32, n int) (uint32, bool) {
+	r := c.find(v, n)
+	if r == nil || r.dst != nil || r.list != nil {
+		return 0, false
+	}
+	return r.cid + v - r.lo, true
+}
+
+func bytesOf(obj interface{}) []byte {
+	s, _ := obj.(str)
+	return []byte(s)
+}
+
+// code returns the value of a big endian code.
+func code(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+// utf16Runes decodes UTF-16BE text. A trailing odd byte is taken as a rune.
+func utf16Runes(b []byte) []rune {
+	u := make([]uint16, 0, len(b)/2+1)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	if len(b)%2 == 1 {
+		u = append(u, uint16(b[len(b)-1]))
+	}
+	return utf16.Decode(u)
+}
@@ -0,0 +1,327 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// The objects of a PDF file are represented by these types, and by bool,
+// int64, float64 and nil.
+type (
+	// name is a name object, without its slash.
+	name string
+	// str is a string object, literal or hexadecimal.
+	str string
+	// array is an array object.
+	array []interface{}
+	// dict is a dictionary object.
+	dict map[name]interface{}
+	// ref is an indirect reference.
+	ref struct {
+		num, gen int64
+	}
+	// stream is a stream object with its undecoded data.
+	stream struct {
+		dict dict
+		data []byte
+	}
+	// keyword is a bare word such as obj, R or a content stream operator.
+	keyword string
+)
+
+// Delimiters and whitespace end tokens.
+func isDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+// lexer reads the objects of a file or a content stream.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+// skipSpace skips whitespace and comments.
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// errEOF is returned when the input ends before an object does.
+var errEOF = fmt.Errorf("unexpected end of data")
+
+// next reads the next token: an object, or a keyword, which includes "]", ">>"
+// and the words making up indirect objects and references. Arrays and
+// dictionaries are read whole, and references within them resolved into ref.
+func (l *lexer) next() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errEOF
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		return l.name(), nil
+	case c == '(':
+		return l.literal()
+	case c == '<' && l.peek(1) == '<':
+		l.pos += 2
+		return l.dict()
+	case c == '<':
+		return l.hex()
+	case c == '>' && l.peek(1) == '>':
+		l.pos += 2
+		return keyword(">>"), nil
+	case c == '[':
+		l.pos++
+		return l.array()
+	case c == ']' || c == '{' || c == '}' || c == ')' || c == '>':
+		l.pos++
+		return keyword(c), nil
+	}
+	start := l.pos
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if isNumber(word) {
+		if i, err := strconv.ParseInt(word, 10, 64); err == nil {
+			return i, nil
+		}
+		f, _ := strconv.ParseFloat(word, 64)
+		return f, nil
+	}
+	return keyword(word), nil
+}
+
+func (l *lexer) peek(n int) byte {
+	if l.pos+n < len(l.data) {
+		return l.data[l.pos+n]
+	}
+	return 0
+}
+
+// isNumber reports whether word is an integer or real number, which PDF writes
+// without exponents.
+func isNumber(word string) bool {
+	digits := 0
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case (c == '+' || c == '-') && i == 0, c == '.':
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
+
+func (l *lexer) name() name {
+	l.pos++
+	var b []byte
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelim(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		l.pos++
+	}
+	return name(b)
+}
+
+func (l *lexer) literal() (str, error) {
+	l.pos++
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return str(b), nil
+			}
+		case '\r':
+			// End of line markers read as a newline.
+			if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+				l.pos++
+			}
+			c = '\n'
+		case '\\':
+			if l.pos >= len(l.data) {
+				return "", errEOF
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return "", errEOF
+}
+
+func (l *lexer) hex() (str, error) {
+	l.pos++
+	var digits []byte
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			if len(digits)%2 == 1 {
+				digits = append(digits, '0')
+			}
+			b := make([]byte, len(digits)/2)
+			for i := range b {
+				b[i] = unhex(digits[2*i])<<4 | unhex(digits[2*i+1])
+			}
+			return str(b), nil
+		}
+		if !isSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	return "", errEOF
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10
+	}
+	return 0
+}
+
+func (l *lexer) array() (array, error) {
+	return l.objects(keyword("]"))
+}
+
+func (l *lexer) dict() (dict, error) {
+	a, err := l.objects(keyword(">>"))
+	if err != nil {
+		return nil, err
+	}
+	d := dict{}
+	for i := 0; i+1 < len(a); i += 2 {
+		if k, ok := a[i].(name); ok {
+			d[k] = a[i+1]
+		}
+	}
+	return d, nil
+}
+
+// objects reads objects up to the keyword end, combining the numbers before
+// each R into a reference.
+func (l *lexer) objects(end keyword) (array, error) {
+	var a array
+	for {
+		obj, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		if obj == end {
+			return a, nil
+		}
+		if obj == keyword("R") && len(a) >= 2 {
+			num, ok1 := a[len(a)-2].(int64)
+			gen, ok2 := a[len(a)-1].(int64)
+			if ok1 && ok2 {
+				a = append(a[:len(a)-2], ref{num, gen})
+				continue
+			}
+		}
+		a = append(a, obj)
+	}
+}
+
+// streamData returns the offset of the data of a stream whose dictionary
+// ends at the current position, if the stream keyword follows.
+func (l *lexer) streamData() (int, bool) {
+	save := l.pos
+	l.skipSpace()
+	if !bytes.HasPrefix(l.data[l.pos:], []byte("stream")) {
+		l.pos = save
+		return 0, false
+	}
+	p := l.pos + len("stream")
+	if p < len(l.data) && l.data[p] == '\r' {
+		p++
+	}
+	if p < len(l.data) && l.data[p] == '\n' {
+		p++
+	}
+	return p, true
+}
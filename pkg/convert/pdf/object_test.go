@@ -0,0 +1,33 @@
+package pdf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexer(t *testing.T) {
+	tests := map[string]struct {
+		data string
+		want interface{}
+	}{
+		"name":            {data: "/A#20B", want: name("A B")},
+		"literal":         {data: "(a\\(b\\) (c)\\n\\101\\\nd)", want: str("a(b) (c)\nAd")},
+		"hex":             {data: "<48 6 9>", want: str("Hi")},
+		"number":          {data: "-.5", want: -0.5},
+		"dict and ref":    {data: "<< /Kids [1 0 R 2] /N null >>", want: dict{"Kids": array{ref{1, 0}, int64(2)}, "N": nil}},
+		"comment":         {data: "% comment\r\ntrue", want: true},
+		"operator":        {data: "T*", want: keyword("T*")},
+		"nested brackets": {data: "[[]]", want: array{array(nil)}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := &lexer{data: []byte(tt.data)}
+			got, err := l.next()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
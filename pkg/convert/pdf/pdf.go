@@ -0,0 +1,276 @@
+// Package pdf converts the text layer of born-digital PDF files to
+// ocr.Document. The text shown by the content streams of each page, and the
+// forms they draw, is read with the boxes of its glyphs, its fonts and its
+// sizes. Nothing is recognized: scanned pages, which have no text layer, are
+// reported instead.
+//
+// Files using the features of PDF 1.7 commonly found in office documents are
+// read: cross-reference streams, object streams, the Flate, LZW, ASCIIHex,
+// ASCII85 and RunLength filters, simple, Type 3 and composite fonts, and
+// ToUnicode CMaps. Damaged cross-reference data is rebuilt by scanning the
+// file. Encrypted files aren't supported.
+//
+// Documents can be written as searchable PDF files too, with their text at
+// the boxes of its characters, over the images of their pages.
+package pdf
+
+import (
+	"crypto/md5"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"unicode"
+
+	"github.com/zuvaai/eocr-utils/internal/builder"
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// defaultDPI is the resolution of pages when Options.DPI is zero.
+const defaultDPI = 300
+
+// Glyphs start a new word when they are further than wordGap ems from the
+// glyph before them, and a new line when their middle is further than
+// lineGap times their height from it.
+const (
+	wordGap = 0.15
+	lineGap = 0.5
+)
+
+// ErrNoPages means that the file has no pages.
+var ErrNoPages = errors.New("pdf: no pages")
+
+// Options configures the conversion of documents.
+type Options struct {
+	// DPI is the resolution given to the pages read by Unmarshal, which are
+	// measured in points. Zero means 300.
+	DPI uint32
+	// InvisibleText makes the text written by Marshal selectable and
+	// searchable without drawing it, to lay it over page images.
+	InvisibleText bool
+	// ImageDir is a directory of page images drawn by Marshal under the text
+	// of their pages. See WriteWithOptions.
+	ImageDir string
+}
+
+// ReadFile reads a PDF file. See UnmarshalWithOptions.
+func ReadFile(filename string) (*ocr.Document, []int, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Read reads a PDF file from r. See UnmarshalWithOptions.
+func Read(r io.Reader) (*ocr.Document, []int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal converts a PDF file to an ocr.Document with the default options.
+// See UnmarshalWithOptions.
+func Unmarshal(data []byte) (*ocr.Document, []int, error) {
+	return UnmarshalWithOptions(data, Options{})
+}
+
+// UnmarshalWithOptions converts the text layer of a PDF file to an
+// ocr.Document. It also returns the indexes of the pages without text, such
+// as scanned pages, which are in the document without characters.
+//
+// Pages are sized from their crop box and rotated as a viewer shows them.
+// Glyphs keep the order in which they are drawn, which is the reading order
+// of most files. Each glyph's box spans its advance width and the ascent and
+// descent of its font. A glyph starts a new word after a space or a gap, and
+// a new line when it is off the baseline of the glyph before it or to the
+// left of it. Invisible text, such as the text layer added to scans by OCR
+// software, is included. Glyphs whose text is unknown, because their font has
+// no ToUnicode CMap or standard encoding, become U+FFFD.
+//
+// Fonts are named by their base font without the tag of a subset, and are
+// serif, monospace, bold or italic according to their descriptor and name.
+// Font sizes are the sizes at which text is shown, rounded to points.
+//
+// The md5 of the document is the md5 of data and its source is eocr.Pdf2ocr.
+func UnmarshalWithOptions(data []byte, opts Options) (*ocr.Document, []int, error) {
+	if opts.DPI == 0 {
+		opts.DPI = defaultDPI
+	}
+	f, err := newFile(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	pages, err := f.pages()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pages) == 0 {
+		return nil, nil, ErrNoPages
+	}
+
+	b := builder.New()
+	var empty []int
+	for i, p := range pages {
+		content, err := f.contents(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		in := newInterpreter(f)
+		in.run(content, p.resources, identity, 0)
+		v := newView(p, opts.DPI)
+		b.Page(pixels(v.width), pixels(v.height), opts.DPI, opts.DPI)
+		start := b.Len()
+		addGlyphs(b, in.glyphs, v)
+		if b.Len() == start {
+			empty = append(empty, i)
+		}
+	}
+	sum := md5.Sum(data)
+	return b.Document(sum[:], eocr.Pdf2ocr), empty, nil
+}
+
+// view maps the default user space of a page to pixels of the page as it is
+// shown.
+type view struct {
+	box           [4]float64
+	rotate        int
+	scale         float64
+	width, height float64
+}
+
+func newView(p *page, dpi uint32) *view {
+	v := &view{box: p.box, rotate: p.rotate, scale: float64(dpi) / 72}
+	v.width, v.height = (p.box[2]-p.box[0])*v.scale, (p.box[3]-p.box[1])*v.scale
+	if v.rotate == 90 || v.rotate == 270 {
+		v.width, v.height = v.height, v.width
+	}
+	return v
+}
+
+// point maps a point of user space to pixels.
+func (v *view) point(x, y float64) (float64, float64) {
+	llx, lly, urx, ury := v.box[0], v.box[1], v.box[2], v.box[3]
+	switch v.rotate {
+	case 90:
+		return (y - lly) * v.scale, (x - llx) * v.scale
+	case 180:
+		return (urx - x) * v.scale, (y - lly) * v.scale
+	case 270:
+		return (ury - y) * v.scale, (urx - x) * v.scale
+	}
+	return (x - llx) * v.scale, (ury - y) * v.scale
+}
+
+// rect maps a glyph to a rectangle in pixels.
+func (v *view) rect(g *glyph) rect {
+	x1, y1 := v.point(g.x1, g.y1)
+	x2, y2 := v.point(g.x2, g.y2)
+	return rect{math.Min(x1, x2), math.Min(y1, y2), math.Max(x1, x2), math.Max(y1, y2)}
+}
+
+type rect struct {
+	x1, y1, x2, y2 float64
+}
+
+func (r rect) box() *ocr.BoundingBox {
+	return &ocr.BoundingBox{X1: pixels(r.x1), Y1: pixels(r.y1), X2: pixels(r.x2), Y2: pixels(r.y2)}
+}
+
+func pixels(v float64) uint32 {
+	if v <= 0 {
+		return 0
+	}
+	return uint32(math.Round(v))
+}
+
+// addGlyphs adds the glyphs of a page to b, grouping them into words and
+// lines. A word whose glyphs change font part way is added in parts.
+func addGlyphs(b *builder.Builder, glyphs []glyph, v *view) {
+	var part []builder.Glyph
+	var partStyle builder.Style
+	inWord := false
+	flush := func() {
+		if len(part) == 0 {
+			return
+		}
+		if inWord {
+			b.Append(part, partStyle)
+		} else {
+			b.Word(part, partStyle)
+		}
+		part, inWord = nil, true
+	}
+	endWord := func() {
+		flush()
+		inWord = false
+	}
+
+	var prev *rect
+	var prevSize float64
+	for i := range glyphs {
+		g := &glyphs[i]
+		r := v.rect(g)
+		if r.x2 < 0 || r.y2 < 0 || r.x1 > v.width || r.y1 > v.height {
+			// The glyph is outside the crop box.
+			continue
+		}
+		em := g.size * v.scale
+		if prev != nil {
+			height := math.Max(r.y2-r.y1, prev.y2-prev.y1)
+			middle, prevMiddle := (r.y1+r.y2)/2, (prev.y1+prev.y2)/2
+			switch {
+			case math.Abs(middle-prevMiddle) > lineGap*height || r.x1 < prev.x1-lineGap*math.Max(em, prevSize):
+				endWord()
+				b.EndLine()
+			case r.x1-prev.x2 > wordGap*em:
+				endWord()
+			}
+		}
+		prev, prevSize = &r, em
+		if isBlank(g.text) {
+			endWord()
+			continue
+		}
+		style := builder.Style{
+			Font:      g.font.name,
+			Serif:     g.font.serif,
+			Monospace: g.font.monospace,
+			Size:      uint32(math.Round(g.size)),
+			Styles:    g.font.styles,
+		}
+		if len(part) > 0 && !sameStyle(style, partStyle) {
+			flush()
+		}
+		partStyle = style
+		part = append(part, builder.Split(string(g.text), r.box(), 0)...)
+	}
+	endWord()
+	b.EndLine()
+}
+
+// isBlank reports whether text is whitespace or control characters, which
+// separate words.
+func isBlank(text []rune) bool {
+	for _, r := range text {
+		if !unicode.IsSpace(r) && !unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStyle(a, b builder.Style) bool {
+	if a.Font != b.Font || a.Serif != b.Serif || a.Monospace != b.Monospace || a.Size != b.Size || len(a.Styles) != len(b.Styles) {
+		return false
+	}
+	for i := range a.Styles {
+		if a.Styles[i] != b.Styles[i] {
+			return false
+		}
+	}
+	return true
+}
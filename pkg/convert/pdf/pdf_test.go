@@ -0,0 +1,186 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestReadFile(t *testing.T) {
+	doc, empty, err := ReadFile("../../../testdata/text.pdf")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Supply Agreement Price $10 合同 ", eocr.Text(doc))
+	// The second page is a scan.
+	assert.Equal(t, []int{1}, empty)
+	assert.Equal(t, []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 30}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+		{CharacterSpan: &ocr.Span{Start: 30, End: 30}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+	}, doc.Pages)
+	assert.Equal(t, &ocr.Character{Unicode: 'S', BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 263, X2: 333, Y2: 313}}, doc.Characters[0])
+	assert.Equal(t, &ocr.Character{Unicode: ' ', BoundingBox: &ocr.BoundingBox{X1: 453, Y1: 263, X2: 467, Y2: 313}}, doc.Characters[6])
+	// Kerning in TJ moves the glyphs after it.
+	assert.Equal(t, &ocr.Character{Unicode: 'c', BoundingBox: &ocr.BoundingBox{X1: 360, Y1: 329, X2: 385, Y2: 379}}, doc.Characters[20])
+	// A composite font with a ToUnicode CMap.
+	assert.Equal(t, &ocr.Character{Unicode: '合', BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 398, X2: 342, Y2: 439}}, doc.Characters[27])
+
+	assert.Equal(t, []*ocr.Font{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 23}, Name: "Helvetica"},
+		{CharacterSpan: &ocr.Span{Start: 23, End: 27}, Name: "Helvetica-Bold"},
+		{CharacterSpan: &ocr.Span{Start: 27, End: 30}, Name: "SimSun", Serif: true},
+	}, doc.Fonts)
+	assert.Equal(t, []*ocr.FontSize{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 27}, Size_: 12},
+		{CharacterSpan: &ocr.Span{Start: 27, End: 30}, Size_: 10},
+	}, doc.FontSizes)
+	assert.Equal(t, []*ocr.FontStyle{{CharacterSpan: &ocr.Span{Start: 23, End: 27}, Style: ocr.BOLD}}, doc.FontStyles)
+	assert.Equal(t, eocr.Pdf2ocr, doc.Source)
+
+	assert.Empty(t, eocr.Validate(doc))
+}
+
+const helvetica = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"
+
+// buildPDF returns a PDF file with a single letter size page. The page has
+// the given attributes, resources inherited from the page tree and content in
+// object 4. The given objects are numbered from 5.
+func buildPDF(pageAttrs, resources, content string, objects ...string) []byte {
+	objects = append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /Resources " + resources + " >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] " + pageAttrs + " /Contents 4 0 R >>",
+		streamObject("", content),
+	}, objects...)
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func streamObject(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		data      []byte
+		opts      Options
+		wantText  string
+		wantBox   *ocr.BoundingBox
+		wantEmpty []int
+	}{
+		// Text on a page rotated clockwise is drawn going up.
+		"rotated page": {
+			data:     buildPDF("/Rotate 90", "<< /Font << /F1 5 0 R >> >>", "BT /F1 10 Tf 0 1 -1 0 50 10 Tm (Hi) Tj ET", helvetica),
+			opts:     Options{DPI: 72},
+			wantText: "Hi ",
+			wantBox:  &ocr.BoundingBox{X1: 10, Y1: 43, X2: 17, Y2: 53},
+		},
+		"widths and lines": {
+			data: buildPDF("", "<< /Font << /F1 5 0 R >> >>",
+				"BT /F1 10 Tf 1 0 0 1 100 700 Tm [(a) -300 (b)] TJ T* 0 -20 Td (c) Tj ET",
+				"<< /Type /Font /Subtype /TrueType /BaseFont /Arial /FirstChar 97 /LastChar 99 /Widths [400 400 400] >>"),
+			opts:     Options{DPI: 72},
+			wantText: "a b c ",
+			wantBox:  &ocr.BoundingBox{X1: 100, Y1: 85, X2: 104, Y2: 95},
+		},
+		"differences": {
+			data: buildPDF("", "<< /Font << /F1 5 0 R >> >>", "BT /F1 10 Tf 0 700 Td <010241> Tj ET",
+				"<< /Type /Font /Subtype /Type1 /BaseFont /Foo /Encoding << /Differences [1 /f_i /uni00E9.alt] >> >>"),
+			opts:     Options{DPI: 72},
+			wantText: "fiéA ",
+			// The ligature's box is divided between its letters.
+			wantBox: &ocr.BoundingBox{X1: 0, Y1: 85, X2: 2, Y2: 95},
+		},
+		"form": {
+			data: buildPDF("", "<< /XObject << /Fm1 5 0 R >> >>", "q 2 0 0 2 0 0 cm /Fm1 Do Q",
+				streamObject("/Type /XObject /Subtype /Form /Matrix [1 0 0 1 50 0] /Resources << /Font << /F1 6 0 R >> >>",
+					"BT /F1 10 Tf 0 300 Td (x) Tj ET"),
+				helvetica),
+			opts:     Options{DPI: 72},
+			wantText: "x ",
+			wantBox:  &ocr.BoundingBox{X1: 100, Y1: 177, X2: 110, Y2: 197},
+		},
+		"scanned": {
+			data:      buildPDF("", "<< >>", "q 612 0 0 792 0 0 cm BI /W 1 /H 1 /CS /G /BPC 8 ID \x00 EI Q"),
+			wantEmpty: []int{0},
+		},
+		"damaged xref": {
+			data: bytes.Replace(
+				buildPDF("", "<< /Font << /F1 5 0 R >> >>", "BT /F1 10 Tf 0 700 Td (ok) Tj ET", helvetica),
+				[]byte("startxref\n"), []byte("startxref\n1"), 1),
+			opts:     Options{DPI: 72},
+			wantText: "ok ",
+			wantBox:  &ocr.BoundingBox{X1: 0, Y1: 85, X2: 6, Y2: 95},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, empty, err := UnmarshalWithOptions(tt.data, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantText, eocr.Text(doc))
+			assert.Equal(t, tt.wantEmpty, empty)
+			if tt.wantBox != nil {
+				assert.Equal(t, tt.wantBox, doc.Characters[0].BoundingBox)
+				assert.Empty(t, eocr.Validate(doc))
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := map[string]struct {
+		data    []byte
+		wantErr string
+	}{
+		"not a pdf": {
+			data:    []byte("<html></html>"),
+			wantErr: "pdf: missing %PDF header",
+		},
+		"encrypted": {
+			data:    []byte(strings.Replace(string(buildPDF("", "<< >>", "")), "/Root 1 0 R", "/Root 1 0 R /Encrypt << >>", 1)),
+			wantErr: ErrEncrypted.Error(),
+		},
+		"no pages": {
+			data:    []byte(strings.Replace(string(buildPDF("", "<< >>", "")), "/Kids [3 0 R]", "/Kids []", 1)),
+			wantErr: ErrNoPages.Error(),
+		},
+		"kid not a dictionary": {
+			data:    []byte(strings.Replace(string(buildPDF("", "<< >>", "")), "/Kids [3 0 R]", "/Kids [[1 2]]", 1)),
+			wantErr: "pdf: page tree node is not a dictionary",
+		},
+		"cyclic page tree": {
+			data:    []byte(strings.Replace(string(buildPDF("", "<< >>", "")), "/Kids [3 0 R]", "/Kids [2 0 R]", 1)),
+			wantErr: "pdf: page tree is too deep or cyclic",
+		},
+		"unsupported filter": {
+			data:    []byte(strings.Replace(string(buildPDF("", "<< >>", "")), "<<  /Length", "<< /Filter /JBIG2Decode /Length", 1)),
+			wantErr: "pdf: unsupported filter JBIG2Decode",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Unmarshal(tt.data)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
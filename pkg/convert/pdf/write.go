@@ -0,0 +1,464 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // Page images can be JPEG files.
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/zuvaai/eocr-utils/internal/layout"
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// pointsPerInch is the resolution of PDF user space.
+const pointsPerInch = 72
+
+// standardFonts are the standard fonts used for the characters of
+// WinAnsiEncoding, which PDF readers have without embedding them, by family
+// and then by style.
+var standardFonts = [3][4]string{
+	{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique"},
+	{"Times-Roman", "Times-Bold", "Times-Italic", "Times-BoldItalic"},
+	{"Courier", "Courier-Bold", "Courier-Oblique", "Courier-BoldOblique"},
+}
+
+// The families of standardFonts.
+const (
+	familySans = iota
+	familySerif
+	familyMono
+)
+
+// fallbackSize is the number of characters of each Type 3 font used for
+// characters outside WinAnsiEncoding.
+const fallbackSize = 256
+
+// Marshal returns doc as a PDF file with the default options.
+func Marshal(doc *ocr.Document) ([]byte, error) {
+	return MarshalWithOptions(doc, Options{})
+}
+
+// MarshalWithOptions returns doc as a PDF file. See WriteWithOptions.
+func MarshalWithOptions(doc *ocr.Document, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := WriteWithOptions(&buf, doc, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write writes doc to w as a PDF file with the default options.
+func Write(w io.Writer, doc *ocr.Document) error {
+	return WriteWithOptions(w, doc, Options{})
+}
+
+// WriteWithOptions writes doc to w as a searchable PDF file.
+//
+// Each page becomes a PDF page of the size of the page at its resolution, or
+// at 300 DPI if it has none. Each character is written at its bounding box,
+// at the size of its font size span, or the height of its box if it has
+// none, and stretched to the width of the box. Text is drawn unless
+// opts.InvisibleText is set. Characters of WinAnsiEncoding use the standard
+// Helvetica, Times or Courier font that is closest to their font and style,
+// and other characters use fonts that draw them as an empty box but map them
+// back to their text.
+//
+// With opts.ImageDir, the JPEG and PNG files of the directory are drawn under
+// the text of their pages, stretched to the page. The page of an image is
+// the number at the end of its file name, without its extension, numbered
+// from 1, as in page-1.png or scan_003.jpg.
+func WriteWithOptions(w io.Writer, doc *ocr.Document, opts Options) error {
+	var images map[int]string
+	if opts.ImageDir != "" {
+		var err error
+		if images, err = pageImages(opts.ImageDir); err != nil {
+			return err
+		}
+	}
+	pw := &writer{
+		opts:          opts,
+		attrs:         layout.NewAttrs(doc),
+		fallbackCodes: map[rune]int{},
+	}
+	catalog, pages, fonts := pw.alloc(), pw.alloc(), pw.alloc()
+	pw.set(catalog, "<< /Type /Catalog /Pages %d 0 R >>", pages)
+
+	var kids []string
+	for i, p := range doc.Pages {
+		page, err := pw.page(doc, i, p, pages, fonts, images[i+1])
+		if err != nil {
+			return err
+		}
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	pw.set(pages, "<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+	pw.fonts(fonts)
+	return pw.write(w)
+}
+
+// writer holds the objects of a PDF file as they are written.
+type writer struct {
+	opts  Options
+	attrs *layout.Attrs
+	// objects holds the objects by number, from 1.
+	objects [][]byte
+	// standard holds the object numbers of the standard fonts used.
+	standard [len(standardFonts) * 4]int
+	// fallbackCodes maps the characters of the fallback fonts to their
+	// index in fallbackRunes.
+	fallbackCodes map[rune]int
+	fallbackRunes []rune
+}
+
+// alloc returns the number of a new object.
+func (pw *writer) alloc() int {
+	pw.objects = append(pw.objects, nil)
+	return len(pw.objects)
+}
+
+func (pw *writer) set(num int, format string, args ...interface{}) {
+	pw.objects[num-1] = []byte(fmt.Sprintf(format, args...))
+}
+
+// stream sets a stream object with the given dictionary entries.
+func (pw *writer) stream(num int, entries string, data []byte) {
+	entries = strings.TrimSpace(fmt.Sprintf("%s /Length %d", entries, len(data)))
+	pw.objects[num-1] = []byte(fmt.Sprintf("<< %s >>\nstream\n%s\nendstream", entries, data))
+}
+
+// flateStream sets a stream object compressed with the Flate filter.
+func (pw *writer) flateStream(num int, entries string, data []byte) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	pw.stream(num, strings.TrimSpace(entries+" /Filter /FlateDecode"), buf.Bytes())
+}
+
+// page writes the page with index i and returns its object number.
+func (pw *writer) page(doc *ocr.Document, i int, p *ocr.Page, parent, fonts int, imagePath string) (int, error) {
+	m, err := eocr.PageTextWithMapping(doc, i)
+	if err != nil {
+		return 0, fmt.Errorf("pdf: %w", err)
+	}
+	dpiX, dpiY := float64(p.GetDpiX()), float64(p.GetDpiY())
+	if dpiX == 0 {
+		dpiX = defaultDPI
+	}
+	if dpiY == 0 {
+		dpiY = defaultDPI
+	}
+	width := float64(p.GetWidth()) * pointsPerInch / dpiX
+	height := float64(p.GetHeight()) * pointsPerInch / dpiY
+
+	var content bytes.Buffer
+	xobjects := ""
+	if imagePath != "" {
+		img, err := pw.image(imagePath)
+		if err != nil {
+			return 0, err
+		}
+		xobjects = fmt.Sprintf(" /XObject << /Im1 %d 0 R >>", img)
+		fmt.Fprintf(&content, "q %s 0 0 %s 0 0 cm /Im1 Do Q\n", num(width), num(height))
+	}
+
+	content.WriteString("BT\n")
+	if pw.opts.InvisibleText {
+		content.WriteString("3 Tr\n")
+	}
+	var font string
+	var size float64
+	runes := []rune(m.Text)
+	for n, r := range runes {
+		first, _ := m.RuneToCharacter(n)
+		next, _ := m.RuneToCharacter(n + 1)
+		var box *ocr.BoundingBox
+		for _, c := range doc.Characters[first:next] {
+			if c != nil {
+				box = layout.Union(box, c.BoundingBox)
+			}
+		}
+		if box == nil || unicode.IsControl(r) {
+			continue
+		}
+		boxWidth := float64(box.X2-minUint32(box.X1, box.X2)) * pointsPerInch / dpiX
+		boxHeight := float64(box.Y2-minUint32(box.Y1, box.Y2)) * pointsPerInch / dpiY
+		s := float64(pw.attrs.Size(first))
+		if s == 0 {
+			s = boxHeight
+		}
+		if s <= 0 {
+			continue
+		}
+		f, code, advance := pw.glyph(r, first)
+		if f != font || s != size {
+			font, size = f, s
+			fmt.Fprintf(&content, "/%s %s Tf\n", font, num(size))
+		}
+		scale := 1.0
+		if advance > 0 && boxWidth > 0 {
+			scale = boxWidth / (advance * size)
+		}
+		x := float64(box.X1) * pointsPerInch / dpiX
+		y := height - float64(box.Y1)*pointsPerInch/dpiY - layout.Ascent*boxHeight
+		fmt.Fprintf(&content, "%s 0 0 1 %s %s Tm <%02x> Tj\n", num(scale), num(x), num(y), code)
+	}
+	content.WriteString("ET\n")
+
+	contents, pageNum := pw.alloc(), pw.alloc()
+	pw.flateStream(contents, "", content.Bytes())
+	pw.set(pageNum, "<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font %d 0 R%s >> /Contents %d 0 R >>",
+		parent, num(width), num(height), fonts, xobjects, contents)
+	return pageNum, nil
+}
+
+// glyph returns the resource name of the font of the character at index i
+// with rune r, its code and its advance in ems.
+func (pw *writer) glyph(r rune, i int) (string, byte, float64) {
+	if code, ok := winAnsiCodes[r]; ok {
+		family := familySans
+		if f := pw.attrs.Font(i); f != nil {
+			switch {
+			case f.Monospace:
+				family = familyMono
+			case f.Serif:
+				family = familySerif
+			}
+		}
+		style := 0
+		if pw.attrs.Has(i, ocr.BOLD) {
+			style |= 1
+		}
+		if pw.attrs.Has(i, ocr.ITALIC) {
+			style |= 2
+		}
+		index := family*4 + style
+		if pw.standard[index] == 0 {
+			pw.standard[index] = pw.alloc()
+			pw.set(pw.standard[index], "<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>",
+				standardFonts[family][style])
+		}
+		advance := defaultWidth / 1000.0
+		switch {
+		case family == familyMono:
+			advance = 0.6
+		case family == familySerif && timesWidths[r] > 0:
+			advance = timesWidths[r] / 1000
+		case family == familySans && helveticaWidths[r] > 0:
+			advance = helveticaWidths[r] / 1000
+		}
+		return "S" + strconv.Itoa(index), code, advance
+	}
+	n, ok := pw.fallbackCodes[r]
+	if !ok {
+		n = len(pw.fallbackRunes)
+		pw.fallbackCodes[r] = n
+		pw.fallbackRunes = append(pw.fallbackRunes, r)
+	}
+	return "U" + strconv.Itoa(n/fallbackSize), byte(n % fallbackSize), 1
+}
+
+// fonts writes the font resources of the pages as object obj.
+func (pw *writer) fonts(obj int) {
+	var entries []string
+	for i, f := range pw.standard {
+		if f != 0 {
+			entries = append(entries, fmt.Sprintf("/S%d %d 0 R", i, f))
+		}
+	}
+	if len(pw.fallbackRunes) > 0 {
+		// Every glyph of the fallback fonts is an empty box, or nothing for
+		// invisible text.
+		proc := pw.alloc()
+		if pw.opts.InvisibleText {
+			pw.stream(proc, "", []byte("1000 0 d0"))
+		} else {
+			pw.stream(proc, "", []byte("1000 0 0 0 1000 750 d1 50 w 100 25 800 700 re S"))
+		}
+		for n := 0; n*fallbackSize < len(pw.fallbackRunes); n++ {
+			runes := pw.fallbackRunes[n*fallbackSize:]
+			if len(runes) > fallbackSize {
+				runes = runes[:fallbackSize]
+			}
+			font, toUnicode := pw.alloc(), pw.alloc()
+			var procs, names, widths []string
+			for c := range runes {
+				procs = append(procs, fmt.Sprintf("/g%d %d 0 R", c, proc))
+				names = append(names, fmt.Sprintf("/g%d", c))
+				widths = append(widths, "1000")
+			}
+			pw.set(font, "<< /Type /Font /Subtype /Type3 /FontBBox [0 0 1000 750] /FontMatrix [0.001 0 0 0.001 0 0] "+
+				"/CharProcs << %s >> /Encoding << /Type /Encoding /Differences [0 %s] >> "+
+				"/FirstChar 0 /LastChar %d /Widths [%s] /ToUnicode %d 0 R /Resources << >> >>",
+				strings.Join(procs, " "), strings.Join(names, " "), len(runes)-1, strings.Join(widths, " "), toUnicode)
+			pw.flateStream(toUnicode, "", toUnicodeCMap(runes))
+			entries = append(entries, fmt.Sprintf("/U%d %d 0 R", n, font))
+		}
+	}
+	pw.set(obj, "<< %s >>", strings.Join(entries, " "))
+}
+
+// toUnicodeCMap returns a ToUnicode CMap mapping the code of each rune of a
+// fallback font, its index, to the rune.
+func toUnicodeCMap(runes []rune) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<00> <ff>\nendcodespacerange\n")
+	// A bfchar section holds at most 100 mappings.
+	for start := 0; start < len(runes); start += 100 {
+		end := start + 100
+		if end > len(runes) {
+			end = len(runes)
+		}
+		fmt.Fprintf(&buf, "%d beginbfchar\n", end-start)
+		for c := start; c < end; c++ {
+			fmt.Fprintf(&buf, "<%02x> <", c)
+			for _, u := range utf16.Encode([]rune{runes[c]}) {
+				fmt.Fprintf(&buf, "%04x", u)
+			}
+			buf.WriteString(">\n")
+		}
+		buf.WriteString("endbfchar\n")
+	}
+	buf.WriteString("endcmap\nCMapName currentdict /CMapResource defineresource pop\nend\nend\n")
+	return buf.Bytes()
+}
+
+// image writes a page image and returns its object number. JPEG files are
+// copied as they are, and PNG files are drawn on white to remove their
+// transparency.
+func (pw *writer) image(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("pdf: %w", err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("pdf: %s: %w", path, err)
+	}
+	obj := pw.alloc()
+	if format == "jpeg" {
+		colorSpace := "/DeviceRGB"
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			colorSpace = "/DeviceGray"
+		case color.CMYKModel:
+			colorSpace = "/DeviceCMYK"
+		}
+		pw.stream(obj, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode",
+			cfg.Width, cfg.Height, colorSpace), data)
+		return obj, nil
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("pdf: %s: %w", path, err)
+	}
+	bounds := img.Bounds()
+	var pixels []byte
+	colorSpace := "/DeviceRGB"
+	if gray, ok := img.(*image.Gray); ok {
+		colorSpace = "/DeviceGray"
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			i := gray.PixOffset(bounds.Min.X, y)
+			pixels = append(pixels, gray.Pix[i:i+bounds.Dx()]...)
+		}
+	} else {
+		rgba := image.NewRGBA(bounds)
+		draw.Draw(rgba, bounds, image.White, image.Point{}, draw.Src)
+		draw.Draw(rgba, bounds, img, bounds.Min, draw.Over)
+		pixels = make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+		for i := 0; i < len(rgba.Pix); i += 4 {
+			pixels = append(pixels, rgba.Pix[i:i+3]...)
+		}
+	}
+	pw.flateStream(obj, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8",
+		bounds.Dx(), bounds.Dy(), colorSpace), pixels)
+	return obj, nil
+}
+
+// write writes the objects, the cross-reference table and the trailer.
+func (pw *writer) write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	// The comment of binary characters marks the file as binary.
+	header := "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n"
+	bw.WriteString(header)
+	offset := len(header)
+	offsets := make([]int, len(pw.objects))
+	for i, obj := range pw.objects {
+		offsets[i] = offset
+		n, _ := fmt.Fprintf(bw, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+		offset += n
+	}
+	fmt.Fprintf(bw, "xref\n0 %d\n0000000000 65535 f \n", len(pw.objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(bw, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(bw, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.objects)+1, offset)
+	return bw.Flush()
+}
+
+// pageImages returns the JPEG and PNG files of dir by the page number at the
+// end of their names.
+func pageImages(dir string) (map[int]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("pdf: %w", err)
+	}
+	images := map[int]string{}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		switch strings.ToLower(ext) {
+		case ".jpg", ".jpeg", ".png":
+		default:
+			continue
+		}
+		if e.IsDir() {
+			continue
+		}
+		stem := strings.TrimSuffix(e.Name(), ext)
+		digits := stem[len(strings.TrimRight(stem, "0123456789")):]
+		page, err := strconv.Atoi(digits)
+		if err != nil || page == 0 {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if other, ok := images[page]; ok {
+			return nil, fmt.Errorf("pdf: %s and %s are both images of page %d", other, path, page)
+		}
+		images[page] = path
+	}
+	return images, nil
+}
+
+// num formats a number with at most three decimals.
+func num(v float64) string {
+	v = math.Round(v*1000) / 1000
+	if v == 0 {
+		// Not -0.
+		v = 0
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
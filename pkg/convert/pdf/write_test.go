@@ -0,0 +1,132 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/convert/docx"
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	doc, err := docx.ReadFile("../../../testdata/contract.docx")
+	require.NoError(t, err)
+	// A character outside WinAnsiEncoding.
+	doc.Characters[18].Unicode = 'ħ'
+
+	for _, opts := range []Options{{}, {InvisibleText: true}} {
+		data, err := MarshalWithOptions(doc, opts)
+		require.NoError(t, err)
+		got, empty, err := Unmarshal(data)
+		require.NoError(t, err)
+
+		assert.Empty(t, empty)
+		assert.Equal(t, strings.Fields(eocr.Text(doc)), strings.Fields(eocr.Text(got)))
+		assert.Equal(t, doc.Pages[1].Width, got.Pages[1].Width)
+		assert.Equal(t, doc.Pages[1].Height, got.Pages[1].Height)
+		// Glyphs are stretched to the width of their boxes.
+		for _, i := range []int{0, 18} {
+			want, box := doc.Characters[i].BoundingBox, got.Characters[i].BoundingBox
+			assert.InDelta(t, want.X1, box.X1, 1)
+			assert.InDelta(t, want.X2, box.X2, 1)
+			assert.InDelta(t, want.Y1, box.Y1, 5)
+			assert.InDelta(t, want.Y2, box.Y2, 5)
+		}
+		assert.Equal(t, uint32(16), got.FontSizes[0].Size_)
+		assert.Equal(t, []*ocr.FontStyle{{CharacterSpan: &ocr.Span{Start: 0, End: 17}, Style: ocr.BOLD}}, got.FontStyles[:1])
+	}
+}
+
+// pageContents returns the content stream of each page of a PDF file.
+func pageContents(t *testing.T, data []byte) []string {
+	t.Helper()
+	f, err := newFile(data)
+	require.NoError(t, err)
+	pages, err := f.pages()
+	require.NoError(t, err)
+	var contents []string
+	for _, p := range pages {
+		c, err := f.contents(p)
+		require.NoError(t, err)
+		contents = append(contents, string(c))
+	}
+	return contents
+}
+
+func TestMarshalPageImages(t *testing.T) {
+	doc, err := eocr.NewDocumentFromText("foo\nbar", 80, 1)
+	require.NoError(t, err)
+	doc.Pages[0].DpiX, doc.Pages[0].DpiY = 0, 0
+
+	dir := t.TempDir()
+	gray := image.NewGray(image.Rect(0, 0, 4, 2))
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, gray))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "scan-001.png"), buf.Bytes(), 0o644))
+	buf.Reset()
+	rgb := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	rgb.Set(0, 0, color.RGBA{R: 0xff, A: 0xff})
+	require.NoError(t, jpeg.Encode(&buf, rgb, nil))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "scan-002.JPG"), buf.Bytes(), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644))
+
+	data, err := MarshalWithOptions(doc, Options{InvisibleText: true, ImageDir: dir})
+	require.NoError(t, err)
+	out := string(data)
+	assert.Contains(t, out, "/Subtype /Image /Width 4 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode")
+	assert.Contains(t, out, "/Subtype /Image /Width 3 /Height 3 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode")
+
+	contents := pageContents(t, data)
+	require.Len(t, contents, 2)
+	for _, c := range contents {
+		// Pages without a resolution are at 300 DPI.
+		assert.True(t, strings.HasPrefix(c, "q "+num(float64(doc.Pages[1].Width)*72/300)+" 0 0 "), c)
+		assert.Contains(t, c, "/Im1 Do Q\nBT\n3 Tr\n/S0 ")
+	}
+
+	got, _, err := Unmarshal(data)
+	require.NoError(t, err)
+	assert.Equal(t, "foo bar ", eocr.Text(got))
+}
+
+func TestMarshalErrors(t *testing.T) {
+	doc, err := eocr.NewDocumentFromText("foo")
+	require.NoError(t, err)
+	duplicate := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(duplicate, "1.png"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(duplicate, "page01.jpg"), nil, 0o644))
+	invalid := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(invalid, "1.png"), []byte("not a png"), 0o644))
+
+	tests := map[string]struct {
+		opts    Options
+		wantErr string
+	}{
+		"duplicate images": {
+			opts:    Options{ImageDir: duplicate},
+			wantErr: "pdf: " + filepath.Join(duplicate, "1.png") + " and " + filepath.Join(duplicate, "page01.jpg") + " are both images of page 1",
+		},
+		"invalid image": {
+			opts:    Options{ImageDir: invalid},
+			wantErr: "pdf: " + filepath.Join(invalid, "1.png") + ": image: unknown format",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := MarshalWithOptions(doc, tt.opts)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
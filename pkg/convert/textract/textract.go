@@ -0,0 +1,318 @@
+// Package textract converts saved AWS Textract AnalyzeDocument and
+// DetectDocumentText responses to ocr.Document. It makes no network calls.
+//
+// See https://docs.aws.amazon.com/textract/latest/dg/how-it-works-document-layout.html
+// for the format.
+package textract
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/zuvaai/eocr-utils/internal/builder"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Default page size, US letter at 300 DPI.
+const (
+	defaultDPI    = 300
+	defaultWidth  = 2550
+	defaultHeight = 3300
+)
+
+// ErrNoPages means that the response has no blocks.
+var ErrNoPages = errors.New("textract: no blocks")
+
+// Options configures the conversion of documents.
+type Options struct {
+	// Width and Height are the size of the pages in pixels. Textract gives
+	// positions relative to the page, so the size of the pages must be
+	// known to convert them. Zero means US letter at DPI.
+	Width, Height uint32
+	// DPI is the resolution of the pages. Zero means 300.
+	DPI uint32
+}
+
+// ReadFile reads a file holding a Textract response.
+func ReadFile(filename string) (*ocr.Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Read reads a Textract response from r.
+func Read(r io.Reader) (*ocr.Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal converts a Textract response to an ocr.Document with the default
+// options.
+func Unmarshal(data []byte) (*ocr.Document, error) {
+	return UnmarshalWithOptions(data, Options{})
+}
+
+// UnmarshalWithOptions converts a Textract response to an ocr.Document.
+//
+// data holds a response, or an array of the responses of a paginated
+// GetDocumentAnalysis job. Pages are numbered by the Page of their blocks, or
+// are all the first page when it is missing. The WORD children of each LINE
+// become characters separated by spaces, in the order the PAGE block lists
+// the lines, or the order of the blocks when there is no PAGE block. Boxes
+// are the bounds of the Polygon of a word, scaled to the page size of opts
+// and divided evenly between its characters. The error of a character is 100
+// minus the confidence of its word. Each TABLE becomes a table with a cell
+// for each CELL block, except that the cells of a MERGED_CELL are replaced by
+// a single cell. Other blocks, such as KEY_VALUE_SET, are ignored.
+//
+// The md5 of the document is the md5 of data.
+func UnmarshalWithOptions(data []byte, opts Options) (*ocr.Document, error) {
+	if opts.DPI == 0 {
+		opts.DPI = defaultDPI
+	}
+	if opts.Width == 0 || opts.Height == 0 {
+		opts.Width, opts.Height = opts.DPI*defaultWidth/defaultDPI, opts.DPI*defaultHeight/defaultDPI
+	}
+	blocks, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return nil, ErrNoPages
+	}
+	c := &converter{opts: opts, b: builder.New(), byID: map[string]*block{}, byPage: map[int][]*block{}}
+	for _, bl := range blocks {
+		if bl.Page == 0 {
+			bl.Page = 1
+		}
+		if bl.Page > c.pages {
+			c.pages = bl.Page
+		}
+		c.byID[bl.ID] = bl
+		c.byPage[bl.Page] = append(c.byPage[bl.Page], bl)
+	}
+	for page := 1; page <= c.pages; page++ {
+		if err := c.page(page); err != nil {
+			return nil, err
+		}
+	}
+	sum := md5.Sum(data)
+	return c.b.Document(sum[:], ""), nil
+}
+
+type response struct {
+	Blocks []*block `json:"Blocks"`
+}
+
+type block struct {
+	BlockType     string         `json:"BlockType"`
+	ID            string         `json:"Id"`
+	Page          int            `json:"Page"`
+	Text          string         `json:"Text"`
+	Confidence    float64        `json:"Confidence"`
+	Geometry      geometry       `json:"Geometry"`
+	Relationships []relationship `json:"Relationships"`
+}
+
+type geometry struct {
+	BoundingBox *struct {
+		Width  float64 `json:"Width"`
+		Height float64 `json:"Height"`
+		Left   float64 `json:"Left"`
+		Top    float64 `json:"Top"`
+	} `json:"BoundingBox"`
+	Polygon []struct {
+		X float64 `json:"X"`
+		Y float64 `json:"Y"`
+	} `json:"Polygon"`
+}
+
+type relationship struct {
+	Type string   `json:"Type"`
+	IDs  []string `json:"Ids"`
+}
+
+// parse returns the blocks of a response or an array of responses.
+func parse(data []byte) ([]*block, error) {
+	var responses []response
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(data, &responses); err != nil {
+			return nil, fmt.Errorf("textract: %w", err)
+		}
+	} else {
+		var r response
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("textract: %w", err)
+		}
+		responses = append(responses, r)
+	}
+	var blocks []*block
+	for _, r := range responses {
+		blocks = append(blocks, r.Blocks...)
+	}
+	return blocks, nil
+}
+
+type converter struct {
+	opts Options
+	b    *builder.Builder
+	byID map[string]*block
+	// byPage holds the blocks of each page, in order.
+	byPage map[int][]*block
+	pages  int
+}
+
+// children returns the blocks related to bl by relationships of type typ.
+func (c *converter) children(bl *block, typ string) ([]*block, error) {
+	var children []*block
+	for _, r := range bl.Relationships {
+		if r.Type != typ {
+			continue
+		}
+		for _, id := range r.IDs {
+			child := c.byID[id]
+			if child == nil {
+				return nil, fmt.Errorf("textract: %s block %q has missing %s block %q", bl.BlockType, bl.ID, typ, id)
+			}
+			children = append(children, child)
+		}
+	}
+	return children, nil
+}
+
+func (c *converter) page(page int) error {
+	c.b.Page(c.opts.Width, c.opts.Height, c.opts.DPI, c.opts.DPI)
+	var lines []*block
+	var hasPage bool
+	blocks := c.byPage[page]
+	for _, bl := range blocks {
+		if bl.BlockType != "PAGE" {
+			continue
+		}
+		hasPage = true
+		children, err := c.children(bl, "CHILD")
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if child.BlockType == "LINE" {
+				lines = append(lines, child)
+			}
+		}
+	}
+	if !hasPage {
+		for _, bl := range blocks {
+			if bl.BlockType == "LINE" {
+				lines = append(lines, bl)
+			}
+		}
+	}
+	for _, line := range lines {
+		words, err := c.children(line, "CHILD")
+		if err != nil {
+			return err
+		}
+		for _, w := range words {
+			if w.BlockType != "WORD" {
+				continue
+			}
+			c.b.Word(builder.Split(w.Text, c.box(w.Geometry), builder.ErrorFromConfidence(w.Confidence)), builder.Style{})
+		}
+		c.b.EndLine()
+	}
+	for _, bl := range blocks {
+		if bl.BlockType == "TABLE" {
+			if err := c.table(bl); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// table adds a table and its cells. A merged cell takes the place of the
+// first of the cells it merges.
+func (c *converter) table(bl *block) error {
+	id := c.b.Table(uint32(bl.Page - 1))
+	cells, err := c.children(bl, "CHILD")
+	if err != nil {
+		return err
+	}
+	merged, err := c.children(bl, "MERGED_CELL")
+	if err != nil {
+		return err
+	}
+	mergedInto := map[*block]*block{}
+	for _, m := range merged {
+		parts, err := c.children(m, "CHILD")
+		if err != nil {
+			return err
+		}
+		for _, p := range parts {
+			mergedInto[p] = m
+		}
+	}
+	added := map[*block]bool{}
+	for _, cell := range cells {
+		if cell.BlockType != "CELL" {
+			continue
+		}
+		if m := mergedInto[cell]; m != nil {
+			cell = m
+		}
+		if added[cell] {
+			continue
+		}
+		added[cell] = true
+		c.b.Cell(id, c.box(cell.Geometry))
+	}
+	return nil
+}
+
+// box converts a geometry to pixels. The bounds of the polygon are used when
+// there is one, since the bounding box of rotated text is less precise.
+func (c *converter) box(g geometry) *ocr.BoundingBox {
+	var x1, y1, x2, y2 float64
+	switch {
+	case len(g.Polygon) > 0:
+		x1, y1, x2, y2 = g.Polygon[0].X, g.Polygon[0].Y, g.Polygon[0].X, g.Polygon[0].Y
+		for _, p := range g.Polygon[1:] {
+			x1, y1 = math.Min(x1, p.X), math.Min(y1, p.Y)
+			x2, y2 = math.Max(x2, p.X), math.Max(y2, p.Y)
+		}
+	case g.BoundingBox != nil:
+		bb := g.BoundingBox
+		x1, y1, x2, y2 = bb.Left, bb.Top, bb.Left+bb.Width, bb.Top+bb.Height
+	default:
+		return nil
+	}
+	return &ocr.BoundingBox{
+		X1: scale(x1, c.opts.Width),
+		Y1: scale(y1, c.opts.Height),
+		X2: scale(x2, c.opts.Width),
+		Y2: scale(y2, c.opts.Height),
+	}
+}
+
+// scale converts a position relative to the page to pixels, clamping it to
+// the page.
+func scale(v float64, size uint32) uint32 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return size
+	}
+	return uint32(math.Round(v * float64(size)))
+}
@@ -0,0 +1,119 @@
+package textract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestReadFile(t *testing.T) {
+	doc, err := ReadFile("../../../testdata/textract.json")
+	require.NoError(t, err)
+
+	// Lines follow the order of the PAGE block, not of the LINE blocks.
+	assert.Equal(t, "Title Supply Agreement Price $10 Signed ", eocr.Text(doc))
+	assert.Equal(t, []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 33}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+		{CharacterSpan: &ocr.Span{Start: 33, End: 40}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+	}, doc.Pages)
+	// Words without a polygon use their bounding box.
+	assert.Equal(t, &ocr.Character{Unicode: 'T', Error: 10, BoundingBox: &ocr.BoundingBox{X1: 255, Y1: 165, X2: 306, Y2: 231}}, doc.Characters[0])
+	assert.Equal(t, &ocr.Character{Unicode: 'S', Error: 1, BoundingBox: &ocr.BoundingBox{X1: 255, Y1: 330, X2: 306, Y2: 396}}, doc.Characters[6])
+	assert.Equal(t, &ocr.Character{Unicode: 'A', Error: 5, BoundingBox: &ocr.BoundingBox{X1: 612, Y1: 330, X2: 663, Y2: 396}}, doc.Characters[13])
+
+	assert.Equal(t, []*ocr.Table{{Id: 0, PageNumber: 0}}, doc.Tables)
+	assert.Equal(t, []*ocr.TableCell{
+		{Id: 0, BoundingBox: &ocr.BoundingBox{X1: 255, Y1: 990, X2: 765, Y2: 1320}},
+		{Id: 0, BoundingBox: &ocr.BoundingBox{X1: 765, Y1: 990, X2: 1785, Y2: 1320}},
+		// The merged cell replaces the cells of the second row.
+		{Id: 0, BoundingBox: &ocr.BoundingBox{X1: 255, Y1: 1320, X2: 1785, Y2: 1650}},
+	}, doc.TableCells)
+
+	assert.Empty(t, eocr.Validate(doc))
+}
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		data      string
+		opts      Options
+		wantText  string
+		wantPages []*ocr.Page
+		wantBox   *ocr.BoundingBox
+	}{
+		"page size": {
+			data: `{"Blocks": [
+{"BlockType": "LINE", "Id": "l", "Relationships": [{"Type": "CHILD", "Ids": ["w"]}]},
+{"BlockType": "WORD", "Id": "w", "Text": "a", "Confidence": 100, "Geometry": {"BoundingBox": {"Left": 0.5, "Top": 0.25, "Width": 0.1, "Height": 0.5}}}
+]}`,
+			opts:      Options{Width: 1000, Height: 2000, DPI: 100},
+			wantText:  "a ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Width: 1000, Height: 2000, DpiX: 100, DpiY: 100}},
+			wantBox:   &ocr.BoundingBox{X1: 500, Y1: 500, X2: 600, Y2: 1500},
+		},
+		"letter at dpi": {
+			data: `{"Blocks": [
+{"BlockType": "LINE", "Id": "l", "Relationships": [{"Type": "CHILD", "Ids": ["w"]}]},
+{"BlockType": "WORD", "Id": "w", "Text": "a", "Confidence": 100, "Geometry": {"BoundingBox": {"Left": -0.1, "Top": 0.5, "Width": 0.2, "Height": 0.7}}}
+]}`,
+			opts:      Options{DPI: 200},
+			wantText:  "a ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Width: 1700, Height: 2200, DpiX: 200, DpiY: 200}},
+			wantBox:   &ocr.BoundingBox{X1: 0, Y1: 1100, X2: 170, Y2: 2200},
+		},
+		"paginated responses": {
+			data: `[{"Blocks": [
+{"BlockType": "LINE", "Id": "l1", "Page": 1, "Relationships": [{"Type": "CHILD", "Ids": ["w1"]}]},
+{"BlockType": "WORD", "Id": "w1", "Page": 1, "Text": "a", "Confidence": 100}
+]}, {"Blocks": [
+{"BlockType": "LINE", "Id": "l2", "Page": 2, "Relationships": [{"Type": "CHILD", "Ids": ["w2"]}]},
+{"BlockType": "WORD", "Id": "w2", "Page": 2, "Text": "b", "Confidence": 100}
+]}]`,
+			wantText: "a b ",
+			wantPages: []*ocr.Page{
+				{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+				{CharacterSpan: &ocr.Span{Start: 2, End: 4}, Width: 2550, Height: 3300, DpiX: 300, DpiY: 300},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := UnmarshalWithOptions([]byte(tt.data), tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantText, eocr.Text(doc))
+			assert.Equal(t, tt.wantPages, doc.Pages)
+			assert.Equal(t, tt.wantBox, doc.Characters[0].BoundingBox)
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"no blocks": {
+			data:    `{"Blocks": []}`,
+			wantErr: ErrNoPages.Error(),
+		},
+		"missing block": {
+			data:    `{"Blocks": [{"BlockType": "LINE", "Id": "l", "Relationships": [{"Type": "CHILD", "Ids": ["w"]}]}]}`,
+			wantErr: `textract: LINE block "l" has missing CHILD block "w"`,
+		},
+		"malformed": {
+			data:    `{"Blocks": [`,
+			wantErr: "textract: unexpected end of JSON input",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.data))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package tsv converts the TSV output of Tesseract to ocr.Document.
+//
+// Tesseract writes one row for each page, block, paragraph, line and word it
+// recognizes, with the columns level, page_num, block_num, par_num, line_num,
+// word_num, left, top, width, height, conf and text.
+package tsv
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/zuvaai/eocr-utils/internal/builder"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// ErrNoPages means that the input has no page rows.
+var ErrNoPages = errors.New("tsv: no page rows")
+
+// Levels of the rows.
+const (
+	levelPage      = 1
+	levelBlock     = 2
+	levelParagraph = 3
+	levelLine      = 4
+	levelWord      = 5
+)
+
+// columns are the columns of a row, in the order Tesseract writes them.
+var columns = []string{
+	"level", "page_num", "block_num", "par_num", "line_num", "word_num",
+	"left", "top", "width", "height", "conf", "text",
+}
+
+// Options configures the conversion of documents.
+type Options struct {
+	// DPI is the resolution given to the pages, which Tesseract doesn't
+	// record. Zero leaves it unknown.
+	DPI uint32
+}
+
+// ReadFile reads a Tesseract TSV file.
+func ReadFile(filename string) (*ocr.Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Read reads a Tesseract TSV document from r.
+func Read(r io.Reader) (*ocr.Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal converts a Tesseract TSV document to an ocr.Document with the
+// default options.
+func Unmarshal(data []byte) (*ocr.Document, error) {
+	return UnmarshalWithOptions(data, Options{})
+}
+
+// UnmarshalWithOptions converts a Tesseract TSV document to an ocr.Document.
+//
+// The first row may be a header naming the columns, in any order. Otherwise
+// the columns are expected in the order Tesseract writes them. Each level 1
+// row starts a page of its width and height. The words of each line become
+// characters separated by spaces, and every line ends with a space, which
+// also separates paragraphs. The box of a word is divided between its
+// characters in proportion to their estimated widths, and its conf becomes
+// their error. Words without text are skipped.
+//
+// The md5 of the document is the md5 of data.
+func UnmarshalWithOptions(data []byte, opts Options) (*ocr.Document, error) {
+	p := &parser{opts: opts, b: builder.New()}
+	if err := p.parse(data); err != nil {
+		return nil, err
+	}
+	if p.b.Pages() == 0 {
+		return nil, ErrNoPages
+	}
+	sum := md5.Sum(data)
+	return p.b.Document(sum[:], ""), nil
+}
+
+// row is a row of the TSV output.
+type row struct {
+	level, page, block, paragraph, line int
+	box                                 *ocr.BoundingBox
+	width, height                       uint32
+	conf                                float64
+	text                                string
+}
+
+// lineKey identifies the line of a word.
+type lineKey struct {
+	page, block, paragraph, line int
+}
+
+type parser struct {
+	opts Options
+	b    *builder.Builder
+	// index maps column names to their index in a row.
+	index map[string]int
+	// page is the page_num of the current page, or 0 before the first page.
+	page int
+	// line is the line of the last word.
+	line lineKey
+}
+
+func (p *parser) parse(data []byte) error {
+	p.index = map[string]int{}
+	for i, name := range columns {
+		p.index[name] = i
+	}
+	for n, line := range bytes.Split(data, []byte("\n")) {
+		fields := strings.Split(strings.TrimRight(string(line), "\r"), "\t")
+		if n == 0 && isHeader(fields) {
+			if err := p.header(fields); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(fields) == 1 && fields[0] == "" {
+			continue
+		}
+		r, err := p.row(fields)
+		if err != nil {
+			return fmt.Errorf("tsv: line %d: %w", n+1, err)
+		}
+		if err := p.add(r); err != nil {
+			return fmt.Errorf("tsv: line %d: %w", n+1, err)
+		}
+	}
+	return nil
+}
+
+// isHeader reports whether fields are the column names of a header row.
+func isHeader(fields []string) bool {
+	for _, f := range fields {
+		if f == "level" {
+			return true
+		}
+	}
+	return false
+}
+
+// header reads the column names of a header row.
+func (p *parser) header(fields []string) error {
+	p.index = map[string]int{}
+	for i, name := range fields {
+		p.index[name] = i
+	}
+	for _, name := range columns {
+		if _, ok := p.index[name]; !ok {
+			return fmt.Errorf("tsv: missing column %q", name)
+		}
+	}
+	return nil
+}
+
+func (p *parser) row(fields []string) (*row, error) {
+	field := func(name string) string {
+		if i := p.index[name]; i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+	var ints [10]int
+	for i, name := range columns[:10] {
+		v, err := strconv.Atoi(strings.TrimSpace(field(name)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", name, field(name))
+		}
+		ints[i] = v
+	}
+	conf, err := strconv.ParseFloat(strings.TrimSpace(field("conf")), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid conf %q", field("conf"))
+	}
+	left, top, width, height := ints[6], ints[7], ints[8], ints[9]
+	if left < 0 || top < 0 || width < 0 || height < 0 {
+		return nil, fmt.Errorf("negative box %d %d %d %d", left, top, width, height)
+	}
+	return &row{
+		level:     ints[0],
+		page:      ints[1],
+		block:     ints[2],
+		paragraph: ints[3],
+		line:      ints[4],
+		box: &ocr.BoundingBox{
+			X1: uint32(left),
+			Y1: uint32(top),
+			X2: uint32(left + width),
+			Y2: uint32(top + height),
+		},
+		width:  uint32(width),
+		height: uint32(height),
+		conf:   conf,
+		text:   field("text"),
+	}, nil
+}
+
+func (p *parser) add(r *row) error {
+	switch r.level {
+	case levelPage:
+		p.b.Page(r.width, r.height, p.opts.DPI, p.opts.DPI)
+		p.page = r.page
+	case levelBlock, levelParagraph, levelLine:
+	case levelWord:
+		if p.page == 0 || r.page != p.page {
+			return fmt.Errorf("word outside of page %d", r.page)
+		}
+		text := strings.TrimSpace(r.text)
+		if text == "" {
+			return nil
+		}
+		key := lineKey{page: r.page, block: r.block, paragraph: r.paragraph, line: r.line}
+		if key != p.line {
+			p.b.EndLine()
+			p.line = key
+		}
+		p.b.Word(builder.SplitProportional(text, r.box, confidenceError(r.conf)), builder.Style{})
+	default:
+		return fmt.Errorf("unknown level %d", r.level)
+	}
+	return nil
+}
+
+// confidenceError converts a confidence between 0 and 100 to an error.
+// Tesseract writes -1 for rows without a confidence, which is an error of 0.
+func confidenceError(conf float64) uint32 {
+	if conf < 0 || math.IsNaN(conf) {
+		return 0
+	}
+	return builder.ErrorFromConfidence(conf)
+}
@@ -0,0 +1,113 @@
+package tsv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestReadFile(t *testing.T) {
+	doc, err := ReadFile("../../../testdata/tesseract.tsv")
+	require.NoError(t, err)
+
+	assert.Equal(t, `Supply Agreement A&B "C" Signed `, eocr.Text(doc))
+	assert.Equal(t, []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 25}, Width: 2550, Height: 3300},
+		{CharacterSpan: &ocr.Span{Start: 25, End: 32}, Width: 1700, Height: 2200},
+	}, doc.Pages)
+	// Word boxes are divided by the estimated widths of their characters, so
+	// the S of Supply is wider than the u after it.
+	assert.Equal(t, &ocr.Character{Unicode: 'S', Error: 4, BoundingBox: &ocr.BoundingBox{X1: 300, Y1: 200, X2: 345, Y2: 250}}, doc.Characters[0])
+	assert.Equal(t, &ocr.Character{Unicode: 'u', Error: 4, BoundingBox: &ocr.BoundingBox{X1: 345, Y1: 200, X2: 379, Y2: 250}}, doc.Characters[1])
+	// The space between words spans the gap between them.
+	assert.Equal(t, &ocr.Character{Unicode: ' ', BoundingBox: &ocr.BoundingBox{X1: 500, Y1: 200, X2: 530, Y2: 250}}, doc.Characters[6])
+	// Lines and paragraphs end with a zero width space.
+	assert.Equal(t, &ocr.Character{Unicode: ' ', BoundingBox: &ocr.BoundingBox{X1: 850, Y1: 200, X2: 850, Y2: 250}}, doc.Characters[16])
+	assert.Equal(t, &ocr.Character{Unicode: '&', Error: 20, BoundingBox: &ocr.BoundingBox{X1: 336, Y1: 270, X2: 363, Y2: 320}}, doc.Characters[18])
+	// A conf of -1 is no error.
+	assert.Equal(t, &ocr.Character{Unicode: 'S', BoundingBox: &ocr.BoundingBox{X1: 100, Y1: 100, X2: 168, Y2: 140}}, doc.Characters[25])
+
+	assert.Empty(t, eocr.Validate(doc))
+}
+
+func TestUnmarshalWithOptions(t *testing.T) {
+	tests := map[string]struct {
+		data      string
+		opts      Options
+		wantText  string
+		wantPages []*ocr.Page
+	}{
+		"no header": {
+			data:      "1\t1\t0\t0\t0\t0\t0\t0\t100\t50\t-1\t\n5\t1\t1\t1\t1\t1\t10\t10\t20\t10\t90\tab\n",
+			wantText:  "ab ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Width: 100, Height: 50}},
+		},
+		"reordered header and crlf": {
+			data:      "text\tlevel\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\r\n\t1\t1\t0\t0\t0\t0\t0\t0\t100\t50\t-1\r\nab\t5\t1\t1\t1\t1\t1\t10\t10\t20\t10\t90\r\n",
+			wantText:  "ab ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Width: 100, Height: 50}},
+		},
+		"lines": {
+			data:      "1\t1\t0\t0\t0\t0\t0\t0\t100\t50\t-1\t\n5\t1\t1\t1\t1\t1\t10\t10\t20\t10\t90\ta\n5\t1\t1\t1\t2\t1\t10\t20\t20\t10\t90\tb\n5\t1\t2\t1\t1\t1\t10\t30\t20\t10\t90\tc\n",
+			opts:      Options{DPI: 150},
+			wantText:  "a b c ",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 6}, Width: 100, Height: 50, DpiX: 150, DpiY: 150}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := UnmarshalWithOptions([]byte(tt.data), tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantText, eocr.Text(doc))
+			assert.Equal(t, tt.wantPages, doc.Pages)
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"no pages": {
+			data:    "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n",
+			wantErr: ErrNoPages.Error(),
+		},
+		"missing column": {
+			data:    "level\tpage_num\ttext\n",
+			wantErr: `tsv: missing column "block_num"`,
+		},
+		"word outside page": {
+			data:    "5\t1\t1\t1\t1\t1\t10\t10\t20\t10\t90\tab\n",
+			wantErr: "tsv: line 1: word outside of page 1",
+		},
+		"invalid number": {
+			data:    "1\t1\t0\t0\t0\t0\t0\t0\twide\t50\t-1\t\n",
+			wantErr: `tsv: line 1: invalid width "wide"`,
+		},
+		"invalid conf": {
+			data:    "1\t1\t0\t0\t0\t0\t0\t0\t100\t50\t\t\n",
+			wantErr: `tsv: line 1: invalid conf ""`,
+		},
+		"negative box": {
+			data:    "1\t1\t0\t0\t0\t0\t-1\t0\t100\t50\t-1\t\n",
+			wantErr: "tsv: line 1: negative box -1 0 100 50",
+		},
+		"unknown level": {
+			data:    "6\t1\t0\t0\t0\t0\t0\t0\t100\t50\t-1\t\n",
+			wantErr: "tsv: line 1: unknown level 6",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Unmarshal([]byte(tt.data))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+// Package edoc reads prepared document files, edoc and the legacy kiradoc.
+//
+// A prepared document uses the same framing as an eocr file: a header, the
+// SHA-1 checksum of the payload and a gzip compressed protobuf message. The
+// message extends ocr.Document with the text of the document and its
+// linguistic analysis. Since the OCR layer keeps the field numbers of
+// ocr.Document, it can be extracted as one.
+//
+// Only the fields whose meaning is known are decoded, everything else is
+// skipped.
+package edoc
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"os"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/zuvaai/eocr-utils/internal/gzip"
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/filetype"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// headerSize is the size of the header on serialized documents.
+const headerSize = filetype.HeaderLength
+
+// supportedTypes are the file types this package reads.
+var supportedTypes = []filetype.Type{filetype.EDoc, filetype.KiraDoc}
+
+// Document is a prepared document.
+type Document struct {
+	// Format is the type of file the document was read from.
+	Format filetype.Type
+	// OCR is the OCR layer of the document, or nil if it has no pages or
+	// characters.
+	OCR *ocr.Document
+	// Text is the text of the document. Each character of the OCR layer
+	// is one rune of the text.
+	Text string
+	// CharacterIndexes holds, for each byte of Text, the index of the
+	// character it was produced from.
+	CharacterIndexes []uint32
+	// Headers are the character spans of the page headers.
+	Headers []*ocr.Span
+	// Footers are the character spans of the page footers.
+	Footers []*ocr.Span
+	// Lines are the lines of text of the document in reading order.
+	Lines []*Line
+	// HeaderLines are the indexes in Lines of the page header lines.
+	HeaderLines []uint32
+	// FooterLines are the indexes in Lines of the page footer lines.
+	FooterLines []uint32
+	// Sentences are the sentences of the document.
+	Sentences []*Sentence
+	// Cells holds the text of each table cell, in the same order as
+	// OCR.TableCells.
+	Cells []*Cell
+	// Language is the main language of the document.
+	Language *Language
+}
+
+// Line is a line of text.
+type Line struct {
+	// BoundingBox is the box around the line on its page.
+	BoundingBox *ocr.BoundingBox
+	// Span is the range of characters of the line.
+	Span *ocr.Span
+	// Tokens are the words and punctuation of the line.
+	Tokens []*Token
+	// Language is the language of the line.
+	Language *Language
+}
+
+// Sentence is a sentence of the document.
+type Sentence struct {
+	// Span is the range of characters of the sentence.
+	Span *ocr.Span
+	// Tokens are the words and punctuation of the sentence.
+	Tokens []*Token
+}
+
+// Cell is the text of a table cell.
+type Cell struct {
+	// Span is the range of characters in the cell.
+	Span *ocr.Span
+	// Tokens are the words and punctuation of the cell.
+	Tokens []*Token
+}
+
+// Token is a word or punctuation mark.
+type Token struct {
+	// Index is the position of the token in its line, sentence or cell.
+	Index uint32
+	// Span is the range of characters of the token.
+	Span *ocr.Span
+	// Text is the token as it appears in the document.
+	Text string
+	// Normalized is the lower case form of the token.
+	Normalized string
+	// Shape is the shape of the token, e.g. "Aa*" for a capitalized word
+	// or "d" for a digit.
+	Shape string
+}
+
+// Language is a detected language.
+type Language struct {
+	// Code is the ISO 639-1 code of the language, e.g. "en".
+	Code string
+	// Confidence is the confidence of the detection, between 0 and 1.
+	Confidence float32
+}
+
+// ReadFile reads a prepared document from a file.
+func ReadFile(filename string) (*Document, error) {
+	in, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(in)
+}
+
+// Verify checks the integrity of a serialized prepared document by checking
+// the header and the checksum against the message.
+func Verify(data []byte) error {
+	_, err := verify(data)
+	return err
+}
+
+func verify(data []byte) (filetype.Type, error) {
+	if len(data) < headerSize+sha1.Size {
+		return filetype.Unknown, eocr.ErrTooSmall
+	}
+	format := filetype.Unknown
+	for _, t := range supportedTypes {
+		if bytes.Equal(data[:headerSize], filetype.Magic(t)) {
+			format = t
+		}
+	}
+	if format == filetype.Unknown {
+		return filetype.Unknown, eocr.ErrInvalidHeader
+	}
+	checksum := sha1.Sum(data[headerSize+sha1.Size:])
+	if !bytes.Equal(checksum[:], data[headerSize:headerSize+sha1.Size]) {
+		return filetype.Unknown, eocr.ErrInvalidChecksum
+	}
+	return format, nil
+}
+
+// Unmarshal verifies and decodes a serialized prepared document.
+func Unmarshal(data []byte) (*Document, error) {
+	format, err := verify(data)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := gzip.Uncompress(data[headerSize+sha1.Size:])
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{Format: format}
+	layer := &ocr.Document{}
+	if err := proto.Unmarshal(msg, layer); err != nil {
+		return nil, fmt.Errorf("invalid OCR layer: %w", err)
+	}
+	if len(layer.Pages) > 0 && len(layer.Characters) > 0 {
+		doc.OCR = layer
+	}
+	if err := decodeDocument(msg, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,84 @@
+package edoc
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/filetype"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestReadFile(t *testing.T) {
+	doc, err := ReadFile("../../testdata/jbs.kiradoc")
+	require.NoError(t, err)
+	assert.Equal(t, filetype.KiraDoc, doc.Format)
+
+	// The OCR layer matches the OCR results the document was prepared from.
+	want, err := eocr.ReadFile("../../testdata/jbs.kiraocr")
+	require.NoError(t, err)
+	require.NotNil(t, doc.OCR)
+	assert.True(t, want.Equal(doc.OCR))
+	assert.Equal(t, eocr.Text(doc.OCR), doc.Text)
+	assert.Len(t, doc.CharacterIndexes, len(doc.Text))
+	assert.Equal(t, uint32(len(doc.OCR.Characters)-1), doc.CharacterIndexes[len(doc.CharacterIndexes)-1])
+
+	require.Len(t, doc.Headers, 15)
+	assert.Equal(t, &ocr.Span{Start: 3100, End: 3113}, doc.Headers[1])
+	assert.Equal(t, "Page 2 of 17 ", doc.Text[3100:3113])
+	require.Len(t, doc.Footers, 14)
+	assert.Equal(t, &ocr.Span{Start: 6793, End: 6795}, doc.Footers[0])
+	assert.Len(t, doc.HeaderLines, 15)
+	assert.Len(t, doc.FooterLines, 14)
+
+	require.Len(t, doc.Lines, 689)
+	line := doc.Lines[1]
+	assert.Equal(t, &ocr.BoundingBox{X1: 864, Y1: 417, X2: 1638, Y2: 446}, line.BoundingBox)
+	assert.Equal(t, &ocr.Span{Start: 13, End: 43}, line.Span)
+	require.Len(t, line.Tokens, 4)
+	assert.Equal(t, &Token{Index: 1, Span: &ocr.Span{Start: 17, End: 25}, Text: "MATERIAL", Normalized: "material", Shape: "A*"}, line.Tokens[1])
+	assert.Equal(t, "en", line.Language.Code)
+
+	require.Len(t, doc.Sentences, 497)
+	assert.Equal(t, &ocr.Span{Start: 43, End: 466}, doc.Sentences[1].Span)
+	assert.Equal(t, "THIS", doc.Sentences[1].Tokens[0].Text)
+
+	require.Len(t, doc.Cells, len(doc.OCR.TableCells))
+	assert.Equal(t, &ocr.Span{Start: 33605, End: 33624}, doc.Cells[0].Span)
+	assert.Equal(t, "Beef", doc.Cells[0].Tokens[0].Text)
+
+	require.NotNil(t, doc.Language)
+	assert.Equal(t, "en", doc.Language.Code)
+	assert.InDelta(t, 0.97, doc.Language.Confidence, 0.01)
+}
+
+func TestVerify(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/jbs.kiradoc")
+	require.NoError(t, err)
+	assert.NoError(t, Verify(data))
+
+	assert.Equal(t, eocr.ErrTooSmall, Verify(data[:20]))
+	corrupt := append([]byte{}, data...)
+	corrupt[len(corrupt)-1]++
+	assert.Equal(t, eocr.ErrInvalidChecksum, Verify(corrupt))
+
+	ocrData, err := os.ReadFile("../../testdata/jbs.kiraocr")
+	require.NoError(t, err)
+	assert.Equal(t, eocr.ErrInvalidHeader, Verify(ocrData))
+}
+
+func TestWalkErrors(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"truncated varint":  {0x08, 0x80},
+		"truncated bytes":   {0x0a, 0x05, 'a'},
+		"truncated fixed32": {0x15, 0x01},
+		"bad wire type":     {0x0b},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, walk(b, func(field) error { return nil }))
+		})
+	}
+}
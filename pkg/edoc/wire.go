@@ -0,0 +1,244 @@
+package edoc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// field is a single field of a protobuf message.
+type field struct {
+	num  uint64
+	wire uint64
+	// value holds varint, fixed32 and fixed64 values.
+	value uint64
+	// bytes holds length delimited values.
+	bytes []byte
+}
+
+// walk calls fn for every field of the protobuf message in b.
+func walk(b []byte, fn func(f field) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return fmt.Errorf("invalid field key")
+		}
+		b = b[n:]
+		f := field{num: key >> 3, wire: key & 7}
+		switch f.wire {
+		case wireVarint:
+			if f.value, n = binary.Uvarint(b); n <= 0 {
+				return fmt.Errorf("field %d: invalid varint", f.num)
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return fmt.Errorf("field %d: truncated fixed64", f.num)
+			}
+			f.value, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return fmt.Errorf("field %d: invalid length", f.num)
+			}
+			f.bytes, b = b[n:n+int(l)], b[n+int(l):]
+		case wireFixed32:
+			if len(b) < 4 {
+				return fmt.Errorf("field %d: truncated fixed32", f.num)
+			}
+			f.value, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		default:
+			return fmt.Errorf("field %d: unsupported wire type %d", f.num, f.wire)
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uint32s appends the values of a repeated uint32 field, packed or not.
+func uint32s(dst []uint32, f field) ([]uint32, error) {
+	if f.wire == wireVarint {
+		return append(dst, uint32(f.value)), nil
+	}
+	b := f.bytes
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("field %d: invalid packed varint", f.num)
+		}
+		dst = append(dst, uint32(v))
+		b = b[n:]
+	}
+	return dst, nil
+}
+
+// decodeDocument decodes the fields a prepared document adds to ocr.Document.
+func decodeDocument(msg []byte, doc *Document) error {
+	cell := 0
+	return walk(msg, func(f field) (err error) {
+		switch f.num {
+		case 5:
+			// The cells of the OCR layer are extended with their text.
+			c, err := decodeCell(f.bytes)
+			if err != nil {
+				return fmt.Errorf("table cell %d: %w", cell, err)
+			}
+			doc.Cells = append(doc.Cells, c)
+			cell++
+		case 9:
+			span, err := decodeSpan(f.bytes)
+			doc.Headers = append(doc.Headers, span)
+			return err
+		case 10:
+			span, err := decodeSpan(f.bytes)
+			doc.Footers = append(doc.Footers, span)
+			return err
+		case 11:
+			doc.Text = string(f.bytes)
+		case 12:
+			doc.CharacterIndexes, err = uint32s(doc.CharacterIndexes, f)
+		case 13:
+			l, err := decodeLine(f.bytes)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", len(doc.Lines), err)
+			}
+			doc.Lines = append(doc.Lines, l)
+		case 14:
+			doc.HeaderLines, err = uint32s(doc.HeaderLines, f)
+		case 15:
+			doc.FooterLines, err = uint32s(doc.FooterLines, f)
+		case 16:
+			s, err := decodeSentence(f.bytes)
+			if err != nil {
+				return fmt.Errorf("sentence %d: %w", len(doc.Sentences), err)
+			}
+			doc.Sentences = append(doc.Sentences, s)
+		case 17:
+			doc.Language, err = decodeLanguage(f.bytes)
+		}
+		return err
+	})
+}
+
+func decodeSpan(b []byte) (*ocr.Span, error) {
+	span := &ocr.Span{}
+	return span, walk(b, func(f field) error {
+		switch f.num {
+		case 1:
+			span.Start = uint32(f.value)
+		case 2:
+			span.End = uint32(f.value)
+		}
+		return nil
+	})
+}
+
+func decodeBoundingBox(b []byte) (*ocr.BoundingBox, error) {
+	bb := &ocr.BoundingBox{}
+	return bb, walk(b, func(f field) error {
+		switch f.num {
+		case 1:
+			bb.X1 = uint32(f.value)
+		case 2:
+			bb.Y1 = uint32(f.value)
+		case 3:
+			bb.X2 = uint32(f.value)
+		case 4:
+			bb.Y2 = uint32(f.value)
+		}
+		return nil
+	})
+}
+
+func decodeLine(b []byte) (*Line, error) {
+	l := &Line{}
+	return l, walk(b, func(f field) (err error) {
+		switch f.num {
+		case 1:
+			l.BoundingBox, err = decodeBoundingBox(f.bytes)
+		case 2:
+			l.Span, err = decodeSpan(f.bytes)
+		case 3:
+			var t *Token
+			t, err = decodeToken(f.bytes)
+			l.Tokens = append(l.Tokens, t)
+		case 4:
+			l.Language, err = decodeLanguage(f.bytes)
+		}
+		return err
+	})
+}
+
+func decodeSentence(b []byte) (*Sentence, error) {
+	s := &Sentence{}
+	return s, walk(b, func(f field) (err error) {
+		switch f.num {
+		case 1:
+			s.Span, err = decodeSpan(f.bytes)
+		case 2:
+			var t *Token
+			t, err = decodeToken(f.bytes)
+			s.Tokens = append(s.Tokens, t)
+		}
+		return err
+	})
+}
+
+func decodeCell(b []byte) (*Cell, error) {
+	c := &Cell{}
+	return c, walk(b, func(f field) (err error) {
+		switch f.num {
+		case 9:
+			c.Span, err = decodeSpan(f.bytes)
+		case 14:
+			var t *Token
+			t, err = decodeToken(f.bytes)
+			c.Tokens = append(c.Tokens, t)
+		}
+		return err
+	})
+}
+
+func decodeToken(b []byte) (*Token, error) {
+	t := &Token{}
+	return t, walk(b, func(f field) (err error) {
+		switch f.num {
+		case 1:
+			t.Index = uint32(f.value)
+		case 2:
+			t.Span, err = decodeSpan(f.bytes)
+		case 3:
+			t.Text = string(f.bytes)
+		case 4:
+			t.Normalized = string(f.bytes)
+		case 5:
+			t.Shape = string(f.bytes)
+		}
+		return err
+	})
+}
+
+func decodeLanguage(b []byte) (*Language, error) {
+	l := &Language{}
+	return l, walk(b, func(f field) error {
+		switch f.num {
+		case 1:
+			l.Code = string(f.bytes)
+		case 2:
+			l.Confidence = math.Float32frombits(uint32(f.value))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,438 @@
+package eocr
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/zuvaai/eocr-utils/internal/span"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// defaultMaxEditDistance is the edit distance DiffOptions.MaxEditDistance
+// defaults to.
+const defaultMaxEditDistance = 1000
+
+// DiffOptions configures Diff.
+type DiffOptions struct {
+	// BoxTolerance is the number of pixels any coordinate of a bounding box
+	// may move before the box is reported as changed.
+	BoxTolerance uint32
+	// MaxChanges limits the number of changes in the report. Zero means no
+	// limit.
+	MaxChanges int
+	// MaxEditDistance bounds the work done aligning the characters of the
+	// two documents. If more than this many characters were inserted or
+	// deleted, the differing region is reported as a single text change.
+	// Zero means defaultMaxEditDistance.
+	MaxEditDistance int
+}
+
+// ChangeKind identifies the part of a document a Change is about.
+type ChangeKind string
+
+const (
+	// ChangeDocument is a change to a document level field.
+	ChangeDocument ChangeKind = "document"
+	// ChangeText is a run of characters whose unicode values changed.
+	ChangeText ChangeKind = "text"
+	// ChangeBoundingBox is a character whose bounding box moved.
+	ChangeBoundingBox ChangeKind = "bounding_box"
+	// ChangePage is a change to the size or resolution of a page.
+	ChangePage ChangeKind = "page"
+	// ChangeFont is a run of characters whose font changed.
+	ChangeFont ChangeKind = "font"
+	// ChangeFontSize is a run of characters whose font size changed.
+	ChangeFontSize ChangeKind = "font_size"
+	// ChangeFontStyle is a run of characters whose font styles changed.
+	ChangeFontStyle ChangeKind = "font_style"
+	// ChangeTable is a change to a table.
+	ChangeTable ChangeKind = "table"
+	// ChangeTableCell is a change to a table cell.
+	ChangeTableCell ChangeKind = "table_cell"
+)
+
+// Change is a single difference between two documents. Path addresses the
+// changed field in the first document using the field names from
+// recognition_results.proto, and BPath addresses it in the second document
+// when its location differs. A and B hold the old and new values.
+type Change struct {
+	Kind  ChangeKind  `json:"kind"`
+	Path  string      `json:"path"`
+	BPath string      `json:"b_path,omitempty"`
+	A     interface{} `json:"a,omitempty"`
+	B     interface{} `json:"b,omitempty"`
+}
+
+// DiffReport lists the changes between two documents.
+type DiffReport struct {
+	Changes []Change `json:"changes"`
+	// Truncated is set when changes were dropped because of
+	// DiffOptions.MaxChanges.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Equal reports whether no changes were found.
+func (r *DiffReport) Equal() bool {
+	return len(r.Changes) == 0 && !r.Truncated
+}
+
+// Diff compares two documents and reports what changed from a to b. The
+// characters of the two documents are aligned on their unicode values, so an
+// inserted word is reported once rather than as a change to every following
+// character, and bounding boxes and font attributes are compared between
+// aligned characters.
+func Diff(a, b *ocr.Document, opts DiffOptions) *DiffReport {
+	if opts.MaxEditDistance <= 0 {
+		opts.MaxEditDistance = defaultMaxEditDistance
+	}
+	d := &differ{a: a, b: b, opts: opts, report: &DiffReport{Changes: []Change{}}}
+	d.diffDocument()
+	d.diffPages()
+	d.diffCharacters()
+	d.diffTables()
+	return d.report
+}
+
+type differ struct {
+	a, b   *ocr.Document
+	opts   DiffOptions
+	report *DiffReport
+}
+
+func (d *differ) add(c Change) {
+	if d.opts.MaxChanges > 0 && len(d.report.Changes) >= d.opts.MaxChanges {
+		d.report.Truncated = true
+		return
+	}
+	d.report.Changes = append(d.report.Changes, c)
+}
+
+func (d *differ) diffDocument() {
+	if d.a.Version != d.b.Version {
+		d.add(Change{Kind: ChangeDocument, Path: "version", A: d.a.Version, B: d.b.Version})
+	}
+	if d.a.Source != d.b.Source {
+		d.add(Change{Kind: ChangeDocument, Path: "source", A: d.a.Source, B: d.b.Source})
+	}
+	if !bytes.Equal(d.a.Md5, d.b.Md5) {
+		d.add(Change{Kind: ChangeDocument, Path: "md5", A: fmt.Sprintf("%x", d.a.Md5), B: fmt.Sprintf("%x", d.b.Md5)})
+	}
+}
+
+func (d *differ) diffPages() {
+	if len(d.a.Pages) != len(d.b.Pages) {
+		d.add(Change{Kind: ChangePage, Path: "pages", A: len(d.a.Pages), B: len(d.b.Pages)})
+	}
+	for i := 0; i < len(d.a.Pages) && i < len(d.b.Pages); i++ {
+		pa, pb := d.a.Pages[i], d.b.Pages[i]
+		if pa == nil || pb == nil {
+			continue
+		}
+		path := fmt.Sprintf("pages[%d]", i)
+		d.diffUint(ChangePage, path+".width", pa.Width, pb.Width)
+		d.diffUint(ChangePage, path+".height", pa.Height, pb.Height)
+		d.diffUint(ChangePage, path+".dpi_x", pa.DpiX, pb.DpiX)
+		d.diffUint(ChangePage, path+".dpi_y", pa.DpiY, pb.DpiY)
+	}
+}
+
+func (d *differ) diffUint(kind ChangeKind, path string, a, b uint32) {
+	if a != b {
+		d.add(Change{Kind: kind, Path: path, A: a, B: b})
+	}
+}
+
+// diffCharacters aligns the characters of both documents and reports text
+// changes between them, then compares the attributes of aligned characters.
+func (d *differ) diffCharacters() {
+	ua, ub := unicodes(d.a.Characters), unicodes(d.b.Characters)
+	matches := align(ua, ub, d.opts.MaxEditDistance)
+
+	// Report the gaps between aligned characters as text changes.
+	ai, bi := 0, 0
+	for _, m := range append(matches, match{len(ua), len(ub)}) {
+		if m.a > ai || m.b > bi {
+			c := Change{
+				Kind: ChangeText,
+				Path: fmt.Sprintf("characters[%d:%d]", ai, m.a),
+				A:    decodeCharacters(d.a.Characters[ai:m.a], ai).Text,
+				B:    decodeCharacters(d.b.Characters[bi:m.b], bi).Text,
+			}
+			if ai != bi || m.a != m.b {
+				c.BPath = fmt.Sprintf("characters[%d:%d]", bi, m.b)
+			}
+			d.add(c)
+		}
+		ai, bi = m.a+1, m.b+1
+	}
+
+	for _, m := range matches {
+		ca, cb := d.a.Characters[m.a], d.b.Characters[m.b]
+		if ca == nil || cb == nil {
+			continue
+		}
+		if !boxesWithin(ca.BoundingBox, cb.BoundingBox, d.opts.BoxTolerance) {
+			c := Change{Kind: ChangeBoundingBox, Path: fmt.Sprintf("characters[%d].bounding_box", m.a), A: ca.BoundingBox, B: cb.BoundingBox}
+			if m.a != m.b {
+				c.BPath = fmt.Sprintf("characters[%d].bounding_box", m.b)
+			}
+			d.add(c)
+		}
+	}
+
+	fa, fb := newCharAttrs(d.a), newCharAttrs(d.b)
+	d.diffRuns(ChangeFont, matches, func(i, j int) (interface{}, interface{}, bool) {
+		return fa.font[i], fb.font[j], fa.font[i] == fb.font[j]
+	})
+	d.diffRuns(ChangeFontSize, matches, func(i, j int) (interface{}, interface{}, bool) {
+		return fa.size[i], fb.size[j], fa.size[i] == fb.size[j]
+	})
+	d.diffRuns(ChangeFontStyle, matches, func(i, j int) (interface{}, interface{}, bool) {
+		return styleNames(fa.styles[i]), styleNames(fb.styles[j]), fa.styles[i] == fb.styles[j]
+	})
+}
+
+// diffRuns reports runs of consecutive aligned characters whose attribute, as
+// returned by attr, differs in the same way.
+func (d *differ) diffRuns(kind ChangeKind, matches []match, attr func(i, j int) (a, b interface{}, equal bool)) {
+	var run *Change
+	var start, prev match
+	flush := func() {
+		if run == nil {
+			return
+		}
+		run.Path = fmt.Sprintf("characters[%d:%d]", start.a, prev.a+1)
+		if start.a != start.b {
+			run.BPath = fmt.Sprintf("characters[%d:%d]", start.b, prev.b+1)
+		}
+		d.add(*run)
+		run = nil
+	}
+	for _, m := range matches {
+		va, vb, equal := attr(m.a, m.b)
+		if run != nil && (equal || m.a != prev.a+1 || m.b != prev.b+1 ||
+			!reflect.DeepEqual(va, run.A) || !reflect.DeepEqual(vb, run.B)) {
+			flush()
+		}
+		if !equal && run == nil {
+			run = &Change{Kind: kind, A: va, B: vb}
+			start = m
+		}
+		prev = m
+	}
+	flush()
+}
+
+func (d *differ) diffTables() {
+	if len(d.a.Tables) != len(d.b.Tables) {
+		d.add(Change{Kind: ChangeTable, Path: "tables", A: len(d.a.Tables), B: len(d.b.Tables)})
+	}
+	for i := 0; i < len(d.a.Tables) && i < len(d.b.Tables); i++ {
+		ta, tb := d.a.Tables[i], d.b.Tables[i]
+		if ta == nil || tb == nil {
+			continue
+		}
+		path := fmt.Sprintf("tables[%d]", i)
+		d.diffUint(ChangeTable, path+".id", ta.Id, tb.Id)
+		d.diffUint(ChangeTable, path+".page_number", ta.PageNumber, tb.PageNumber)
+	}
+
+	if len(d.a.TableCells) != len(d.b.TableCells) {
+		d.add(Change{Kind: ChangeTableCell, Path: "table_cells", A: len(d.a.TableCells), B: len(d.b.TableCells)})
+	}
+	for i := 0; i < len(d.a.TableCells) && i < len(d.b.TableCells); i++ {
+		ca, cb := d.a.TableCells[i], d.b.TableCells[i]
+		if ca == nil || cb == nil {
+			continue
+		}
+		path := fmt.Sprintf("table_cells[%d]", i)
+		d.diffUint(ChangeTableCell, path+".id", ca.Id, cb.Id)
+		if !boxesWithin(ca.BoundingBox, cb.BoundingBox, d.opts.BoxTolerance) {
+			d.add(Change{Kind: ChangeTableCell, Path: path + ".bounding_box", A: ca.BoundingBox, B: cb.BoundingBox})
+		}
+		if !ca.BackgroundColor.Equal(cb.BackgroundColor) {
+			d.add(Change{Kind: ChangeTableCell, Path: path + ".background_color", A: ca.BackgroundColor, B: cb.BackgroundColor})
+		}
+		d.diffUint(ChangeTableCell, path+".left_border_width", ca.LeftBorderWidth, cb.LeftBorderWidth)
+		d.diffUint(ChangeTableCell, path+".right_border_width", ca.RightBorderWidth, cb.RightBorderWidth)
+		d.diffUint(ChangeTableCell, path+".top_border_width", ca.TopBorderWidth, cb.TopBorderWidth)
+		d.diffUint(ChangeTableCell, path+".bottom_border_width", ca.BottomBorderWidth, cb.BottomBorderWidth)
+	}
+}
+
+// boxesWithin reports whether no coordinate of a differs from b by more than
+// tolerance pixels.
+func boxesWithin(a, b *ocr.BoundingBox, tolerance uint32) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return within(a.X1, b.X1, tolerance) && within(a.Y1, b.Y1, tolerance) &&
+		within(a.X2, b.X2, tolerance) && within(a.Y2, b.Y2, tolerance)
+}
+
+func within(a, b, tolerance uint32) bool {
+	if a > b {
+		return a-b <= tolerance
+	}
+	return b-a <= tolerance
+}
+
+// charAttrs holds the font attributes of every character of a document.
+type charAttrs struct {
+	font   []string
+	size   []uint32
+	styles []uint32 // bit set of FontStyle_Style values
+}
+
+func newCharAttrs(doc *ocr.Document) *charAttrs {
+	n := uint32(len(doc.Characters))
+	attrs := &charAttrs{
+		font:   make([]string, n),
+		size:   make([]uint32, n),
+		styles: make([]uint32, n),
+	}
+	for _, f := range doc.Fonts {
+		start, end := span.Clamp(f.CharacterSpan, n)
+		for i := start; i < end; i++ {
+			attrs.font[i] = f.Name
+		}
+	}
+	for _, f := range doc.FontSizes {
+		start, end := span.Clamp(f.CharacterSpan, n)
+		for i := start; i < end; i++ {
+			attrs.size[i] = f.Size_
+		}
+	}
+	for _, f := range doc.FontStyles {
+		start, end := span.Clamp(f.CharacterSpan, n)
+		for i := start; i < end; i++ {
+			attrs.styles[i] |= 1 << uint32(f.Style)
+		}
+	}
+	return attrs
+}
+
+// styleNames returns the names of the styles in the bit set.
+func styleNames(styles uint32) []string {
+	names := []string{}
+	for s := int32(0); s < 32; s++ {
+		if styles&(1<<uint32(s)) != 0 {
+			names = append(names, ocr.FontStyle_Style(s).String())
+		}
+	}
+	return names
+}
+
+func unicodes(chars []*ocr.Character) []uint32 {
+	u := make([]uint32, len(chars))
+	for i, c := range chars {
+		if c != nil {
+			u[i] = c.Unicode
+		}
+	}
+	return u
+}
+
+// match is a pair of aligned indexes into two sequences.
+type match struct {
+	a, b int
+}
+
+// align returns the indexes of a longest common subsequence of a and b,
+// computed with Myers' O(ND) algorithm. If more than maxD insertions and
+// deletions are needed, only the common prefix and suffix are aligned.
+func align(a, b []uint32, maxD int) []match {
+	var matches []match
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		matches = append(matches, match{prefix, prefix})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, m := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], maxD) {
+		matches = append(matches, match{m.a + prefix, m.b + prefix})
+	}
+	for i := suffix; i > 0; i-- {
+		matches = append(matches, match{len(a) - i, len(b) - i})
+	}
+	return matches
+}
+
+// myers returns the aligned indexes of a and b in increasing order, or nil if
+// the edit distance exceeds maxD.
+func myers(a, b []uint32, maxD int) []match {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return nil
+	}
+	if maxD > n+m {
+		maxD = n + m
+	}
+	// v[k+offset] is the furthest x reached on diagonal k.
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] holds v for diagonals -d..d after step d.
+	var trace [][]int
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				return backtrack(trace, n, m)
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	return nil
+}
+
+// backtrack walks the trace of myers back from (n, m) and collects the
+// diagonal moves.
+func backtrack(trace [][]int, n, m int) []match {
+	var rev []match
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1] // indexed by k+d-1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rev = append(rev, match{x, y})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		rev = append(rev, match{x, y})
+	}
+	matches := make([]match, len(rev))
+	for i, mt := range rev {
+		matches[len(rev)-1-i] = mt
+	}
+	return matches
+}
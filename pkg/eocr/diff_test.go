@@ -0,0 +1,184 @@
+package eocr
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestDiff(t *testing.T) {
+	tests := map[string]struct {
+		b    string
+		opts DiffOptions
+		edit func(a, b *ocr.Document)
+		want []Change
+	}{
+		"same": {
+			b: "foo bar baz",
+		},
+		"inserted word": {
+			b: "foo xyz bar baz",
+			want: []Change{
+				{Kind: ChangeDocument, Path: "md5", A: "ab07acbb1e496801937adfa772424bf7", B: "f11b409f4b12dd976eb8fc606f7e0a97"},
+				{Kind: ChangeText, Path: "characters[4:4]", BPath: "characters[4:8]", A: "", B: "xyz "},
+				{Kind: ChangeBoundingBox, Path: "characters[4].bounding_box", BPath: "characters[8].bounding_box",
+					A: &ocr.BoundingBox{X1: 40, X2: 50, Y2: 10}, B: &ocr.BoundingBox{X1: 80, X2: 90, Y2: 10}},
+			},
+			opts: DiffOptions{MaxChanges: 3},
+		},
+		"replaced character": {
+			b: "foo bat baz",
+			want: []Change{
+				{Kind: ChangeText, Path: "characters[6:7]", A: "r", B: "t"},
+			},
+			edit: func(a, b *ocr.Document) { b.Md5 = a.Md5 },
+		},
+		"box within tolerance": {
+			b:    "foo bar baz",
+			opts: DiffOptions{BoxTolerance: 2},
+			edit: func(a, b *ocr.Document) { b.Characters[1].BoundingBox.X1 += 2 },
+		},
+		"box drift": {
+			b:    "foo bar baz",
+			opts: DiffOptions{BoxTolerance: 2},
+			edit: func(a, b *ocr.Document) { b.Characters[1].BoundingBox.Y2 += 3 },
+			want: []Change{
+				{Kind: ChangeBoundingBox, Path: "characters[1].bounding_box",
+					A: &ocr.BoundingBox{X1: 10, X2: 20, Y2: 10}, B: &ocr.BoundingBox{X1: 10, X2: 20, Y2: 13}},
+			},
+		},
+		"page size": {
+			b: "foo bar baz",
+			edit: func(a, b *ocr.Document) {
+				b.Pages[0].Width = 100
+				b.Pages[0].DpiY = 200
+			},
+			want: []Change{
+				{Kind: ChangePage, Path: "pages[0].width", A: uint32(800), B: uint32(100)},
+				{Kind: ChangePage, Path: "pages[0].dpi_y", A: uint32(300), B: uint32(200)},
+			},
+		},
+		"fonts": {
+			b: "foo bar baz",
+			edit: func(a, b *ocr.Document) {
+				a.Fonts = []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 11}, Name: "Arial"}}
+				b.Fonts = []*ocr.Font{
+					{CharacterSpan: &ocr.Span{Start: 0, End: 4}, Name: "Arial"},
+					{CharacterSpan: &ocr.Span{Start: 4, End: 11}, Name: "Times"},
+				}
+				b.FontSizes = []*ocr.FontSize{{CharacterSpan: &ocr.Span{Start: 8, End: 11}, Size_: 12}}
+				b.FontStyles = []*ocr.FontStyle{
+					{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Style: ocr.BOLD},
+					{CharacterSpan: &ocr.Span{Start: 2, End: 3}, Style: ocr.ITALIC},
+				}
+			},
+			want: []Change{
+				{Kind: ChangeFont, Path: "characters[4:11]", A: "Arial", B: "Times"},
+				{Kind: ChangeFontSize, Path: "characters[8:11]", A: uint32(0), B: uint32(12)},
+				{Kind: ChangeFontStyle, Path: "characters[0:2]", A: []string{}, B: []string{"BOLD"}},
+				{Kind: ChangeFontStyle, Path: "characters[2:3]", A: []string{}, B: []string{"BOLD", "ITALIC"}},
+			},
+		},
+		"tables": {
+			b: "foo bar baz",
+			edit: func(a, b *ocr.Document) {
+				a.Tables = []*ocr.Table{{Id: 1}}
+				b.Tables = []*ocr.Table{{Id: 1}, {Id: 2}}
+				a.TableCells = []*ocr.TableCell{{Id: 1, BackgroundColor: &ocr.Color{R: 255}}}
+				b.TableCells = []*ocr.TableCell{{Id: 1, LeftBorderWidth: 2}}
+			},
+			want: []Change{
+				{Kind: ChangeTable, Path: "tables", A: 1, B: 2},
+				{Kind: ChangeTableCell, Path: "table_cells[0].background_color", A: &ocr.Color{R: 255}, B: (*ocr.Color)(nil)},
+				{Kind: ChangeTableCell, Path: "table_cells[0].left_border_width", A: uint32(0), B: uint32(2)},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, err := NewDocumentFromText("foo bar baz")
+			require.NoError(t, err)
+			b, err := NewDocumentFromText(tt.b)
+			require.NoError(t, err)
+			if tt.edit != nil {
+				tt.edit(a, b)
+			}
+			got := Diff(a, b, tt.opts)
+			if tt.want == nil {
+				assert.True(t, got.Equal(), got.Changes)
+				return
+			}
+			assert.Equal(t, tt.want, got.Changes)
+		})
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	a, err := NewDocumentFromText("foo bar baz")
+	require.NoError(t, err)
+	b, err := NewDocumentFromText("foo bat baz")
+	require.NoError(t, err)
+	b.Md5 = a.Md5
+
+	out, err := json.Marshal(Diff(a, b, DiffOptions{}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"changes":[{"kind":"text","path":"characters[6:7]","a":"r","b":"t"}]}`, string(out))
+}
+
+func TestAlign(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		a := randomUnicodes(rnd, rnd.Intn(30))
+		b := randomUnicodes(rnd, rnd.Intn(30))
+		matches := align(a, b, 100)
+		assert.Equal(t, lcsLength(a, b), len(matches))
+		prev := match{-1, -1}
+		for _, m := range matches {
+			require.Equal(t, a[m.a], b[m.b])
+			require.Greater(t, m.a, prev.a)
+			require.Greater(t, m.b, prev.b)
+			prev = m
+		}
+	}
+}
+
+func TestAlignMaxEditDistance(t *testing.T) {
+	a := []uint32{1, 2, 3, 4, 5, 6}
+	b := []uint32{1, 7, 8, 9, 4, 6}
+	assert.Equal(t, []match{{0, 0}, {3, 4}, {5, 5}}, align(a, b, 10))
+	assert.Equal(t, []match{{0, 0}, {5, 5}}, align(a, b, 2))
+}
+
+func randomUnicodes(rnd *rand.Rand, n int) []uint32 {
+	u := make([]uint32, n)
+	for i := range u {
+		u[i] = uint32('a' + rnd.Intn(3))
+	}
+	return u
+}
+
+func lcsLength(a, b []uint32) int {
+	l := make([][]int, len(a)+1)
+	for i := range l {
+		l[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				l[i][j] = l[i+1][j+1] + 1
+			case l[i+1][j] > l[i][j+1]:
+				l[i][j] = l[i+1][j]
+			default:
+				l[i][j] = l[i][j+1]
+			}
+		}
+	}
+	return l[0][0]
+}
@@ -0,0 +1,216 @@
+package eocr
+
+import (
+	"sort"
+
+	"github.com/zuvaai/eocr-utils/internal/span"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Index answers questions about the characters of a document, such as their
+// page and font, by binary search over its spans instead of scanning them.
+// Build it once with NewIndex and use it for many characters. It must not be
+// used after the document is modified.
+//
+// Lookups take logarithmic time in the number of pages and spans, except for
+// TableCellsAt, which is linear in the number of table cells on the page of
+// the character, as cell boxes may overlap in any way.
+//
+// Pages are expected to follow each other, as Validate checks. Fonts and font
+// sizes are expected not to overlap: where they do, only the one starting
+// last at or before a character is looked at. Styles may overlap freely.
+type Index struct {
+	doc      *ocr.Document
+	numChars uint32
+	// pageStarts and pageEnds hold the clamped span of each page.
+	pageStarts, pageEnds []uint32
+	fonts, sizes         indexSpans
+	// styles holds the merged spans of each style, in the order of their
+	// values.
+	styles []styleSpans
+	// cells holds the table cells of each page.
+	cells map[uint32][]*ocr.TableCell
+}
+
+// indexSpan is the clamped span of the element at index i of a document
+// field.
+type indexSpan struct {
+	start, end uint32
+	i          int
+}
+
+// indexSpans holds spans sorted by start.
+type indexSpans []indexSpan
+
+type styleSpans struct {
+	style ocr.FontStyle_Style
+	spans indexSpans
+}
+
+// NewIndex indexes doc.
+func NewIndex(doc *ocr.Document) *Index {
+	n := uint32(len(doc.Characters))
+	x := &Index{
+		doc:        doc,
+		numChars:   n,
+		pageStarts: make([]uint32, len(doc.Pages)),
+		pageEnds:   make([]uint32, len(doc.Pages)),
+		cells:      map[uint32][]*ocr.TableCell{},
+	}
+	for i, p := range doc.Pages {
+		x.pageStarts[i], x.pageEnds[i] = span.Clamp(p.GetCharacterSpan(), n)
+	}
+	for i, f := range doc.Fonts {
+		x.fonts = x.fonts.add(f.GetCharacterSpan(), n, i)
+	}
+	for i, s := range doc.FontSizes {
+		x.sizes = x.sizes.add(s.GetCharacterSpan(), n, i)
+	}
+	sort.SliceStable(x.fonts, func(i, j int) bool { return x.fonts[i].start < x.fonts[j].start })
+	sort.SliceStable(x.sizes, func(i, j int) bool { return x.sizes[i].start < x.sizes[j].start })
+
+	styles := map[ocr.FontStyle_Style]indexSpans{}
+	for i, s := range doc.FontStyles {
+		if s != nil {
+			styles[s.Style] = styles[s.Style].add(s.CharacterSpan, n, i)
+		}
+	}
+	for style, spans := range styles {
+		x.styles = append(x.styles, styleSpans{style: style, spans: spans.union()})
+	}
+	sort.Slice(x.styles, func(i, j int) bool { return x.styles[i].style < x.styles[j].style })
+
+	tablePages := map[uint32]uint32{}
+	for _, t := range doc.Tables {
+		if t != nil {
+			tablePages[t.Id] = t.PageNumber
+		}
+	}
+	for _, c := range doc.TableCells {
+		if page, ok := tablePages[c.GetId()]; ok {
+			x.cells[page] = append(x.cells[page], c)
+		}
+	}
+	return x
+}
+
+// add appends sp, clamped to n characters, unless it is empty.
+func (s indexSpans) add(sp *ocr.Span, n uint32, i int) indexSpans {
+	start, end := span.Clamp(sp, n)
+	if start == end {
+		return s
+	}
+	return append(s, indexSpan{start: start, end: end, i: i})
+}
+
+// union sorts s and joins the spans that overlap or touch.
+func (s indexSpans) union() indexSpans {
+	sort.Slice(s, func(i, j int) bool { return s[i].start < s[j].start })
+	var u indexSpans
+	for _, span := range s {
+		if last := len(u) - 1; last >= 0 && span.start <= u[last].end {
+			if span.end > u[last].end {
+				u[last].end = span.end
+			}
+			continue
+		}
+		u = append(u, span)
+	}
+	return u
+}
+
+// find returns the span holding character c, or false if none does.
+func (s indexSpans) find(c uint32) (indexSpan, bool) {
+	i := sort.Search(len(s), func(i int) bool { return s[i].start > c }) - 1
+	if i < 0 || s[i].end <= c {
+		return indexSpan{}, false
+	}
+	return s[i], true
+}
+
+// PageOf returns the index of the page holding the character at index c, or
+// -1 if no page does.
+func (x *Index) PageOf(c int) int {
+	if c < 0 || c >= int(x.numChars) {
+		return -1
+	}
+	i := sort.Search(len(x.pageEnds), func(i int) bool { return x.pageEnds[i] > uint32(c) })
+	if i == len(x.pageEnds) || x.pageStarts[i] > uint32(c) {
+		return -1
+	}
+	return i
+}
+
+// CharsInPage returns the indexes of the first character of the page with the
+// given index and of the character following its last, or 0 and 0 if there
+// is no such page.
+func (x *Index) CharsInPage(page int) (start, end int) {
+	if page < 0 || page >= len(x.pageStarts) {
+		return 0, 0
+	}
+	return int(x.pageStarts[page]), int(x.pageEnds[page])
+}
+
+// FontAt returns the font of the character at index c, or nil if it has none.
+func (x *Index) FontAt(c int) *ocr.Font {
+	if c < 0 {
+		return nil
+	}
+	span, ok := x.fonts.find(uint32(c))
+	if !ok {
+		return nil
+	}
+	return x.doc.Fonts[span.i]
+}
+
+// FontSizeAt returns the font size of the character at index c, or 0 if it
+// has none.
+func (x *Index) FontSizeAt(c int) uint32 {
+	if c < 0 {
+		return 0
+	}
+	span, ok := x.sizes.find(uint32(c))
+	if !ok {
+		return 0
+	}
+	return x.doc.FontSizes[span.i].Size_
+}
+
+// StylesAt returns the styles of the character at index c, in the order of
+// their values.
+func (x *Index) StylesAt(c int) []ocr.FontStyle_Style {
+	if c < 0 {
+		return nil
+	}
+	var styles []ocr.FontStyle_Style
+	for _, s := range x.styles {
+		if _, ok := s.spans.find(uint32(c)); ok {
+			styles = append(styles, s.style)
+		}
+	}
+	return styles
+}
+
+// TableCellsAt returns the cells of the tables on the page of the character
+// at index c whose boxes hold the center of its box, in document order. It
+// checks every cell on the page.
+func (x *Index) TableCellsAt(c int) []*ocr.TableCell {
+	page := x.PageOf(c)
+	if page < 0 {
+		return nil
+	}
+	box := x.doc.Characters[c].GetBoundingBox()
+	if box == nil {
+		return nil
+	}
+	// Doubled to stay in integers.
+	cx, cy := box.X1+box.X2, box.Y1+box.Y2
+	var cells []*ocr.TableCell
+	for _, cell := range x.cells[uint32(page)] {
+		b := cell.GetBoundingBox()
+		if b != nil && 2*b.X1 <= cx && cx <= 2*b.X2 && 2*b.Y1 <= cy && cy <= 2*b.Y2 {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
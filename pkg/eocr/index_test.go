@@ -0,0 +1,63 @@
+package eocr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestIndex(t *testing.T) {
+	doc := newSplitDocument(t)
+	doc.FontStyles = append(doc.FontStyles,
+		&ocr.FontStyle{CharacterSpan: &ocr.Span{Start: 5, End: 7}, Style: ocr.ITALIC},
+		&ocr.FontStyle{CharacterSpan: &ocr.Span{Start: 0, End: 1}, Style: ocr.ITALIC},
+		&ocr.FontStyle{CharacterSpan: &ocr.Span{Start: 6, End: 8}, Style: ocr.ITALIC},
+	)
+	x := NewIndex(doc)
+	arial, courier := doc.Fonts[0], doc.Fonts[1]
+	cells := doc.TableCells
+
+	tests := map[string]struct {
+		c          int
+		wantPage   int
+		wantFont   *ocr.Font
+		wantSize   uint32
+		wantStyles []ocr.FontStyle_Style
+		wantCells  []*ocr.TableCell
+	}{
+		"first":         {c: 0, wantPage: 0, wantFont: arial, wantSize: 10, wantStyles: []ocr.FontStyle_Style{ocr.ITALIC}},
+		"end of page":   {c: 1, wantPage: 0, wantFont: arial, wantSize: 10},
+		"start of page": {c: 2, wantPage: 1, wantFont: arial, wantSize: 10, wantCells: cells[:1]},
+		"font change":   {c: 4, wantPage: 1, wantFont: courier, wantSize: 10, wantCells: cells[:1]},
+		"left cell":     {c: 6, wantPage: 2, wantFont: courier, wantSize: 10, wantStyles: []ocr.FontStyle_Style{ocr.BOLD, ocr.ITALIC}, wantCells: cells[1:2]},
+		"right cell":    {c: 7, wantPage: 2, wantFont: courier, wantSize: 10, wantStyles: []ocr.FontStyle_Style{ocr.ITALIC}, wantCells: cells[2:]},
+		"negative":      {c: -1, wantPage: -1},
+		"past end":      {c: 8, wantPage: -1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.wantPage, x.PageOf(tt.c))
+			assert.Equal(t, tt.wantFont, x.FontAt(tt.c))
+			assert.Equal(t, tt.wantSize, x.FontSizeAt(tt.c))
+			assert.Equal(t, tt.wantStyles, x.StylesAt(tt.c))
+			assert.Equal(t, tt.wantCells, x.TableCellsAt(tt.c))
+		})
+	}
+}
+
+func TestIndexCharsInPage(t *testing.T) {
+	doc := newSplitDocument(t)
+	// A page without text between the first two.
+	doc.Pages = append(doc.Pages[:1], append([]*ocr.Page{{CharacterSpan: &ocr.Span{Start: 2, End: 2}}}, doc.Pages[1:]...)...)
+	x := NewIndex(doc)
+
+	for page, want := range [][2]int{{0, 2}, {2, 2}, {2, 5}, {5, 8}, {0, 0}} {
+		start, end := x.CharsInPage(page)
+		assert.Equal(t, want, [2]int{start, end}, "page %d", page)
+	}
+	assert.Equal(t, 2, x.PageOf(2))
+	assert.Equal(t, 3, x.PageOf(5))
+}
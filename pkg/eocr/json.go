@@ -0,0 +1,281 @@
+package eocr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// jsonIndent is the indentation used by MarshalJSON and MarshalCompactJSON.
+const jsonIndent = "  "
+
+// runsKey is the field holding the characters of a compact JSON document.
+const runsKey = "runs"
+
+// MarshalJSON returns the protobuf JSON mapping of doc, using the field names
+// of recognition_results.proto. It is lossless, so UnmarshalJSON returns an
+// identical document.
+func MarshalJSON(doc *ocr.Document) ([]byte, error) {
+	var buf bytes.Buffer
+	m := jsonpb.Marshaler{Indent: jsonIndent, OrigName: true}
+	if err := m.Marshal(&buf, doc); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// textRun is a run of consecutive characters of a compact JSON document.
+type textRun struct {
+	// Text holds the characters of the run. Each UTF-16 code unit of the
+	// text is one character.
+	Text string `json:"text"`
+	// Boxes holds the x1, y1, x2, y2 coordinates of the bounding box of
+	// each character, or null if it has none.
+	Boxes [][]uint32 `json:"boxes"`
+	// Errors holds the error of each character. It is omitted when all of
+	// them are zero.
+	Errors []uint32 `json:"errors,omitempty"`
+}
+
+// MarshalCompactJSON is like MarshalJSON, but renders the characters of doc as
+// runs of text, one per line of the page, each with an array of bounding boxes.
+// This keeps fixtures short enough to review and edit by hand. Characters must
+// be valid UTF-16: unpaired surrogates and values above 0xFFFF can't be
+// represented and return an error.
+func MarshalCompactJSON(doc *ocr.Document) ([]byte, error) {
+	runs, err := textRuns(doc)
+	if err != nil {
+		return nil, err
+	}
+	rest := *doc
+	rest.Characters = nil
+	var buf bytes.Buffer
+	m := jsonpb.Marshaler{OrigName: true}
+	if err := m.Marshal(&buf, &rest); err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		return nil, err
+	}
+	if fields[runsKey], err = json.Marshal(runs); err != nil {
+		return nil, err
+	}
+	return writeCompact(fields)
+}
+
+// compactFieldOrder is the order of the fields of a compact JSON document.
+// Other fields follow in alphabetical order.
+var compactFieldOrder = []string{
+	"version", "source", "md5", "pages", runsKey, "tables", "table_cells",
+	"fonts", "font_sizes", "font_styles",
+}
+
+// writeCompact writes the fields of a document with one array element per
+// line.
+func writeCompact(fields map[string]json.RawMessage) ([]byte, error) {
+	keys := make([]string, 0, len(fields))
+	for _, k := range compactFieldOrder {
+		if _, ok := fields[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	var others []string
+	for k := range fields {
+		if !containsString(compactFieldOrder, k) {
+			others = append(others, k)
+		}
+	}
+	sort.Strings(others)
+	keys = append(keys, others...)
+
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, k := range keys {
+		fmt.Fprintf(&buf, "%s%q: ", jsonIndent, k)
+		var elems []json.RawMessage
+		if err := json.Unmarshal(fields[k], &elems); err == nil {
+			buf.WriteString("[")
+			for j, elem := range elems {
+				if j > 0 {
+					buf.WriteString(",")
+				}
+				buf.WriteString("\n" + jsonIndent + jsonIndent)
+				if err := json.Compact(&buf, elem); err != nil {
+					return nil, err
+				}
+			}
+			if len(elems) > 0 {
+				buf.WriteString("\n" + jsonIndent)
+			}
+			buf.WriteString("]")
+		} else if err := json.Compact(&buf, fields[k]); err != nil {
+			return nil, err
+		}
+		if i < len(keys)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON parses a document written by MarshalJSON or
+// MarshalCompactJSON. Serialize the result with Marshal to get an eocr file
+// with a freshly computed checksum.
+func UnmarshalJSON(data []byte) (*ocr.Document, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var runs []textRun
+	if raw, ok := fields[runsKey]; ok {
+		if err := json.Unmarshal(raw, &runs); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", runsKey, err)
+		}
+		delete(fields, runsKey)
+		var err error
+		if data, err = json.Marshal(fields); err != nil {
+			return nil, err
+		}
+	}
+	doc := &ocr.Document{}
+	if err := jsonpb.Unmarshal(bytes.NewReader(data), doc); err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		return doc, nil
+	}
+	if len(doc.Characters) > 0 {
+		return nil, fmt.Errorf("document has both characters and %s", runsKey)
+	}
+	for i, run := range runs {
+		chars, err := runCharacters(run)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", runsKey, i, err)
+		}
+		doc.Characters = append(doc.Characters, chars...)
+	}
+	return doc, nil
+}
+
+// textRuns splits the characters of doc into runs, starting a new run at each
+// page and whenever a character moves down a line or back to the left.
+func textRuns(doc *ocr.Document) ([]textRun, error) {
+	pageStarts := map[uint32]bool{}
+	for _, p := range doc.Pages {
+		if p != nil && p.CharacterSpan != nil {
+			pageStarts[p.CharacterSpan.Start] = true
+		}
+	}
+	runs := []textRun{}
+	var units []uint16
+	var run *textRun
+	flush := func() {
+		if run == nil {
+			return
+		}
+		run.Text = string(utf16.Decode(units))
+		allZero := true
+		for _, e := range run.Errors {
+			allZero = allZero && e == 0
+		}
+		if allZero {
+			run.Errors = nil
+		}
+		runs = append(runs, *run)
+		run, units = nil, nil
+	}
+	var prev *ocr.BoundingBox
+	for i, c := range doc.Characters {
+		if c == nil {
+			return nil, fmt.Errorf("character %d is missing", i)
+		}
+		if c.Unicode > 0xFFFF {
+			return nil, fmt.Errorf("character %d (%#x) is not a UTF-16 code unit", i, c.Unicode)
+		}
+		if utf16.IsSurrogate(rune(c.Unicode)) && !isPaired(doc.Characters, i) {
+			return nil, fmt.Errorf("character %d (%#x) is an unpaired surrogate", i, c.Unicode)
+		}
+		bb := c.BoundingBox
+		// Never split a surrogate pair between runs.
+		lowSurrogate := c.Unicode >= 0xDC00 && c.Unicode <= 0xDFFF
+		if !lowSurrogate && (pageStarts[uint32(i)] || startsLine(prev, bb)) {
+			flush()
+		}
+		if run == nil {
+			run = &textRun{Boxes: [][]uint32{}}
+		}
+		units = append(units, uint16(c.Unicode))
+		if bb == nil {
+			run.Boxes = append(run.Boxes, nil)
+		} else {
+			run.Boxes = append(run.Boxes, []uint32{bb.X1, bb.Y1, bb.X2, bb.Y2})
+			prev = bb
+		}
+		run.Errors = append(run.Errors, c.Error)
+	}
+	flush()
+	return runs, nil
+}
+
+// isPaired reports whether the surrogate at index i is part of a valid pair.
+func isPaired(chars []*ocr.Character, i int) bool {
+	u := rune(chars[i].Unicode)
+	if u < 0xDC00 {
+		return i+1 < len(chars) && chars[i+1] != nil &&
+			utf16.DecodeRune(u, rune(chars[i+1].Unicode)) != 0xFFFD
+	}
+	return i > 0 && chars[i-1] != nil &&
+		utf16.DecodeRune(rune(chars[i-1].Unicode), u) != 0xFFFD
+}
+
+// startsLine reports whether bb is on a new line relative to prev.
+func startsLine(prev, bb *ocr.BoundingBox) bool {
+	if prev == nil || bb == nil {
+		return false
+	}
+	return bb.Y1 >= prev.Y2 || bb.X1 < prev.X1
+}
+
+// runCharacters returns the characters of a text run.
+func runCharacters(run textRun) ([]*ocr.Character, error) {
+	units := utf16.Encode([]rune(run.Text))
+	if len(run.Boxes) != len(units) {
+		return nil, fmt.Errorf("text has %d characters but there are %d boxes", len(units), len(run.Boxes))
+	}
+	if run.Errors != nil && len(run.Errors) != len(units) {
+		return nil, fmt.Errorf("text has %d characters but there are %d errors", len(units), len(run.Errors))
+	}
+	chars := make([]*ocr.Character, len(units))
+	for i, u := range units {
+		c := &ocr.Character{Unicode: uint32(u)}
+		if box := run.Boxes[i]; box != nil {
+			if len(box) != 4 {
+				return nil, fmt.Errorf("box %d has %d coordinates, want 4", i, len(box))
+			}
+			c.BoundingBox = &ocr.BoundingBox{X1: box[0], Y1: box[1], X2: box[2], Y2: box[3]}
+		}
+		if run.Errors != nil {
+			c.Error = run.Errors[i]
+		}
+		chars[i] = c
+	}
+	return chars, nil
+}
@@ -0,0 +1,84 @@
+package eocr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	orig, err := ReadFile("../../testdata/jbs.kiraocr")
+	require.NoError(t, err)
+
+	for name, marshal := range map[string]func(*ocr.Document) ([]byte, error){
+		"full":    MarshalJSON,
+		"compact": MarshalCompactJSON,
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := marshal(orig)
+			require.NoError(t, err)
+			got, err := UnmarshalJSON(data)
+			require.NoError(t, err)
+			assert.True(t, orig.Equal(got))
+		})
+	}
+}
+
+func TestMarshalCompactJSON(t *testing.T) {
+	doc, err := NewDocumentFromText("ab 😀\ncd", 70, 10)
+	require.NoError(t, err)
+	doc.Characters[1].Error = 20
+	doc.Fonts = []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 8}, Name: "Arial"}}
+	doc.FontStyles = []*ocr.FontStyle{{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Style: ocr.ITALIC}}
+
+	data, err := MarshalCompactJSON(doc)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "version": 3,
+  "md5": "KiFXZxddR0gCYBkaXfE5Aw==",
+  "pages": [
+    {"character_span":{"end":8},"width":700,"height":100,"dpi_x":300,"dpi_y":300}
+  ],
+  "runs": [
+    {"text":"ab 😀","boxes":[[0,0,10,10],[10,0,20,10],[20,0,30,10],[30,0,40,10],[30,0,40,10]],"errors":[0,20,0,0,0]},
+    {"text":"\ncd","boxes":[[0,10,10,20],[0,10,10,20],[10,10,20,20]]}
+  ],
+  "fonts": [
+    {"character_span":{"end":8},"name":"Arial"}
+  ],
+  "font_styles": [
+    {"character_span":{"end":2},"style":"ITALIC"}
+  ]
+}
+`, string(data))
+
+	got, err := UnmarshalJSON(data)
+	require.NoError(t, err)
+	assert.True(t, doc.Equal(got))
+}
+
+func TestMarshalCompactJSONInvalidUnicode(t *testing.T) {
+	_, err := MarshalCompactJSON(newTestDocument('a', 0x1F600))
+	assert.Error(t, err)
+	_, err = MarshalCompactJSON(newTestDocument('a', 0xD83D, 'b'))
+	assert.Error(t, err)
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"not json":        `foo`,
+		"unknown field":   `{"version":3,"foo":1}`,
+		"box count":       `{"runs":[{"text":"ab","boxes":[[0,0,1,1]]}]}`,
+		"error count":     `{"runs":[{"text":"a","boxes":[null],"errors":[1,2]}]}`,
+		"box coordinates": `{"runs":[{"text":"a","boxes":[[0,0,1]]}]}`,
+		"both":            `{"characters":[{"unicode":97}],"runs":[{"text":"a","boxes":[null]}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := UnmarshalJSON([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package eocr
+
+import (
+	"crypto/md5"
+	"errors"
+	"unicode"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Merge returns a document holding the pages of docs in order.
+//
+// Characters are concatenated with a space between documents, like the space
+// between paragraphs, unless there is whitespace on either side already.
+// The space is added to the page of the character before it, with an empty
+// box at the end of that character. Spans are moved to the merged characters,
+// pages of tables are renumbered, and the ids of the tables of each document
+// are offset past those of the documents before it so that they stay unique.
+//
+// The version of the merged document is the version of the first document.
+// Its source is the source of the documents when they share one, with the
+// empty source the same as omnipage. Otherwise it is omnipage, since the
+// merged document wasn't converted from a single digital file.
+//
+// The md5 of a merge of a single document is its md5. Otherwise it is the
+// md5 of the md5s of the documents concatenated in order.
+//
+// Missing fonts, font sizes, font styles, tables and table cells are
+// dropped. docs aren't modified, and the merged document shares no messages
+// with them.
+func Merge(docs ...*ocr.Document) (*ocr.Document, error) {
+	if len(docs) == 0 {
+		return nil, errors.New("no documents to merge")
+	}
+	for _, doc := range docs {
+		if doc == nil {
+			return nil, errors.New("cannot merge a nil document")
+		}
+	}
+	merged := &ocr.Document{
+		Version: docs[0].Version,
+		Source:  mergedSource(docs),
+		Md5:     mergedMd5(docs),
+	}
+	var nextID uint32
+	for _, doc := range docs {
+		if n := len(merged.Characters); n > 0 && len(doc.Characters) > 0 && len(merged.Pages) > 0 && !isSpace(merged.Characters[n-1]) && !isSpace(doc.Characters[0]) {
+			var box *ocr.BoundingBox
+			if last := merged.Characters[n-1].GetBoundingBox(); last != nil {
+				box = &ocr.BoundingBox{X1: last.X2, Y1: last.Y1, X2: last.X2, Y2: last.Y2}
+			}
+			merged.Characters = append(merged.Characters, &ocr.Character{Unicode: ' ', BoundingBox: box})
+			// Add the space to the page of the character before it, and move
+			// the empty pages after that page past it.
+			for i := len(merged.Pages) - 1; i >= 0; i-- {
+				span := merged.Pages[i].CharacterSpan
+				if span == nil || span.End != uint32(n) {
+					break
+				}
+				span.End++
+				if span.Start < uint32(n) {
+					break
+				}
+				span.Start++
+			}
+		}
+		offset := uint32(len(merged.Characters))
+		pageOffset := uint32(len(merged.Pages))
+
+		for _, c := range doc.Characters {
+			if c == nil {
+				merged.Characters = append(merged.Characters, nil)
+				continue
+			}
+			merged.Characters = append(merged.Characters, &ocr.Character{
+				Unicode:     c.Unicode,
+				Error:       c.Error,
+				BoundingBox: copyBox(c.BoundingBox),
+			})
+		}
+		for _, p := range doc.Pages {
+			page := &ocr.Page{CharacterSpan: &ocr.Span{Start: offset, End: offset}}
+			if p != nil {
+				*page = *p
+				page.CharacterSpan = offsetSpan(p.CharacterSpan, offset)
+			}
+			merged.Pages = append(merged.Pages, page)
+		}
+		for _, f := range doc.Fonts {
+			if f == nil {
+				continue
+			}
+			font := *f
+			font.CharacterSpan = offsetSpan(f.CharacterSpan, offset)
+			merged.Fonts = append(merged.Fonts, &font)
+		}
+		for _, s := range doc.FontSizes {
+			if s == nil {
+				continue
+			}
+			size := *s
+			size.CharacterSpan = offsetSpan(s.CharacterSpan, offset)
+			merged.FontSizes = append(merged.FontSizes, &size)
+		}
+		for _, s := range doc.FontStyles {
+			if s == nil {
+				continue
+			}
+			style := *s
+			style.CharacterSpan = offsetSpan(s.CharacterSpan, offset)
+			merged.FontStyles = append(merged.FontStyles, &style)
+		}
+
+		idOffset, maxID := nextID, nextID
+		for _, t := range doc.Tables {
+			if t == nil {
+				continue
+			}
+			id := t.Id + idOffset
+			merged.Tables = append(merged.Tables, &ocr.Table{Id: id, PageNumber: t.PageNumber + pageOffset})
+			if id+1 > maxID {
+				maxID = id + 1
+			}
+		}
+		for _, c := range doc.TableCells {
+			if c == nil {
+				continue
+			}
+			cell := *c
+			cell.Id += idOffset
+			cell.BoundingBox = copyBox(c.BoundingBox)
+			if c.BackgroundColor != nil {
+				color := *c.BackgroundColor
+				cell.BackgroundColor = &color
+			}
+			merged.TableCells = append(merged.TableCells, &cell)
+		}
+		nextID = maxID
+	}
+	return merged, nil
+}
+
+// mergedSource returns the source of a merge of docs.
+func mergedSource(docs []*ocr.Document) string {
+	source := docs[0].Source
+	for _, doc := range docs[1:] {
+		if doc.Source == source {
+			continue
+		}
+		if (doc.Source == Empty || doc.Source == Omnipage) && (source == Empty || source == Omnipage) {
+			source = Omnipage
+			continue
+		}
+		return Omnipage
+	}
+	return source
+}
+
+// mergedMd5 returns the md5 of a merge of docs.
+func mergedMd5(docs []*ocr.Document) []byte {
+	if len(docs) == 1 {
+		return append([]byte(nil), docs[0].Md5...)
+	}
+	var data []byte
+	for _, doc := range docs {
+		data = append(data, doc.Md5...)
+	}
+	sum := md5.Sum(data)
+	return sum[:]
+}
+
+// isSpace reports whether c is whitespace, or missing.
+func isSpace(c *ocr.Character) bool {
+	return c == nil || unicode.IsSpace(rune(c.Unicode))
+}
+
+// offsetSpan returns a copy of span moved by offset characters.
+func offsetSpan(span *ocr.Span, offset uint32) *ocr.Span {
+	if span == nil {
+		return nil
+	}
+	return &ocr.Span{Start: span.Start + offset, End: span.End + offset}
+}
@@ -0,0 +1,127 @@
+package eocr
+
+import (
+	"crypto/md5"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestMerge(t *testing.T) {
+	doc := newSplitDocument(t)
+	parts, err := SplitEvery(doc, 1)
+	require.NoError(t, err)
+
+	got, err := Merge(parts...)
+	require.NoError(t, err)
+	assert.Equal(t, Text(doc), Text(got))
+	assert.Equal(t, doc.Pages, got.Pages)
+	assert.Equal(t, []*ocr.Font{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Name: "Arial"},
+		{CharacterSpan: &ocr.Span{Start: 2, End: 4}, Name: "Arial"},
+		{CharacterSpan: &ocr.Span{Start: 4, End: 5}, Name: "Courier", Monospace: true},
+		{CharacterSpan: &ocr.Span{Start: 5, End: 8}, Name: "Courier", Monospace: true},
+	}, got.Fonts)
+	assert.Equal(t, doc.FontStyles, got.FontStyles)
+	// The ids of the tables of the third part are past those of the second.
+	assert.Equal(t, []*ocr.Table{{Id: 7, PageNumber: 1}, {Id: 11, PageNumber: 2}}, got.Tables)
+	assert.Equal(t, []uint32{7, 11, 11}, []uint32{got.TableCells[0].Id, got.TableCells[1].Id, got.TableCells[2].Id})
+	assert.Equal(t, Word2ocr, got.Source)
+	sum := md5.Sum(append(append(append([]byte(nil), parts[0].Md5...), parts[1].Md5...), parts[2].Md5...))
+	assert.Equal(t, sum[:], got.Md5)
+	assert.Empty(t, Validate(got))
+
+	// The merge doesn't share messages with the documents.
+	got.Characters[0].BoundingBox.X1 = 99
+	got.Pages[0].Width = 99
+	got.TableCells[0].BackgroundColor.R = 99
+	again, err := SplitEvery(doc, 1)
+	require.NoError(t, err)
+	assert.Equal(t, again, parts)
+
+	// A single document is copied.
+	got, err = Merge(doc)
+	require.NoError(t, err)
+	assert.Equal(t, doc, got)
+}
+
+func TestMergeSeparator(t *testing.T) {
+	foo, err := NewDocumentFromText("foo")
+	require.NoError(t, err)
+	bar, err := NewDocumentFromText("bar\n")
+	require.NoError(t, err)
+	empty := &ocr.Document{Pages: []*ocr.Page{{CharacterSpan: &ocr.Span{}, Width: 800, Height: 10}}}
+
+	got, err := Merge(foo, empty, bar, foo)
+	require.NoError(t, err)
+	assert.Equal(t, "foo bar\nfoo", Text(got))
+	assert.Equal(t, []*ocr.Span{
+		{Start: 0, End: 4},
+		{Start: 4, End: 4},
+		{Start: 4, End: 8},
+		{Start: 8, End: 11},
+	}, []*ocr.Span{got.Pages[0].CharacterSpan, got.Pages[1].CharacterSpan, got.Pages[2].CharacterSpan, got.Pages[3].CharacterSpan})
+	// The space is at the end of the character before it.
+	last := foo.Characters[2].BoundingBox
+	assert.Equal(t, &ocr.Character{
+		Unicode:     ' ',
+		BoundingBox: &ocr.BoundingBox{X1: last.X2, Y1: last.Y1, X2: last.X2, Y2: last.Y2},
+	}, got.Characters[3])
+	assert.Empty(t, Validate(got))
+}
+
+func TestMergeSource(t *testing.T) {
+	tests := map[string]struct {
+		sources []string
+		want    string
+	}{
+		"same":          {sources: []string{Pdf2ocr, Pdf2ocr}, want: Pdf2ocr},
+		"empty":         {sources: []string{Empty, Empty}, want: Empty},
+		"empty and ocr": {sources: []string{Empty, Omnipage, Empty}, want: Omnipage},
+		"different":     {sources: []string{Word2ocr, Pdf2ocr}, want: Omnipage},
+		"later":         {sources: []string{Word2ocr, Word2ocr, Empty}, want: Omnipage},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var docs []*ocr.Document
+			for _, source := range tt.sources {
+				docs = append(docs, &ocr.Document{Source: source})
+			}
+			got, err := Merge(docs...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Source)
+		})
+	}
+}
+
+func TestMergeNilEntries(t *testing.T) {
+	foo, err := NewDocumentFromText("foo")
+	require.NoError(t, err)
+	foo.Fonts = []*ocr.Font{nil, {CharacterSpan: &ocr.Span{Start: 0, End: 3}, Name: "Arial"}}
+	foo.FontSizes = []*ocr.FontSize{nil}
+	foo.FontStyles = []*ocr.FontStyle{nil}
+	foo.Tables = []*ocr.Table{nil}
+	foo.TableCells = []*ocr.TableCell{nil}
+	bar, err := NewDocumentFromText("bar")
+	require.NoError(t, err)
+
+	got, err := Merge(foo, bar)
+	require.NoError(t, err)
+	assert.Equal(t, "foo bar", Text(got))
+	assert.Equal(t, []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Name: "Arial"}}, got.Fonts)
+	assert.Empty(t, got.FontSizes)
+	assert.Empty(t, got.FontStyles)
+	assert.Empty(t, got.Tables)
+	assert.Empty(t, got.TableCells)
+}
+
+func TestMergeErrors(t *testing.T) {
+	_, err := Merge()
+	assert.EqualError(t, err, "no documents to merge")
+	_, err = Merge(&ocr.Document{}, nil)
+	assert.EqualError(t, err, "cannot merge a nil document")
+}
@@ -1,6 +1,7 @@
 package eocr
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/sha1"
 	"fmt"
@@ -8,9 +9,9 @@ import (
 	"strings"
 
 	"github.com/gogo/protobuf/proto"
-	"github.com/zuvaai/eocr-utils/internal/filetype"
 	"github.com/zuvaai/eocr-utils/internal/gzip"
 	"github.com/zuvaai/eocr-utils/internal/text"
+	"github.com/zuvaai/eocr-utils/pkg/filetype"
 	"github.com/zuvaai/eocr-utils/pkg/ocr"
 )
 
@@ -21,6 +22,8 @@ const (
 	Omnipage = "omnipage"
 	// EOCR file was generated using word2ocr.
 	Word2ocr = "word2ocr"
+	// EOCR file was generated from the text layer of a PDF.
+	Pdf2ocr = "pdf2ocr"
 )
 
 var (
@@ -31,27 +34,31 @@ var (
 	// ErrInvalidChecksum means that the message doesn't match the checksum.
 	ErrInvalidChecksum = fmt.Errorf("data doesn't match checksum")
 	ErrEmptyDocument   = fmt.Errorf("document has zero pages or characters")
+	// ErrUnsupportedFormat means that documents can't be written in the
+	// requested file format.
+	ErrUnsupportedFormat = fmt.Errorf("file format can't hold ocr results")
 )
 
 // headerSize is the size of the header on serialized documents.
 const headerSize = 10
 
 var (
-	headerBytes          = filetype.Types[filetype.EOCR].Magic
+	headerBytes          = filetype.Magic(filetype.EOCR)
 	supportedHeaderBytes = [][]byte{
 		headerBytes,
-		filetype.Types[filetype.KiraOCR].Magic,
+		filetype.Magic(filetype.KiraOCR),
 	}
 )
 
 // ReadFile reads ocr results from a protobuf file and returns a pointer to an
 // unprepared Document.
 func ReadFile(filename string) (*ocr.Document, error) {
-	in, err := os.ReadFile(filename)
+	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
-	return Unmarshal(in)
+	defer f.Close()
+	return NewDecoder(bufio.NewReader(f)).Decode()
 }
 
 const (
@@ -83,6 +90,35 @@ func NewDocumentFromText(content string, args ...int) (*ocr.Document, error) {
 	return text.FromUTF8(content, lineLength, pageLength)
 }
 
+// TextOptions configures NewDocumentFromTextWithOptions.
+type TextOptions struct {
+	// LineLength is the length of each line in characters. Zero means 80.
+	LineLength int
+	// PageLength is the number of lines per page. Zero means 200.
+	PageLength int
+	// LegacyUnicode stores characters outside the Basic Multilingual Plane,
+	// such as emoji, as a single character holding the code point rather
+	// than as a UTF-16 surrogate pair. This is how versions up to v0.0.2
+	// encoded them.
+	LegacyUnicode bool
+}
+
+// NewDocumentFromTextWithOptions creates a new document with the supplied
+// UTF-8 content laid out according to opts.
+func NewDocumentFromTextWithOptions(content string, opts TextOptions) (*ocr.Document, error) {
+	if opts.LineLength == 0 {
+		opts.LineLength = defaultLineLength
+	}
+	if opts.PageLength == 0 {
+		opts.PageLength = defaultPageLength
+	}
+	return text.FromUTF8WithOptions(content, text.Options{
+		LineLength:    opts.LineLength,
+		PageLength:    opts.PageLength,
+		LegacyUnicode: opts.LegacyUnicode,
+	})
+}
+
 // Unmarshal parses a protobuf byte array and returns a pointer to an
 // unpreprepared Document.
 func Unmarshal(data []byte) (*ocr.Document, error) {
@@ -134,20 +170,52 @@ func Verify(data []byte) error {
 
 // Marshal takes a Document and writes it to eocr format.
 func Marshal(doc *ocr.Document) ([]byte, error) {
-	msg, err := proto.Marshal(doc)
+	return encode(doc, headerBytes)
+}
+
+// MarshalOptions configures MarshalWithOptions and NewEncoderWithOptions.
+type MarshalOptions struct {
+	// Format is the file format to write, filetype.EOCR or the legacy
+	// filetype.KiraOCR. Zero means filetype.EOCR.
+	Format filetype.Type
+}
+
+// header returns the header of the format selected by o.
+func (o MarshalOptions) header() ([]byte, error) {
+	switch o.Format {
+	case filetype.Unknown, filetype.EOCR:
+		return headerBytes, nil
+	case filetype.KiraOCR:
+		return filetype.Magic(filetype.KiraOCR), nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// MarshalWithOptions is like Marshal, but writes the file format selected by
+// opts.
+func MarshalWithOptions(doc *ocr.Document, opts MarshalOptions) ([]byte, error) {
+	header, err := opts.header()
 	if err != nil {
 		return nil, err
 	}
-	gzMsg, err := gzip.Compress(msg)
+	return encode(doc, header)
+}
+
+// Convert verifies a serialized document and returns a copy of it in the file
+// format selected by opts. The checksum only covers the compressed message,
+// so only the header changes and the message is copied byte for byte.
+func Convert(data []byte, opts MarshalOptions) ([]byte, error) {
+	header, err := opts.header()
 	if err != nil {
 		return nil, err
 	}
-	checksum := sha1.Sum(gzMsg)
-	data := make([]byte, len(headerBytes)+sha1.Size+len(gzMsg))
-	copy(data, headerBytes)
-	copy(data[headerSize:], checksum[:])
-	copy(data[headerSize+sha1.Size:], gzMsg)
-	return data, nil
+	if err := Verify(data); err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	copy(out, header)
+	copy(out[headerSize:], data[headerSize:])
+	return out, nil
 }
 
 // CompareEOCRMetadata checks if metadata from two EOCRs are identical
@@ -1,11 +1,13 @@
 package eocr
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/zuvaai/eocr-utils/pkg/filetype"
 	"github.com/zuvaai/eocr-utils/pkg/ocr"
 )
 
@@ -94,6 +96,61 @@ func TestReadSupportedLegacyFile(t *testing.T) {
 	require.NotEmpty(t, got.Md5)
 }
 
+func TestMarshalWithOptions(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/jbs.kiraocr")
+	require.NoError(t, err)
+	doc, err := Unmarshal(data)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		format     filetype.Type
+		wantHeader string
+		wantErr    error
+	}{
+		"default": {format: filetype.Unknown, wantHeader: "eocr     \n"},
+		"eocr":    {format: filetype.EOCR, wantHeader: "eocr     \n"},
+		"kiraocr": {format: filetype.KiraOCR, wantHeader: "kiraocr  \n"},
+		"edoc":    {format: filetype.EDoc, wantErr: ErrUnsupportedFormat},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := MarshalWithOptions(doc, MarshalOptions{Format: tt.format})
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHeader, string(got[:headerSize]))
+			require.NoError(t, Verify(got))
+
+			roundTrip, err := Unmarshal(got)
+			require.NoError(t, err)
+			assert.True(t, doc.Equal(roundTrip))
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/jbs.kiraocr")
+	require.NoError(t, err)
+
+	converted, err := Convert(data, MarshalOptions{Format: filetype.EOCR})
+	require.NoError(t, err)
+	assert.Equal(t, headerBytes, converted[:headerSize])
+	assert.Equal(t, data[headerSize:], converted[headerSize:])
+	require.NoError(t, Verify(converted))
+
+	back, err := Convert(converted, MarshalOptions{Format: filetype.KiraOCR})
+	require.NoError(t, err)
+	assert.Equal(t, data, back)
+
+	_, err = Convert(data[:len(data)-1], MarshalOptions{})
+	assert.Equal(t, ErrInvalidChecksum, err)
+	_, err = Convert(data, MarshalOptions{Format: filetype.KiraDoc})
+	assert.Equal(t, ErrUnsupportedFormat, err)
+}
+
 func TestNewDocumentFromText(t *testing.T) {
 	// simple test to check if NewDocumentFromText works. Internal tests of FromUTF8 are more detailed.
 	maxLineSymbols := 70
@@ -146,6 +203,22 @@ func TestNewDocumentFromText(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestNewDocumentFromTextWithOptions(t *testing.T) {
+	doc, err := NewDocumentFromTextWithOptions("a😀", TextOptions{})
+	require.NoError(t, err)
+	require.Len(t, doc.Characters, 3)
+	assert.Equal(t, uint32(0xD83D), doc.Characters[1].Unicode)
+	assert.Equal(t, uint32(0xDE00), doc.Characters[2].Unicode)
+	assert.Equal(t, uint32(800), doc.Pages[0].Width)
+	assert.Empty(t, Validate(doc))
+
+	doc, err = NewDocumentFromTextWithOptions("a😀", TextOptions{LineLength: 10, LegacyUnicode: true})
+	require.NoError(t, err)
+	require.Len(t, doc.Characters, 2)
+	assert.Equal(t, uint32(0x1F600), doc.Characters[1].Unicode)
+	assert.Equal(t, uint32(100), doc.Pages[0].Width)
+}
+
 func TestCompareEOCRMetadata(t *testing.T) {
 	tests := map[string]struct {
 		inputEOCR, refEOCR string
@@ -0,0 +1,194 @@
+package eocr
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// SliceDocument returns a document holding the pages of doc from index
+// fromPage up to, but not including, toPage, as with a Go slice.
+//
+// The characters of the slice are those from the start of its first page to
+// the end of its last page. Page, font, font size and font style spans are
+// moved to the characters of the slice, and spans straddling the cut are
+// clipped to it, while spans outside it are dropped. Tables on the pages of
+// the slice keep their ids and get their new page numbers, and the cells of
+// other tables are dropped. The version and source of doc are kept.
+//
+// A document must have characters, so slicing only pages without any, such
+// as blank scanned pages, returns an error wrapping ErrEmptyDocument. Pages
+// without characters inside a slice that has some are kept.
+//
+// The md5 of a slice of every page is the md5 of doc. Otherwise it is the
+// md5 of the md5 of doc followed by the page range in decimal, as in
+// md5(doc.Md5 + "pages 4-12") for SliceDocument(doc, 4, 12), so that
+// different slices of a document have different md5s and slicing is
+// repeatable.
+//
+// doc isn't modified, and the slice shares no messages with it.
+func SliceDocument(doc *ocr.Document, fromPage, toPage int) (*ocr.Document, error) {
+	if fromPage < 0 || toPage > len(doc.Pages) || fromPage >= toPage {
+		return nil, fmt.Errorf("pages %d to %d do not exist in a document of %d pages", fromPage, toPage, len(doc.Pages))
+	}
+	numChars := uint32(len(doc.Characters))
+	start, end := numChars, uint32(0)
+	for _, p := range doc.Pages[fromPage:toPage] {
+		if s := p.GetCharacterSpan(); s != nil && s.Start < s.End {
+			if s.Start < start {
+				start = s.Start
+			}
+			if s.End > end {
+				end = s.End
+			}
+		}
+	}
+	if end > numChars {
+		end = numChars
+	}
+	if start >= end {
+		return nil, fmt.Errorf("pages %d to %d have no characters: %w", fromPage, toPage, ErrEmptyDocument)
+	}
+
+	slice := &ocr.Document{
+		Version: doc.Version,
+		Source:  doc.Source,
+		Md5:     sliceMd5(doc, fromPage, toPage),
+	}
+	for _, c := range doc.Characters[start:end] {
+		if c == nil {
+			slice.Characters = append(slice.Characters, nil)
+			continue
+		}
+		slice.Characters = append(slice.Characters, &ocr.Character{
+			Unicode:     c.Unicode,
+			Error:       c.Error,
+			BoundingBox: copyBox(c.BoundingBox),
+		})
+	}
+	for _, p := range doc.Pages[fromPage:toPage] {
+		if p == nil {
+			slice.Pages = append(slice.Pages, &ocr.Page{CharacterSpan: &ocr.Span{}})
+			continue
+		}
+		page := *p
+		page.CharacterSpan, _ = moveSpan(p.CharacterSpan, start, end)
+		if page.CharacterSpan == nil {
+			// Keep the page, at the end of the characters before it.
+			var at uint32
+			if n := len(slice.Pages); n > 0 {
+				at = slice.Pages[n-1].CharacterSpan.End
+			}
+			page.CharacterSpan = &ocr.Span{Start: at, End: at}
+		}
+		slice.Pages = append(slice.Pages, &page)
+	}
+
+	for _, f := range doc.Fonts {
+		if span, ok := moveSpan(f.GetCharacterSpan(), start, end); ok {
+			font := *f
+			font.CharacterSpan = span
+			slice.Fonts = append(slice.Fonts, &font)
+		}
+	}
+	for _, s := range doc.FontSizes {
+		if span, ok := moveSpan(s.GetCharacterSpan(), start, end); ok {
+			size := *s
+			size.CharacterSpan = span
+			slice.FontSizes = append(slice.FontSizes, &size)
+		}
+	}
+	for _, s := range doc.FontStyles {
+		if span, ok := moveSpan(s.GetCharacterSpan(), start, end); ok {
+			style := *s
+			style.CharacterSpan = span
+			slice.FontStyles = append(slice.FontStyles, &style)
+		}
+	}
+
+	tables := map[uint32]bool{}
+	for _, t := range doc.Tables {
+		if t != nil && int(t.PageNumber) >= fromPage && int(t.PageNumber) < toPage {
+			tables[t.Id] = true
+			slice.Tables = append(slice.Tables, &ocr.Table{Id: t.Id, PageNumber: t.PageNumber - uint32(fromPage)})
+		}
+	}
+	for _, c := range doc.TableCells {
+		if c == nil || !tables[c.Id] {
+			continue
+		}
+		cell := *c
+		cell.BoundingBox = copyBox(c.BoundingBox)
+		if c.BackgroundColor != nil {
+			color := *c.BackgroundColor
+			cell.BackgroundColor = &color
+		}
+		slice.TableCells = append(slice.TableCells, &cell)
+	}
+	return slice, nil
+}
+
+// SplitEvery splits doc into documents of n pages, the last of which may
+// have fewer. See SliceDocument. A document that would have no characters,
+// such as one of blank scanned pages, is nil instead, so that the document at
+// index i always starts at page i*n.
+func SplitEvery(doc *ocr.Document, n int) ([]*ocr.Document, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("cannot split a document every %d pages", n)
+	}
+	var docs []*ocr.Document
+	for from := 0; from < len(doc.Pages); from += n {
+		to := from + n
+		if to > len(doc.Pages) {
+			to = len(doc.Pages)
+		}
+		slice, err := SliceDocument(doc, from, to)
+		if errors.Is(err, ErrEmptyDocument) {
+			slice, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, slice)
+	}
+	return docs, nil
+}
+
+// sliceMd5 returns the md5 of the slice of doc from page from to page to.
+func sliceMd5(doc *ocr.Document, from, to int) []byte {
+	if from == 0 && to == len(doc.Pages) {
+		return append([]byte(nil), doc.Md5...)
+	}
+	data := append(append([]byte(nil), doc.Md5...), fmt.Sprintf("pages %d-%d", from, to)...)
+	sum := md5.Sum(data)
+	return sum[:]
+}
+
+// moveSpan returns the part of span between the characters start and end,
+// relative to start. It returns false if they don't overlap.
+func moveSpan(span *ocr.Span, start, end uint32) (*ocr.Span, bool) {
+	if span == nil {
+		return nil, false
+	}
+	s, e := span.Start, span.End
+	if s < start {
+		s = start
+	}
+	if e > end {
+		e = end
+	}
+	if s >= e {
+		return nil, false
+	}
+	return &ocr.Span{Start: s - start, End: e - start}, true
+}
+
+func copyBox(b *ocr.BoundingBox) *ocr.BoundingBox {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	return &c
+}
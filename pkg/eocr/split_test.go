@@ -0,0 +1,182 @@
+package eocr
+
+import (
+	"crypto/md5"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// newSplitDocument returns the pages "ab", "\ncd" and "\nef", with a font
+// and a style straddling pages and a table on each of the last two pages.
+func newSplitDocument(t *testing.T) *ocr.Document {
+	t.Helper()
+	doc, err := NewDocumentFromText("ab\ncd\nef", 80, 1)
+	require.NoError(t, err)
+	doc.Source = Word2ocr
+	doc.Fonts = []*ocr.Font{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 4}, Name: "Arial"},
+		{CharacterSpan: &ocr.Span{Start: 4, End: 8}, Name: "Courier", Monospace: true},
+	}
+	doc.FontSizes = []*ocr.FontSize{{CharacterSpan: &ocr.Span{Start: 0, End: 8}, Size_: 10}}
+	doc.FontStyles = []*ocr.FontStyle{{CharacterSpan: &ocr.Span{Start: 6, End: 7}, Style: ocr.BOLD}}
+	doc.Tables = []*ocr.Table{{Id: 7, PageNumber: 1}, {Id: 3, PageNumber: 2}}
+	doc.TableCells = []*ocr.TableCell{
+		{Id: 7, BoundingBox: &ocr.BoundingBox{X2: 20, Y2: 10}, BackgroundColor: &ocr.Color{R: 1}},
+		{Id: 3, BoundingBox: &ocr.BoundingBox{X2: 10, Y2: 10}},
+		{Id: 3, BoundingBox: &ocr.BoundingBox{X1: 10, X2: 20, Y2: 10}},
+	}
+	return doc
+}
+
+func TestSliceDocument(t *testing.T) {
+	doc := newSplitDocument(t)
+
+	tests := map[string]struct {
+		from, to       int
+		wantText       string
+		wantPages      []*ocr.Page
+		wantFonts      []*ocr.Font
+		wantFontStyles []*ocr.FontStyle
+		wantTables     []*ocr.Table
+		wantCells      int
+		wantMd5        []byte
+	}{
+		"first page": {
+			from: 0, to: 1,
+			wantText:  "ab",
+			wantPages: []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Width: 800, Height: 10, DpiX: 300, DpiY: 300}},
+			wantFonts: []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Name: "Arial"}},
+			wantMd5:   md5Of(doc.Md5, "pages 0-1"),
+		},
+		"last pages": {
+			from: 1, to: 3,
+			wantText: "\ncd\nef",
+			wantPages: []*ocr.Page{
+				{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Width: 800, Height: 10, DpiX: 300, DpiY: 300},
+				{CharacterSpan: &ocr.Span{Start: 3, End: 6}, Width: 800, Height: 10, DpiX: 300, DpiY: 300},
+			},
+			wantFonts: []*ocr.Font{
+				{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Name: "Arial"},
+				{CharacterSpan: &ocr.Span{Start: 2, End: 6}, Name: "Courier", Monospace: true},
+			},
+			wantFontStyles: []*ocr.FontStyle{{CharacterSpan: &ocr.Span{Start: 4, End: 5}, Style: ocr.BOLD}},
+			wantTables:     []*ocr.Table{{Id: 7, PageNumber: 0}, {Id: 3, PageNumber: 1}},
+			wantCells:      3,
+			wantMd5:        md5Of(doc.Md5, "pages 1-3"),
+		},
+		"middle page": {
+			from: 1, to: 2,
+			wantText:   "\ncd",
+			wantPages:  []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: 3}, Width: 800, Height: 10, DpiX: 300, DpiY: 300}},
+			wantFonts:  []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Name: "Arial"}, {CharacterSpan: &ocr.Span{Start: 2, End: 3}, Name: "Courier", Monospace: true}},
+			wantTables: []*ocr.Table{{Id: 7, PageNumber: 0}},
+			wantCells:  1,
+			wantMd5:    md5Of(doc.Md5, "pages 1-2"),
+		},
+		"every page": {
+			from: 0, to: 3,
+			wantText:       "ab\ncd\nef",
+			wantPages:      doc.Pages,
+			wantFonts:      doc.Fonts,
+			wantFontStyles: doc.FontStyles,
+			wantTables:     doc.Tables,
+			wantCells:      3,
+			wantMd5:        doc.Md5,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := SliceDocument(doc, tt.from, tt.to)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantText, Text(got))
+			assert.Equal(t, tt.wantPages, got.Pages)
+			assert.Equal(t, tt.wantFonts, got.Fonts)
+			assert.Equal(t, []*ocr.FontSize{{CharacterSpan: &ocr.Span{Start: 0, End: uint32(len(got.Characters))}, Size_: 10}}, got.FontSizes)
+			assert.Equal(t, tt.wantFontStyles, got.FontStyles)
+			assert.Equal(t, tt.wantTables, got.Tables)
+			assert.Len(t, got.TableCells, tt.wantCells)
+			assert.Equal(t, tt.wantMd5, got.Md5)
+			assert.Equal(t, Word2ocr, got.Source)
+			assert.Empty(t, Validate(got))
+		})
+	}
+
+	// The slice doesn't share messages with the document.
+	got, err := SliceDocument(doc, 1, 2)
+	require.NoError(t, err)
+	got.Characters[1].BoundingBox.X1 = 99
+	got.Pages[0].Width = 99
+	got.TableCells[0].BackgroundColor.R = 99
+	assert.Equal(t, newSplitDocument(t), doc)
+}
+
+func md5Of(md5Bytes []byte, suffix string) []byte {
+	sum := md5.Sum(append(append([]byte(nil), md5Bytes...), suffix...))
+	return sum[:]
+}
+
+func TestSliceDocumentErrors(t *testing.T) {
+	doc := newSplitDocument(t)
+
+	tests := map[string]struct {
+		from, to int
+		wantErr  string
+	}{
+		"negative": {from: -1, to: 1, wantErr: "pages -1 to 1 do not exist in a document of 3 pages"},
+		"past end": {from: 2, to: 4, wantErr: "pages 2 to 4 do not exist in a document of 3 pages"},
+		"empty":    {from: 1, to: 1, wantErr: "pages 1 to 1 do not exist in a document of 3 pages"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := SliceDocument(doc, tt.from, tt.to)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestSliceDocumentEmptyPages(t *testing.T) {
+	doc := newSplitDocument(t)
+	// A scanned page without text between the first two.
+	doc.Pages = append(doc.Pages[:1], append([]*ocr.Page{{CharacterSpan: &ocr.Span{Start: 2, End: 2}}}, doc.Pages[1:]...)...)
+
+	got, err := SliceDocument(doc, 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "\ncd", Text(got))
+	assert.Equal(t, &ocr.Span{Start: 0, End: 0}, got.Pages[0].CharacterSpan)
+	assert.Equal(t, &ocr.Span{Start: 0, End: 3}, got.Pages[1].CharacterSpan)
+
+	// A slice of only the blank page would have no characters.
+	_, err = SliceDocument(doc, 1, 2)
+	assert.ErrorIs(t, err, ErrEmptyDocument)
+	assert.EqualError(t, err, "pages 1 to 2 have no characters: "+ErrEmptyDocument.Error())
+	// SplitEvery leaves a gap for it.
+	docs, err := SplitEvery(doc, 1)
+	require.NoError(t, err)
+	require.Len(t, docs, 4)
+	assert.Nil(t, docs[1])
+	assert.Equal(t, "\ncd", Text(docs[2]))
+}
+
+func TestSplitEvery(t *testing.T) {
+	doc := newSplitDocument(t)
+
+	docs, err := SplitEvery(doc, 2)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "ab\ncd", Text(docs[0]))
+	assert.Equal(t, "\nef", Text(docs[1]))
+	assert.Equal(t, []*ocr.Table{{Id: 3, PageNumber: 0}}, docs[1].Tables)
+
+	docs, err = SplitEvery(doc, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []*ocr.Document{doc}, docs)
+
+	_, err = SplitEvery(doc, 0)
+	assert.EqualError(t, err, "cannot split a document every 0 pages")
+}
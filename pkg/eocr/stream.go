@@ -0,0 +1,168 @@
+package eocr
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"errors"
+	"io"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// A Decoder reads an eocr document from an input stream. The header and
+// checksum are read first and the gzip payload is decompressed as it is read,
+// so the compressed file never needs to be held in memory.
+type Decoder struct {
+	r io.Reader
+}
+
+// NewDecoder returns a new decoder that reads from r. A stream holds exactly
+// one document, so the decoder reads r until EOF.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Decode reads the next document from its input. The checksum is verified
+// against the payload as it flows through the decoder, and ErrInvalidChecksum
+// is returned if they do not match. Like Unmarshal, a document without pages
+// or characters is returned as a completely empty document.
+func (d *Decoder) Decode() (*ocr.Document, error) {
+	prefix := make([]byte, headerSize+sha1.Size)
+	if _, err := io.ReadFull(d.r, prefix); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrTooSmall
+		}
+		return nil, err
+	}
+	if !validateSupportedHeaders(prefix[:headerSize]) {
+		return nil, ErrInvalidHeader
+	}
+	checksum := prefix[headerSize:]
+
+	h := sha1.New()
+	in := &readErrReader{r: d.r}
+	payload := io.TeeReader(in, h)
+	msg, err := uncompress(payload)
+	// Drain whatever follows the gzip stream so the checksum covers the whole
+	// payload, as it does in Verify.
+	if _, drainErr := io.Copy(io.Discard, payload); drainErr != nil && err == nil {
+		err = drainErr
+	}
+	if in.err != nil {
+		// The payload wasn't read to the end, so the checksum can't be
+		// compared.
+		return nil, in.err
+	}
+	if !bytes.Equal(checksum, h.Sum(nil)) {
+		// A corrupt payload usually also fails to decompress; report the
+		// checksum mismatch since it is the root cause.
+		return nil, ErrInvalidChecksum
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &ocr.Document{}
+	if err := proto.Unmarshal(msg, doc); err != nil {
+		return nil, err
+	}
+
+	if len(doc.Pages) == 0 || len(doc.Characters) == 0 {
+		// return a completely empty document
+		return &ocr.Document{}, nil
+	}
+
+	return doc, nil
+}
+
+// readErrReader records the first error other than io.EOF returned by r.
+type readErrReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *readErrReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// uncompress reads a single gzip stream from r and returns its contents.
+func uncompress(r io.Reader) ([]byte, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	zr.Multistream(false)
+	msg, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	if err := zr.Close(); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// An Encoder writes eocr documents to an output stream.
+type Encoder struct {
+	w    io.Writer
+	opts MarshalOptions
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// NewEncoderWithOptions returns a new encoder that writes to w in the file
+// format selected by opts.
+func NewEncoderWithOptions(w io.Writer, opts MarshalOptions) *Encoder {
+	return &Encoder{w: w, opts: opts}
+}
+
+// Encode writes the eocr encoding of doc to the stream. Since the checksum
+// precedes the payload, the compressed payload is buffered before being
+// written in a single call.
+func (e *Encoder) Encode(doc *ocr.Document) error {
+	data, err := MarshalWithOptions(doc, e.opts)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+// encode serializes doc into a single buffer holding header, the checksum and
+// the compressed message. Space for the header and checksum is reserved up
+// front and the checksum is computed while compressing, so the message is
+// never copied after compression.
+func encode(doc *ocr.Document, header []byte) ([]byte, error) {
+	msg, err := proto.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.Write(make([]byte, sha1.Size))
+	h := sha1.New()
+	if err := compress(io.MultiWriter(&buf, h), msg); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	copy(data[headerSize:], h.Sum(nil))
+	return data, nil
+}
+
+// compress writes the gzip compression of msg to w.
+func compress(w io.Writer, msg []byte) error {
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(msg); err != nil {
+		return err
+	}
+	return zw.Close()
+}
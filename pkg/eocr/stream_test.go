@@ -0,0 +1,111 @@
+package eocr
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/filetype"
+)
+
+func TestDecoder(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/simple-doc.kiraocr")
+	require.NoError(t, err)
+	want, err := Unmarshal(data)
+	require.NoError(t, err)
+
+	got, err := NewDecoder(bytes.NewReader(data)).Decode()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestDecoderErrors(t *testing.T) {
+	doc, err := NewDocumentFromText("foo bar baz")
+	require.NoError(t, err)
+	data, err := Marshal(doc)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		data    []byte
+		wantErr error
+	}{
+		"empty": {
+			data:    nil,
+			wantErr: ErrTooSmall,
+		},
+		"too small": {
+			data:    data[:headerSize+5],
+			wantErr: ErrTooSmall,
+		},
+		"invalid header": {
+			data:    append([]byte("aaaaaaaaaa"), data[headerSize:]...),
+			wantErr: ErrInvalidHeader,
+		},
+		"truncated payload": {
+			data:    data[:len(data)-4],
+			wantErr: ErrInvalidChecksum,
+		},
+		"trailing garbage": {
+			data:    append(append([]byte{}, data...), 'x'),
+			wantErr: ErrInvalidChecksum,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewDecoder(bytes.NewReader(tt.data)).Decode()
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestDecoderReadError(t *testing.T) {
+	doc, err := NewDocumentFromText("foo bar baz")
+	require.NoError(t, err)
+	data, err := Marshal(doc)
+	require.NoError(t, err)
+	readErr := errors.New("connection reset")
+
+	// A read failing in the payload, or in what follows it, is reported
+	// rather than a checksum mismatch.
+	for _, n := range []int{headerSize + 30, len(data)} {
+		r := io.MultiReader(bytes.NewReader(data[:n]), iotest.ErrReader(readErr))
+		_, err := NewDecoder(r).Decode()
+		assert.Equal(t, readErr, err, n)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	doc, err := NewDocumentFromText("foo bar baz")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, NewEncoder(&buf).Encode(doc))
+	require.NoError(t, Verify(buf.Bytes()))
+
+	marshaled, err := Marshal(doc)
+	require.NoError(t, err)
+	assert.Equal(t, marshaled, buf.Bytes())
+
+	got, err := NewDecoder(&buf).Decode()
+	require.NoError(t, err)
+	assert.Equal(t, doc, got)
+}
+
+func TestEncoderWithOptions(t *testing.T) {
+	doc, err := NewDocumentFromText("foo bar baz")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, NewEncoderWithOptions(&buf, MarshalOptions{Format: filetype.KiraOCR}).Encode(doc))
+	assert.Equal(t, "kiraocr  \n", buf.String()[:headerSize])
+
+	got, err := NewDecoder(&buf).Decode()
+	require.NoError(t, err)
+	assert.Equal(t, doc, got)
+}
@@ -0,0 +1,152 @@
+package eocr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/zuvaai/eocr-utils/internal/span"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Text returns the text of doc as a Go string. Characters hold UTF-16 code
+// units, so surrogate pairs are joined into a single rune. Unpaired
+// surrogates and missing characters are replaced by utf8.RuneError, while
+// values above 0xFFFF, as written by older versions of NewDocumentFromText,
+// are decoded as runes. Text is the inverse of NewDocumentFromText.
+func Text(doc *ocr.Document) string {
+	return decodeCharacters(doc.Characters, 0).Text
+}
+
+// PageText returns the text of the page with the given index, starting from 0.
+func PageText(doc *ocr.Document, page int) (string, error) {
+	m, err := PageTextWithMapping(doc, page)
+	if err != nil {
+		return "", err
+	}
+	return m.Text, nil
+}
+
+// TextMapping holds text extracted from a document along with what is needed
+// to map offsets in the text back to character indexes in the document.
+type TextMapping struct {
+	// Text is the extracted text.
+	Text string
+	// first is the index of the character the text starts at.
+	first int
+	// numChars is the number of characters the text was extracted from.
+	numChars int
+	// runeOffsets holds the byte offset of each rune in Text.
+	runeOffsets []int
+	// runeChars holds the index, relative to first, of the first character
+	// of each rune in Text.
+	runeChars []int
+}
+
+// TextWithMapping returns the text of doc with a mapping back to its
+// characters.
+func TextWithMapping(doc *ocr.Document) *TextMapping {
+	return decodeCharacters(doc.Characters, 0)
+}
+
+// PageTextWithMapping returns the text of the page with the given index with
+// a mapping back to the characters of the document.
+func PageTextWithMapping(doc *ocr.Document, page int) (*TextMapping, error) {
+	if page < 0 || page >= len(doc.Pages) {
+		return nil, fmt.Errorf("page %d does not exist in a document of %d pages", page, len(doc.Pages))
+	}
+	p := doc.Pages[page]
+	if p == nil || p.CharacterSpan == nil {
+		return nil, fmt.Errorf("page %d has no character span", page)
+	}
+	start, end := span.Clamp(p.CharacterSpan, uint32(len(doc.Characters)))
+	return decodeCharacters(doc.Characters[start:end], int(start)), nil
+}
+
+// ByteToCharacter returns the index of the document character that produced
+// the byte at offset in Text. An offset equal to the length of Text maps to the
+// index following the last character. It returns false if offset is out of
+// range.
+func (m *TextMapping) ByteToCharacter(offset int) (int, bool) {
+	if offset < 0 || offset > len(m.Text) {
+		return 0, false
+	}
+	if offset == len(m.Text) {
+		return m.first + m.numChars, true
+	}
+	// Find the rune containing the byte.
+	r := sort.Search(len(m.runeOffsets), func(i int) bool { return m.runeOffsets[i] > offset }) - 1
+	return m.runeToCharacter(r), true
+}
+
+// RuneToCharacter returns the index of the document character that produced
+// the rune at offset, counted in runes, in Text. An offset equal to the number
+// of runes maps to the index following the last character. It returns false if
+// offset is out of range.
+func (m *TextMapping) RuneToCharacter(offset int) (int, bool) {
+	if offset < 0 || offset > len(m.runeChars) {
+		return 0, false
+	}
+	return m.runeToCharacter(offset), true
+}
+
+// CharacterToByte returns the byte offset in Text of the rune produced by the
+// document character at index. The second character of a surrogate pair maps
+// to the same offset as the first. It returns false if the character is not
+// part of Text.
+func (m *TextMapping) CharacterToByte(index int) (int, bool) {
+	index -= m.first
+	if index < 0 || index > m.numChars {
+		return 0, false
+	}
+	if index == m.numChars {
+		return len(m.Text), true
+	}
+	r := sort.Search(len(m.runeChars), func(i int) bool { return m.runeChars[i] > index }) - 1
+	return m.runeOffsets[r], true
+}
+
+func (m *TextMapping) runeToCharacter(r int) int {
+	if r >= len(m.runeChars) {
+		return m.first + m.numChars
+	}
+	return m.first + m.runeChars[r]
+}
+
+// decodeCharacters decodes the UTF-16 code units of chars, the first of which
+// is the character at index first of the document.
+func decodeCharacters(chars []*ocr.Character, first int) *TextMapping {
+	m := &TextMapping{
+		first:       first,
+		numChars:    len(chars),
+		runeOffsets: make([]int, 0, len(chars)),
+		runeChars:   make([]int, 0, len(chars)),
+	}
+	var sb strings.Builder
+	sb.Grow(len(chars))
+	for i := 0; i < len(chars); i++ {
+		start := i
+		r := utf8.RuneError
+		if c := chars[i]; c != nil {
+			r = rune(c.Unicode)
+			if utf16.IsSurrogate(r) {
+				r = utf8.RuneError
+				if i+1 < len(chars) && chars[i+1] != nil {
+					if pair := utf16.DecodeRune(rune(c.Unicode), rune(chars[i+1].Unicode)); pair != utf8.RuneError {
+						r = pair
+						i++
+					}
+				}
+			} else if !utf8.ValidRune(r) {
+				r = utf8.RuneError
+			}
+		}
+		m.runeOffsets = append(m.runeOffsets, sb.Len())
+		m.runeChars = append(m.runeChars, start)
+		sb.WriteRune(r)
+	}
+	m.Text = sb.String()
+	return m
+}
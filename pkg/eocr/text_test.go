@@ -0,0 +1,133 @@
+package eocr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func newTestDocument(units ...uint32) *ocr.Document {
+	doc := &ocr.Document{
+		Version: 3,
+		Pages:   []*ocr.Page{{CharacterSpan: &ocr.Span{Start: 0, End: uint32(len(units))}}},
+	}
+	for _, u := range units {
+		doc.Characters = append(doc.Characters, &ocr.Character{Unicode: u})
+	}
+	return doc
+}
+
+func TestText(t *testing.T) {
+	tests := map[string]struct {
+		units []uint32
+		want  string
+	}{
+		"empty": {
+			units: nil,
+			want:  "",
+		},
+		"ascii": {
+			units: []uint32{'f', 'o', 'o'},
+			want:  "foo",
+		},
+		"bmp": {
+			units: []uint32{'©', ' ', '収'},
+			want:  "© 収",
+		},
+		"surrogate pair": {
+			units: []uint32{'a', 0xD83D, 0xDE00, 'b'},
+			want:  "a😀b",
+		},
+		"legacy astral value": {
+			units: []uint32{'a', 0x1F600, 'b'},
+			want:  "a😀b",
+		},
+		"unpaired high surrogate": {
+			units: []uint32{'a', 0xD83D, 'b'},
+			want:  "a�b",
+		},
+		"unpaired low surrogate": {
+			units: []uint32{'a', 0xDE00, 'b'},
+			want:  "a�b",
+		},
+		"invalid value": {
+			units: []uint32{0x110000},
+			want:  "�",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Text(newTestDocument(tt.units...)))
+		})
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	for _, s := range []string{
+		"foo bar baz",
+		"foo\r\nbar\n\nbaz",
+		"収容人数 ：消防法 上の定員",
+		"emoji 😀 and 𠀋 extension b",
+	} {
+		doc, err := NewDocumentFromText(s, 5, 2)
+		require.NoError(t, err)
+		assert.Equal(t, s, Text(doc))
+	}
+}
+
+func TestPageText(t *testing.T) {
+	doc, err := NewDocumentFromText("foo beer baz buz", 5, 2)
+	require.NoError(t, err)
+
+	got, err := PageText(doc, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "foo beer ", got)
+	got, err = PageText(doc, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "baz buz", got)
+	_, err = PageText(doc, 2)
+	assert.Error(t, err)
+}
+
+func TestTextMapping(t *testing.T) {
+	doc := newTestDocument('a', 0xD83D, 0xDE00, '収', 'b')
+	doc.Pages = []*ocr.Page{
+		{CharacterSpan: &ocr.Span{Start: 0, End: 1}},
+		{CharacterSpan: &ocr.Span{Start: 1, End: 5}},
+	}
+
+	m := TextWithMapping(doc)
+	require.Equal(t, "a😀収b", m.Text)
+	for offset, want := range map[int]int{0: 0, 1: 1, 4: 1, 5: 3, 7: 3, 8: 4, 9: 5} {
+		got, ok := m.ByteToCharacter(offset)
+		assert.True(t, ok)
+		assert.Equal(t, want, got, "byte offset %d", offset)
+	}
+	_, ok := m.ByteToCharacter(10)
+	assert.False(t, ok)
+	for offset, want := range map[int]int{0: 0, 1: 1, 2: 3, 3: 4, 4: 5} {
+		got, ok := m.RuneToCharacter(offset)
+		assert.True(t, ok)
+		assert.Equal(t, want, got, "rune offset %d", offset)
+	}
+	for index, want := range map[int]int{0: 0, 1: 1, 2: 1, 3: 5, 4: 8, 5: 9} {
+		got, ok := m.CharacterToByte(index)
+		assert.True(t, ok)
+		assert.Equal(t, want, got, "character %d", index)
+	}
+
+	m, err := PageTextWithMapping(doc, 1)
+	require.NoError(t, err)
+	require.Equal(t, "😀収b", m.Text)
+	got, ok := m.ByteToCharacter(4)
+	assert.True(t, ok)
+	assert.Equal(t, 3, got)
+	_, ok = m.CharacterToByte(0)
+	assert.False(t, ok)
+	got, ok = m.CharacterToByte(4)
+	assert.True(t, ok)
+	assert.Equal(t, 7, got)
+}
@@ -0,0 +1,235 @@
+package eocr
+
+import (
+	"fmt"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// documentVersion is the only supported value of Document.Version.
+const documentVersion = 3
+
+// maxCharacterError is the largest valid value of Character.Error.
+const maxCharacterError = 100
+
+// Severity indicates how serious a validation problem is.
+type Severity int
+
+const (
+	// SeverityError means the document breaks a requirement of the format
+	// and should be rejected.
+	SeverityError Severity = iota
+	// SeverityWarning means the document is usable but likely to be
+	// interpreted incorrectly.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// ValidationError describes a single structural problem in a document. Path
+// addresses the offending field using the field names from
+// recognition_results.proto, e.g. "pages[3].character_span.end".
+type ValidationError struct {
+	Path     string
+	Severity Severity
+	Message  string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Severity, e.Path, e.Message)
+}
+
+// Validate checks doc against the requirements documented in
+// recognition_results.proto and returns every problem found, in document
+// order. It returns nil if the document is valid.
+func Validate(doc *ocr.Document) []ValidationError {
+	v := &validator{doc: doc}
+	v.validate()
+	return v.errs
+}
+
+// HasErrors reports whether errs contains a problem of SeverityError.
+func HasErrors(errs []ValidationError) bool {
+	for _, err := range errs {
+		if err.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+type validator struct {
+	doc  *ocr.Document
+	errs []ValidationError
+}
+
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{Path: path, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{Path: path, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate() {
+	doc := v.doc
+	if doc.Version != documentVersion {
+		v.errorf("version", "must be %d, got %d", documentVersion, doc.Version)
+	}
+	if len(doc.Characters) == 0 {
+		v.errorf("characters", "document has no characters")
+	}
+	if len(doc.Pages) == 0 {
+		v.errorf("pages", "document has no pages")
+	}
+	if len(doc.Md5) == 0 {
+		v.errorf("md5", "md5 is required")
+	}
+	switch doc.Source {
+	case Empty, Omnipage, Word2ocr, Pdf2ocr:
+	default:
+		v.errorf("source", "unknown source %q", doc.Source)
+	}
+	v.validateCharacters()
+	v.validatePages()
+	v.validateTables()
+	for i, f := range doc.Fonts {
+		v.validateSpan(fmt.Sprintf("fonts[%d].character_span", i), f.CharacterSpan)
+	}
+	for i, f := range doc.FontSizes {
+		v.validateSpan(fmt.Sprintf("font_sizes[%d].character_span", i), f.CharacterSpan)
+	}
+	for i, f := range doc.FontStyles {
+		v.validateSpan(fmt.Sprintf("font_styles[%d].character_span", i), f.CharacterSpan)
+	}
+}
+
+func (v *validator) validateCharacters() {
+	for i, c := range v.doc.Characters {
+		if c == nil {
+			v.errorf(fmt.Sprintf("characters[%d]", i), "character is missing")
+			continue
+		}
+		if c.Error > maxCharacterError {
+			v.errorf(fmt.Sprintf("characters[%d].error", i), "must be between 0 and %d, got %d", maxCharacterError, c.Error)
+		}
+		if c.Unicode > 0xFFFF {
+			v.warnf(fmt.Sprintf("characters[%d].unicode", i), "%#x is not a UTF-16 code unit", c.Unicode)
+		}
+	}
+}
+
+// validatePages checks that the page spans are well formed, contiguous and
+// cover every character, and that characters lie within their page.
+func (v *validator) validatePages() {
+	numChars := uint32(len(v.doc.Characters))
+	next := uint32(0)
+	for i, p := range v.doc.Pages {
+		path := fmt.Sprintf("pages[%d]", i)
+		if p == nil {
+			v.errorf(path, "page is missing")
+			continue
+		}
+		if !v.validateSpan(path+".character_span", p.CharacterSpan) {
+			continue
+		}
+		if p.CharacterSpan.Start != next {
+			v.errorf(path+".character_span.start", "must be %d to follow the previous page, got %d", next, p.CharacterSpan.Start)
+		}
+		next = p.CharacterSpan.End
+		if p.Width == 0 || p.Height == 0 {
+			v.warnf(path, "page has no size, skipping bounding box checks")
+			continue
+		}
+		for c := p.CharacterSpan.Start; c < p.CharacterSpan.End; c++ {
+			if char := v.doc.Characters[c]; char != nil {
+				v.validateBoundingBox(fmt.Sprintf("characters[%d].bounding_box", c), char.BoundingBox, p)
+			}
+		}
+	}
+	if len(v.doc.Pages) > 0 && next != numChars {
+		v.errorf(fmt.Sprintf("pages[%d].character_span.end", len(v.doc.Pages)-1), "pages cover %d of %d characters", next, numChars)
+	}
+}
+
+func (v *validator) validateTables() {
+	ids := make(map[uint32]*ocr.Table, len(v.doc.Tables))
+	for i, t := range v.doc.Tables {
+		path := fmt.Sprintf("tables[%d]", i)
+		if t == nil {
+			v.errorf(path, "table is missing")
+			continue
+		}
+		if _, ok := ids[t.Id]; ok {
+			v.errorf(path+".id", "duplicate table id %d", t.Id)
+		}
+		ids[t.Id] = t
+		if int(t.PageNumber) >= len(v.doc.Pages) {
+			v.errorf(path+".page_number", "page %d does not exist", t.PageNumber)
+		}
+	}
+	for i, c := range v.doc.TableCells {
+		path := fmt.Sprintf("table_cells[%d]", i)
+		if c == nil {
+			v.errorf(path, "table cell is missing")
+			continue
+		}
+		t, ok := ids[c.Id]
+		if !ok {
+			v.errorf(path+".id", "no table with id %d", c.Id)
+			continue
+		}
+		if int(t.PageNumber) < len(v.doc.Pages) {
+			if p := v.doc.Pages[t.PageNumber]; p != nil && p.Width > 0 && p.Height > 0 {
+				v.validateBoundingBox(path+".bounding_box", c.BoundingBox, p)
+			}
+		}
+	}
+}
+
+// validateSpan checks that span is present, ordered and within the
+// characters of the document. It returns false if the span can't be used to
+// index the characters.
+func (v *validator) validateSpan(path string, span *ocr.Span) bool {
+	if span == nil {
+		v.errorf(path, "span is required")
+		return false
+	}
+	ok := true
+	if span.Start > span.End {
+		v.errorf(path+".start", "start %d is after end %d", span.Start, span.End)
+		ok = false
+	}
+	if numChars := uint32(len(v.doc.Characters)); span.End > numChars {
+		v.errorf(path+".end", "end %d is beyond the %d characters of the document", span.End, numChars)
+		ok = false
+	}
+	return ok
+}
+
+func (v *validator) validateBoundingBox(path string, bb *ocr.BoundingBox, p *ocr.Page) {
+	if bb == nil {
+		v.warnf(path, "bounding box is missing")
+		return
+	}
+	if bb.X1 > bb.X2 {
+		v.errorf(path+".x1", "x1 %d is right of x2 %d", bb.X1, bb.X2)
+	}
+	if bb.Y1 > bb.Y2 {
+		v.errorf(path+".y1", "y1 %d is below y2 %d", bb.Y1, bb.Y2)
+	}
+	if bb.X2 > p.Width {
+		v.errorf(path+".x2", "x2 %d is outside the page width %d", bb.X2, p.Width)
+	}
+	if bb.Y2 > p.Height {
+		v.errorf(path+".y2", "y2 %d is outside the page height %d", bb.Y2, p.Height)
+	}
+}
@@ -0,0 +1,118 @@
+package eocr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		transform func(*ocr.Document)
+		want      []ValidationError
+	}{
+		"ok": {
+			transform: func(doc *ocr.Document) {},
+		},
+		"wrong version": {
+			transform: func(doc *ocr.Document) { doc.Version = 2 },
+			want: []ValidationError{
+				{Path: "version", Severity: SeverityError, Message: "must be 3, got 2"},
+			},
+		},
+		"unknown source": {
+			transform: func(doc *ocr.Document) { doc.Source = "tesseract" },
+			want: []ValidationError{
+				{Path: "source", Severity: SeverityError, Message: `unknown source "tesseract"`},
+			},
+		},
+		"character error too large": {
+			transform: func(doc *ocr.Document) { doc.Characters[1].Error = 101 },
+			want: []ValidationError{
+				{Path: "characters[1].error", Severity: SeverityError, Message: "must be between 0 and 100, got 101"},
+			},
+		},
+		"astral character": {
+			transform: func(doc *ocr.Document) { doc.Characters[0].Unicode = 0x1F600 },
+			want: []ValidationError{
+				{Path: "characters[0].unicode", Severity: SeverityWarning, Message: "0x1f600 is not a UTF-16 code unit"},
+			},
+		},
+		"page gap": {
+			transform: func(doc *ocr.Document) { doc.Pages[1].CharacterSpan.Start++ },
+			want: []ValidationError{
+				{Path: "pages[1].character_span.start", Severity: SeverityError, Message: "must be 9 to follow the previous page, got 10"},
+			},
+		},
+		"pages don't cover characters": {
+			transform: func(doc *ocr.Document) { doc.Pages[1].CharacterSpan.End-- },
+			want: []ValidationError{
+				{Path: "pages[1].character_span.end", Severity: SeverityError, Message: "pages cover 15 of 16 characters"},
+			},
+		},
+		"page span out of bounds": {
+			transform: func(doc *ocr.Document) { doc.Pages[1].CharacterSpan.End = 20 },
+			want: []ValidationError{
+				{Path: "pages[1].character_span.end", Severity: SeverityError, Message: "end 20 is beyond the 16 characters of the document"},
+				{Path: "pages[1].character_span.end", Severity: SeverityError, Message: "pages cover 9 of 16 characters"},
+			},
+		},
+		"inverted font span": {
+			transform: func(doc *ocr.Document) {
+				doc.Fonts = []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 4, End: 2}}}
+			},
+			want: []ValidationError{
+				{Path: "fonts[0].character_span.start", Severity: SeverityError, Message: "start 4 is after end 2"},
+			},
+		},
+		"character outside page": {
+			transform: func(doc *ocr.Document) { doc.Characters[2].BoundingBox.X2 = 1000 },
+			want: []ValidationError{
+				{Path: "characters[2].bounding_box.x2", Severity: SeverityError, Message: "x2 1000 is outside the page width 50"},
+			},
+		},
+		"tables": {
+			transform: func(doc *ocr.Document) {
+				doc.Tables = []*ocr.Table{{Id: 1, PageNumber: 0}, {Id: 1, PageNumber: 2}}
+				doc.TableCells = []*ocr.TableCell{
+					{Id: 1, BoundingBox: &ocr.BoundingBox{X1: 0, Y1: 0, X2: 10, Y2: 10}},
+					{Id: 3},
+				}
+			},
+			want: []ValidationError{
+				{Path: "tables[1].id", Severity: SeverityError, Message: "duplicate table id 1"},
+				{Path: "tables[1].page_number", Severity: SeverityError, Message: "page 2 does not exist"},
+				{Path: "table_cells[1].id", Severity: SeverityError, Message: "no table with id 3"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			doc, err := NewDocumentFromText("foo beer baz buz", 5, 2)
+			require.NoError(t, err)
+			tt.transform(doc)
+			got := Validate(doc)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateEmptyDocument(t *testing.T) {
+	errs := Validate(&ocr.Document{})
+	assert.True(t, HasErrors(errs))
+	var paths []string
+	for _, err := range errs {
+		paths = append(paths, err.Path)
+	}
+	assert.Equal(t, []string{"version", "characters", "pages", "md5"}, paths)
+}
+
+func TestValidateLegacyFile(t *testing.T) {
+	doc, err := ReadFile("../../testdata/simple-doc.kiraocr")
+	require.NoError(t, err)
+	assert.False(t, HasErrors(Validate(doc)))
+}
@@ -0,0 +1,180 @@
+// Package filetype identifies eocr, edoc and their legacy formats by the magic
+// number at the start of the file. Other formats can be added with Register.
+package filetype
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Type represents a file type.
+type Type int
+
+const (
+	// Unknown represents an unknown file format.
+	Unknown Type = iota
+	// EOCR files contain OCR recognition results.
+	EOCR
+	// EDoc files contain prepared document data.
+	EDoc
+
+	// Supported legacy formats
+	// KiraOCR files contain a previous version of OCR recognition results.
+	KiraOCR
+	// KiraDoc files contain a previous version of prepared document data.
+	KiraDoc
+
+	// HeaderLength is the assumed length, in bytes, of the header of
+	// all Engine formats
+	HeaderLength = 10
+)
+
+// DefaultMIME is the MIME type of files of unknown type.
+const DefaultMIME = "application/octet-stream"
+
+// TypeInfo contains metadata about a file format.
+type TypeInfo struct {
+	Extension string
+	Magic     []byte
+	// MIME is the media type of the format.
+	MIME string
+}
+
+// types is a map of formats to information about them.
+var types = map[Type]*TypeInfo{
+	EOCR:    {Magic: []byte("eocr     \n"), Extension: "eocr", MIME: "application/x-eocr"},
+	EDoc:    {Magic: []byte("edoc     \n"), Extension: "edoc", MIME: "application/x-edoc"},
+	KiraDoc: {Magic: []byte("kiradoc  \n"), Extension: "kiradoc", MIME: "application/x-kiradoc"},
+	KiraOCR: {Magic: []byte("kiraocr  \n"), Extension: "kiraocr", MIME: "application/x-kiraocr"},
+}
+
+// typesMu guards types.
+var typesMu sync.RWMutex
+
+// lookup returns the information about t, or nil if t isn't registered.
+func lookup(t Type) *TypeInfo {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	return types[t]
+}
+
+// Lookup returns a copy of the information about t, and false if t isn't
+// registered. It is safe for concurrent use with Register.
+func Lookup(t Type) (TypeInfo, bool) {
+	info := lookup(t)
+	if info == nil {
+		return TypeInfo{}, false
+	}
+	c := *info
+	c.Magic = append([]byte(nil), info.Magic...)
+	return c, true
+}
+
+// Magic returns a copy of the magic number of t, or nil if t isn't
+// registered.
+func Magic(t Type) []byte {
+	info, _ := Lookup(t)
+	return info.Magic
+}
+
+func (t Type) String() string {
+	if info := lookup(t); info != nil {
+		return info.Extension
+	}
+	if t == Unknown {
+		return "unknown"
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// MIME returns the MIME type of t, or DefaultMIME if it has none.
+func (t Type) MIME() string {
+	if info := lookup(t); info != nil && info.MIME != "" {
+		return info.MIME
+	}
+	return DefaultMIME
+}
+
+// Register adds a file format, so that it is identified by Infer, InferReader
+// and InferBytes. The magic number must not be empty, and neither t nor its
+// magic number may already be registered. Register is safe for concurrent
+// use, but is usually called from an init function.
+func Register(t Type, info TypeInfo) error {
+	if t == Unknown {
+		return errors.New("can't register the unknown type")
+	}
+	if len(info.Magic) == 0 {
+		return fmt.Errorf("type %d has no magic number", int(t))
+	}
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	if _, ok := types[t]; ok {
+		return fmt.Errorf("type %d is already registered", int(t))
+	}
+	for _, other := range types {
+		if bytes.Equal(other.Magic, info.Magic) {
+			return fmt.Errorf("magic number %q is already registered for %s", info.Magic, other.Extension)
+		}
+	}
+	info.Magic = append([]byte(nil), info.Magic...)
+	types[t] = &info
+	return nil
+}
+
+// magicLength returns the length of the longest registered magic number.
+func magicLength() int {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	n := HeaderLength
+	for _, info := range types {
+		if len(info.Magic) > n {
+			n = len(info.Magic)
+		}
+	}
+	return n
+}
+
+// InferBytes identifies the file starting with data by magic number and
+// returns the type. When magic numbers overlap, the longest match wins. Data
+// too short to hold a magic number is Unknown.
+func InferBytes(data []byte) Type {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	format, matched := Unknown, 0
+	for t, info := range types {
+		if len(info.Magic) > matched && bytes.HasPrefix(data, info.Magic) {
+			format, matched = t, len(info.Magic)
+		}
+	}
+	return format
+}
+
+// InferReader identifies the stream r by magic number without needing it to
+// be seekable. It returns the type and a reader that yields the whole stream,
+// including the header read to identify it. A stream too short to hold a
+// magic number is Unknown; only read errors are returned.
+func InferReader(r io.Reader) (Type, io.Reader, error) {
+	header := make([]byte, magicLength())
+	n, err := io.ReadFull(r, header)
+	header = header[:n]
+	replay := io.MultiReader(bytes.NewReader(header), r)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return Unknown, replay, err
+	}
+	return InferBytes(header), replay, nil
+}
+
+// Infer identifies the supplied file by magic number and returns the type.
+func Infer(filename string) (Type, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Unknown, err
+	}
+	defer file.Close()
+	t, _, err := InferReader(file)
+	return t, err
+}
@@ -0,0 +1,120 @@
+package filetype
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfer(t *testing.T) {
+	format, err := Infer("../../testdata/jbs.eocr")
+	assert.NoError(t, err)
+	assert.Equal(t, EOCR, format)
+
+	format, err = Infer("../../testdata/jbs.kiraocr")
+	assert.NoError(t, err)
+	assert.Equal(t, KiraOCR, format)
+
+	format, err = Infer("../../testdata/jbs.edoc")
+	assert.NoError(t, err)
+	assert.Equal(t, EDoc, format)
+
+	format, err = Infer("../../testdata/jbs.kiradoc")
+	assert.NoError(t, err)
+	assert.Equal(t, KiraDoc, format)
+
+	format, err = Infer("../../testdata/dummy1.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, Unknown, format)
+
+	format, err = Infer("../../testdata/dummy2.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, Unknown, format)
+
+	format, err = Infer("testdata/missing")
+	assert.Error(t, err)
+	assert.Equal(t, Unknown, format)
+}
+
+func TestInferBytes(t *testing.T) {
+	tests := map[string]struct {
+		data string
+		want Type
+	}{
+		"eocr":      {data: "eocr     \npayload", want: EOCR},
+		"edoc":      {data: "edoc     \n", want: EDoc},
+		"kiraocr":   {data: "kiraocr  \npayload", want: KiraOCR},
+		"kiradoc":   {data: "kiradoc  \npayload", want: KiraDoc},
+		"short":     {data: "eocr", want: Unknown},
+		"empty":     {data: "", want: Unknown},
+		"different": {data: "aaaaaaaaaaaaaaaaaaaa", want: Unknown},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, InferBytes([]byte(tt.data)))
+		})
+	}
+}
+
+func TestInferReader(t *testing.T) {
+	for _, data := range []string{"kiraocr  \npayload", "small", ""} {
+		format, r, err := InferReader(strings.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, InferBytes([]byte(data)), format)
+		replayed, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(replayed))
+	}
+
+	readErr := errors.New("read failed")
+	_, _, err := InferReader(iotest.ErrReader(readErr))
+	assert.Equal(t, readErr, err)
+}
+
+func TestInferSmallFile(t *testing.T) {
+	format, err := Infer("../../testdata/dummy1.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, Unknown, format)
+}
+
+func TestRegister(t *testing.T) {
+	const zip Type = 100
+	t.Cleanup(func() {
+		typesMu.Lock()
+		delete(types, zip)
+		typesMu.Unlock()
+	})
+
+	require.NoError(t, Register(zip, TypeInfo{Extension: "zip", Magic: []byte("PK\x03\x04"), MIME: "application/zip"}))
+	assert.Equal(t, zip, InferBytes([]byte("PK\x03\x04\x14\x00")))
+	assert.Equal(t, "zip", zip.String())
+	assert.Equal(t, "application/zip", zip.MIME())
+	info, ok := Lookup(zip)
+	assert.True(t, ok)
+	assert.Equal(t, TypeInfo{Extension: "zip", Magic: []byte("PK\x03\x04"), MIME: "application/zip"}, info)
+	// The magic number returned can't change the registered one.
+	Magic(zip)[0] = 'X'
+	assert.Equal(t, []byte("PK\x03\x04"), Magic(zip))
+	_, ok = Lookup(101)
+	assert.False(t, ok)
+	assert.Nil(t, Magic(101))
+
+	assert.Error(t, Register(zip, TypeInfo{Magic: []byte("other")}))
+	assert.Error(t, Register(101, TypeInfo{Magic: []byte("PK\x03\x04")}))
+	assert.Error(t, Register(101, TypeInfo{}))
+	assert.Error(t, Register(Unknown, TypeInfo{Magic: []byte("other")}))
+}
+
+func TestMIME(t *testing.T) {
+	assert.Equal(t, "application/x-eocr", EOCR.MIME())
+	assert.Equal(t, "application/x-kiradoc", KiraDoc.MIME())
+	assert.Equal(t, DefaultMIME, Unknown.MIME())
+	assert.Equal(t, "unknown", Unknown.String())
+	assert.Equal(t, "Type(42)", Type(42).String())
+}
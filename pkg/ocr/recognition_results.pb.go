@@ -91,7 +91,7 @@ type Document struct {
 	// Required
 	Md5 []byte `protobuf:"bytes,18,opt,name=md5,proto3" json:"md5,omitempty"`
 	// Optional
-	// The valid values are : “omnipage” “word2ocr” ““(empty)
+	// The valid values are : “omnipage” “word2ocr” “pdf2ocr” ““(empty)
 	// An empty value is treated equivalently to “omnipage”.
 	Source string `protobuf:"bytes,20,opt,name=source,proto3" json:"source,omitempty"`
 }
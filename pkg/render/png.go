@@ -0,0 +1,129 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// WritePNG draws the page of doc with the given index, starting from 0, to w
+// as a PNG image. See Image.
+func WritePNG(w io.Writer, doc *ocr.Document, page int, opts Options) error {
+	img, err := Image(doc, page, opts)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// Image draws the page of doc with the given index, starting from 0.
+//
+// The image is sized by the page and opts.Scale. Each character is drawn with
+// the glyph of the built-in bitmap font stretched to its box, and borders are
+// at least one image pixel wide so that they stay visible when scaled down.
+func Image(doc *ocr.Document, page int, opts Options) (*image.RGBA, error) {
+	s, err := newScene(doc, page, opts)
+	if err != nil {
+		return nil, err
+	}
+	r := &raster{scale: opts.scale()}
+	r.img = image.NewRGBA(image.Rect(0, 0, r.px(float64(s.width)), r.px(float64(s.height))))
+	draw.Draw(r.img, r.img.Bounds(), image.NewUniform(paperColor), image.Point{}, draw.Src)
+
+	for _, c := range s.cells {
+		if bg, ok := cellColor(c); ok {
+			b := c.BoundingBox
+			r.fill(float64(b.X1), float64(b.Y1), float64(b.X2), float64(b.Y2), bg)
+		}
+	}
+	for _, c := range s.cells {
+		for _, e := range edges(c) {
+			r.stroke(e)
+		}
+	}
+	for _, g := range s.glyphs {
+		r.glyph(g)
+	}
+	for _, o := range s.outlines {
+		r.outline(o.box, o.color)
+	}
+	return r.img, nil
+}
+
+// raster draws on an image in page pixels.
+type raster struct {
+	img   *image.RGBA
+	scale float64
+}
+
+// px converts a page coordinate to an image coordinate.
+func (r *raster) px(v float64) int {
+	return int(math.Round(v * r.scale))
+}
+
+// fill fills a rectangle given in page pixels, making it at least one image
+// pixel wide and high.
+func (r *raster) fill(x1, y1, x2, y2 float64, c color.RGBA) {
+	rect := image.Rect(r.px(x1), r.px(y1), r.px(x2), r.px(y2))
+	if rect.Dx() == 0 {
+		rect.Max.X++
+	}
+	if rect.Dy() == 0 {
+		rect.Max.Y++
+	}
+	draw.Draw(r.img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// stroke draws a border centered on a side of a cell.
+func (r *raster) stroke(e edge) {
+	half := float64(e.width) / 2
+	r.fill(float64(e.x1)-half, float64(e.y1)-half, float64(e.x2)+half, float64(e.y2)+half, borderColor)
+}
+
+// outline draws the sides of a box one image pixel wide, inside the box.
+func (r *raster) outline(b *ocr.BoundingBox, c color.RGBA) {
+	rect := image.Rect(r.px(float64(b.X1)), r.px(float64(b.Y1)), r.px(float64(b.X2)), r.px(float64(b.Y2)))
+	if rect.Dx() == 0 {
+		rect.Max.X++
+	}
+	if rect.Dy() == 0 {
+		rect.Max.Y++
+	}
+	u := image.NewUniform(c)
+	for _, side := range []image.Rectangle{
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+1),
+		image.Rect(rect.Min.X, rect.Max.Y-1, rect.Max.X, rect.Max.Y),
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+1, rect.Max.Y),
+		image.Rect(rect.Max.X-1, rect.Min.Y, rect.Max.X, rect.Max.Y),
+	} {
+		draw.Draw(r.img, side, u, image.Point{}, draw.Src)
+	}
+}
+
+// glyph draws a character by dividing its box into the cells of the bitmap
+// font and filling those that are set. Characters without a glyph are drawn
+// as an empty box.
+func (r *raster) glyph(g glyph) {
+	bits, ok := bitmap(g.r)
+	if !ok {
+		r.outline(g.box, g.color)
+		return
+	}
+	b := g.box
+	w := float64(size(b.X1, b.X2)) / glyphWidth
+	h := float64(size(b.Y1, b.Y2)) / glyphHeight
+	for col, column := range bits {
+		for row := 0; row < glyphHeight; row++ {
+			if column&(1<<uint(row)) == 0 {
+				continue
+			}
+			x, y := float64(b.X1)+float64(col)*w, float64(b.Y1)+float64(row)*h
+			r.fill(x, y, x+w, y+h, g.color)
+		}
+	}
+}
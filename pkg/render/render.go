@@ -0,0 +1,177 @@
+// Package render draws the pages of an ocr.Document to SVG and PNG images,
+// to see what OCR output looks like.
+//
+// Pages are drawn at their size in pixels with every character at its
+// bounding box, over the shading and borders of table cells. The boxes of
+// characters, words and lines can be outlined, and characters can be colored
+// by their error. PNG images are drawn in pure Go with a built-in 5×7 bitmap
+// font stretched to each box, which has glyphs for printable ASCII only:
+// other characters are drawn as an empty box. SVG images use text elements
+// and the fonts of the viewer instead.
+package render
+
+import (
+	"image/color"
+
+	"github.com/zuvaai/eocr-utils/internal/layout"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// Colors of the parts of a page.
+var (
+	paperColor  = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	inkColor    = color.RGBA{A: 0xff}
+	borderColor = color.RGBA{R: 0x40, G: 0x40, B: 0x40, A: 0xff}
+	charColor   = color.RGBA{R: 0xdc, A: 0xff}
+	wordColor   = color.RGBA{G: 0xa0, A: 0xff}
+	lineColor   = color.RGBA{B: 0xff, A: 0xff}
+)
+
+// Options configures rendering.
+type Options struct {
+	// Scale multiplies the size of the image, so that 0.5 draws a 300 DPI
+	// page at 150 DPI. Zero means 1.
+	Scale float64
+	// CharBoxes, WordBoxes and LineBoxes outline the boxes of characters in
+	// red, words in green and lines in blue.
+	CharBoxes, WordBoxes, LineBoxes bool
+	// Heatmap colors characters from green, for no error, through yellow to
+	// red, for an error of 100.
+	Heatmap bool
+}
+
+func (o Options) scale() float64 {
+	if o.Scale <= 0 {
+		return 1
+	}
+	return o.Scale
+}
+
+// scene is what is drawn on a page, in the order it is drawn, in page
+// pixels.
+type scene struct {
+	width, height uint32
+	cells         []*ocr.TableCell
+	glyphs        []glyph
+	outlines      []outline
+}
+
+// glyph is a character drawn at its box.
+type glyph struct {
+	r      rune
+	box    *ocr.BoundingBox
+	color  color.RGBA
+	font   *ocr.Font
+	size   uint32
+	styles []ocr.FontStyle_Style
+}
+
+// outline is a box drawn with a stroke of one image pixel.
+type outline struct {
+	box   *ocr.BoundingBox
+	color color.RGBA
+}
+
+// newScene returns what is drawn on the page of doc with the given index.
+func newScene(doc *ocr.Document, page int, opts Options) (*scene, error) {
+	lines, err := layout.PageLines(doc, page)
+	if err != nil {
+		return nil, err
+	}
+	p := doc.Pages[page]
+	s := &scene{width: p.Width, height: p.Height}
+
+	tables := map[uint32]bool{}
+	for _, t := range doc.Tables {
+		if t != nil && int(t.PageNumber) == page {
+			tables[t.Id] = true
+		}
+	}
+	for _, c := range doc.TableCells {
+		if c != nil && c.BoundingBox != nil && tables[c.Id] {
+			s.cells = append(s.cells, c)
+		}
+	}
+
+	attrs := layout.NewAttrs(doc)
+	var charBoxes, wordBoxes, lineBoxes []outline
+	for _, l := range lines {
+		if l.Box != nil {
+			lineBoxes = append(lineBoxes, outline{l.Box, lineColor})
+		}
+		for _, w := range l.Words {
+			if w.Box != nil {
+				wordBoxes = append(wordBoxes, outline{w.Box, wordColor})
+			}
+			for _, g := range w.Glyphs {
+				box := g.Box()
+				if box == nil {
+					continue
+				}
+				c := inkColor
+				if opts.Heatmap {
+					c = heat(g.Error())
+				}
+				s.glyphs = append(s.glyphs, glyph{
+					r:      g.Rune,
+					box:    box,
+					color:  c,
+					font:   attrs.Font(g.Index),
+					size:   attrs.Size(g.Index),
+					styles: attrs.Styles(g.Index),
+				})
+				charBoxes = append(charBoxes, outline{box, charColor})
+			}
+		}
+	}
+	// Smaller boxes are drawn last so that they aren't hidden by the boxes
+	// containing them.
+	if opts.LineBoxes {
+		s.outlines = append(s.outlines, lineBoxes...)
+	}
+	if opts.WordBoxes {
+		s.outlines = append(s.outlines, wordBoxes...)
+	}
+	if opts.CharBoxes {
+		s.outlines = append(s.outlines, charBoxes...)
+	}
+	return s, nil
+}
+
+// heat returns the color of a character with the given error, from green
+// through yellow to red.
+func heat(err uint32) color.RGBA {
+	if err > 100 {
+		err = 100
+	}
+	if err <= 50 {
+		return color.RGBA{R: uint8(err * 0xdc / 50), G: 0xb4, A: 0xff}
+	}
+	return color.RGBA{R: 0xdc, G: uint8((100 - err) * 0xb4 / 50), A: 0xff}
+}
+
+// cellColor returns the background color of a cell, if it has one.
+func cellColor(c *ocr.TableCell) (color.RGBA, bool) {
+	bg := c.BackgroundColor
+	if bg == nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{R: channel(bg.R), G: channel(bg.G), B: channel(bg.B), A: 0xff}, true
+}
+
+func channel(v uint32) uint8 {
+	if v > 0xff {
+		return 0xff
+	}
+	return uint8(v)
+}
+
+// has reports whether styles contains s.
+func has(styles []ocr.FontStyle_Style, s ocr.FontStyle_Style) bool {
+	for _, t := range styles {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
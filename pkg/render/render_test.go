@@ -0,0 +1,131 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zuvaai/eocr-utils/pkg/eocr"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// testDocument returns a page with the line "Hi é" over a shaded table cell
+// and a second line "x" inside the cell. Characters are 10 pixels wide and
+// high.
+func testDocument(t *testing.T) *ocr.Document {
+	t.Helper()
+	doc, err := eocr.NewDocumentFromText("Hi é\nx", 4)
+	require.NoError(t, err)
+	doc.Characters[0].Error = 100
+	doc.Fonts = []*ocr.Font{{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Name: "Courier", Monospace: true}}
+	doc.FontStyles = []*ocr.FontStyle{{CharacterSpan: &ocr.Span{Start: 0, End: 2}, Style: ocr.BOLD}}
+	doc.Tables = []*ocr.Table{{Id: 1, PageNumber: 0}}
+	doc.TableCells = []*ocr.TableCell{{
+		Id:              1,
+		BoundingBox:     &ocr.BoundingBox{X1: 0, Y1: 10, X2: 40, Y2: 40},
+		BackgroundColor: &ocr.Color{R: 0, G: 0, B: 200},
+		TopBorderWidth:  2,
+	}}
+	return doc
+}
+
+func TestImage(t *testing.T) {
+	doc := testDocument(t)
+	blue := color.RGBA{B: 200, A: 0xff}
+
+	tests := map[string]struct {
+		opts       Options
+		wantBounds image.Rectangle
+		wantPixels map[image.Point]color.RGBA
+	}{
+		"default": {
+			wantBounds: image.Rect(0, 0, int(doc.Pages[0].Width), int(doc.Pages[0].Height)),
+			wantPixels: map[image.Point]color.RGBA{
+				// The left column of H.
+				{0, 0}: inkColor,
+				{1, 9}: inkColor,
+				{5, 5}: inkColor,
+				{3, 0}: paperColor,
+				// é has no glyph and is drawn as a box.
+				{30, 0}: inkColor,
+				{35, 5}: paperColor,
+				// The border is centered on the top of the cell.
+				{20, 9}:  borderColor,
+				{20, 10}: borderColor,
+				{20, 11}: blue,
+				// The bottom of x is drawn over the cell.
+				{0, 19}:  inkColor,
+				{20, 39}: blue,
+				{20, 40}: paperColor,
+			},
+		},
+		"heatmap and boxes": {
+			opts:       Options{Heatmap: true, CharBoxes: true, LineBoxes: true},
+			wantBounds: image.Rect(0, 0, int(doc.Pages[0].Width), int(doc.Pages[0].Height)),
+			wantPixels: map[image.Point]color.RGBA{
+				{1, 9}: {R: 0xdc, A: 0xff},
+				{5, 5}: {R: 0xdc, A: 0xff},
+				// Character boxes are drawn over the line box.
+				{5, 0}:  charColor,
+				{9, 5}:  charColor,
+				{25, 0}: lineColor,
+				{25, 9}: lineColor,
+			},
+		},
+		"scaled": {
+			opts:       Options{Scale: 0.5},
+			wantBounds: image.Rect(0, 0, int(doc.Pages[0].Width)/2, int(doc.Pages[0].Height)/2),
+			wantPixels: map[image.Point]color.RGBA{
+				{0, 0}:  inkColor,
+				{10, 5}: borderColor,
+				{10, 6}: blue,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			img, err := Image(doc, 0, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBounds, img.Bounds())
+			for p, want := range tt.wantPixels {
+				assert.Equal(t, want, img.RGBAAt(p.X, p.Y), p)
+			}
+		})
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	doc := testDocument(t)
+	var buf bytes.Buffer
+	require.NoError(t, WritePNG(&buf, doc, 0, Options{}))
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int(doc.Pages[0].Width), img.Bounds().Dx())
+
+	assert.EqualError(t, WritePNG(&buf, doc, 1, Options{}), "page 1 does not exist in a document of 1 pages")
+}
+
+func TestWriteSVG(t *testing.T) {
+	doc := testDocument(t)
+	var buf bytes.Buffer
+	require.NoError(t, WriteSVG(&buf, doc, 0, Options{Scale: 0.5, Heatmap: true, WordBoxes: true}))
+	out := buf.String()
+
+	width, height := doc.Pages[0].Width, doc.Pages[0].Height
+	assert.True(t, strings.HasPrefix(out, `<svg xmlns="http://www.w3.org/2000/svg" width="`), out)
+	assert.Contains(t, out, `viewBox="0 0 `+num(float64(width))+` `+num(float64(height))+`"`)
+	assert.Contains(t, out, `<rect x="0" y="10" width="40" height="30" fill="#0000c8"/>`)
+	assert.Contains(t, out, `<line x1="0" y1="10" x2="40" y2="10" stroke="#404040" stroke-width="2"/>`)
+	assert.Contains(t, out, `<text x="0" y="8" font-size="10" textLength="10" lengthAdjust="spacingAndGlyphs" font-family="&#39;Courier&#39;, monospace" fill="#dc0000" font-weight="bold">H</text>`)
+	assert.Contains(t, out, `<text x="30" y="8" font-size="10" textLength="10" lengthAdjust="spacingAndGlyphs" font-family="sans-serif" fill="#00b400">é</text>`)
+	assert.Contains(t, out, `<rect x="0" y="0" width="20" height="10" fill="none" stroke="#00a000" stroke-width="1" vector-effect="non-scaling-stroke"/>`)
+	assert.NotContains(t, out, "<rect x=\"0\" y=\"0\" width=\"10\"")
+	assert.True(t, strings.HasSuffix(out, "</svg>\n"))
+}
@@ -0,0 +1,150 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/zuvaai/eocr-utils/internal/layout"
+	"github.com/zuvaai/eocr-utils/pkg/ocr"
+)
+
+// WriteSVG draws the page of doc with the given index, starting from 0, to w
+// as an SVG image.
+//
+// The image is sized by the page and opts.Scale, and its user units are page
+// pixels. Each character is a text element with the height of its box as font
+// size, stretched to the width of the box. It uses the font of the character,
+// or a generic serif, sans-serif or monospace family, and the bold, italic,
+// underline and strikethrough styles.
+func WriteSVG(w io.Writer, doc *ocr.Document, page int, opts Options) error {
+	s, err := newScene(doc, page, opts)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	scale := opts.scale()
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %d %d">`+"\n",
+		num(float64(s.width)*scale), num(float64(s.height)*scale), s.width, s.height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="%s"/>`+"\n", s.width, s.height, hex(paperColor))
+
+	for _, c := range s.cells {
+		if bg, ok := cellColor(c); ok {
+			b := c.BoundingBox
+			fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", b.X1, b.Y1, size(b.X1, b.X2), size(b.Y1, b.Y2), hex(bg))
+		}
+	}
+	for _, c := range s.cells {
+		for _, e := range edges(c) {
+			fmt.Fprintf(bw, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="%d"/>`+"\n", e.x1, e.y1, e.x2, e.y2, hex(borderColor), e.width)
+		}
+	}
+
+	for _, g := range s.glyphs {
+		b := g.box
+		height := size(b.Y1, b.Y2)
+		fmt.Fprintf(bw, `<text x="%d" y="%s" font-size="%d" textLength="%d" lengthAdjust="spacingAndGlyphs" font-family="%s" fill="%s"`,
+			b.X1, num(float64(b.Y1)+layout.Ascent*float64(height)), height, size(b.X1, b.X2), fontFamily(g.font), hex(g.color))
+		if has(g.styles, ocr.BOLD) {
+			bw.WriteString(` font-weight="bold"`)
+		}
+		if has(g.styles, ocr.ITALIC) {
+			bw.WriteString(` font-style="italic"`)
+		}
+		var decorations []string
+		if has(g.styles, ocr.UNDERLINE) {
+			decorations = append(decorations, "underline")
+		}
+		if has(g.styles, ocr.STRIKETHROUGH) {
+			decorations = append(decorations, "line-through")
+		}
+		if decorations != nil {
+			fmt.Fprintf(bw, ` text-decoration="%s"`, strings.Join(decorations, " "))
+		}
+		fmt.Fprintf(bw, ">%s</text>\n", html.EscapeString(string(printable(g.r))))
+	}
+
+	for _, o := range s.outlines {
+		b := o.box
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="%s" stroke-width="1" vector-effect="non-scaling-stroke"/>`+"\n",
+			b.X1, b.Y1, size(b.X1, b.X2), size(b.Y1, b.Y2), hex(o.color))
+	}
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// edge is a border of a table cell.
+type edge struct {
+	x1, y1, x2, y2, width uint32
+}
+
+// edges returns the borders of a cell with a width, centered on its sides.
+func edges(c *ocr.TableCell) []edge {
+	b := c.BoundingBox
+	var es []edge
+	for _, e := range []edge{
+		{b.X1, b.Y1, b.X1, b.Y2, c.LeftBorderWidth},
+		{b.X2, b.Y1, b.X2, b.Y2, c.RightBorderWidth},
+		{b.X1, b.Y1, b.X2, b.Y1, c.TopBorderWidth},
+		{b.X1, b.Y2, b.X2, b.Y2, c.BottomBorderWidth},
+	} {
+		if e.width > 0 {
+			es = append(es, e)
+		}
+	}
+	return es
+}
+
+// fontFamily returns the font-family of text in the given font.
+func fontFamily(f *ocr.Font) string {
+	generic := "sans-serif"
+	switch {
+	case f == nil:
+	case f.Monospace:
+		generic = "monospace"
+	case f.Serif:
+		generic = "serif"
+	}
+	if f == nil || f.Name == "" {
+		return generic
+	}
+	name := strings.Map(func(r rune) rune {
+		if r == '\'' || r == '"' || r == '\\' {
+			return -1
+		}
+		return r
+	}, f.Name)
+	return html.EscapeString("'" + name + "', " + generic)
+}
+
+// printable replaces characters that can't appear in XML.
+func printable(r rune) rune {
+	if unicode.IsControl(r) || r == 0xfffe || r == 0xffff || (r >= 0xd800 && r < 0xe000) {
+		return unicode.ReplacementChar
+	}
+	return r
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// num formats a coordinate with at most two decimals.
+func num(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// size returns the size of a side from a to b, which is zero if b is before
+// a.
+func size(a, b uint32) uint32 {
+	if b < a {
+		return 0
+	}
+	return b - a
+}